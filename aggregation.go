@@ -0,0 +1,195 @@
+package tesoql
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// name returns the name of the metric in the results.
+func (m Metric) name() string {
+	if m.Alias != "" {
+		return m.Alias
+	}
+	if m.Field == "" {
+		return strings.ToLower(m.Function)
+	}
+	return strings.ToLower(m.Function) + "_" + m.Field
+}
+
+// validateAggregations checks that the group and metric fields of the Aggregations in
+// the JsonMap are aggregation fields, that the metric functions are supported, that the
+// names of the groups and metrics are unique, and that the sort conditions refer to them.
+// Projection and cursor pagination cannot be combined with aggregations.
+//
+// Returns:
+//
+// - *ErrorResponseDTO: An error response if validation fails, or nil if validation passes.
+func (jm *JsonMap) validateAggregations(fm *FieldsMap) *ErrorResponseDTO {
+	aggregations := jm.Aggregations
+	if aggregations == nil {
+		return nil
+	}
+	if len(aggregations.GroupBy) == 0 && len(aggregations.Metrics) == 0 {
+		return newResponse(TESOQL_VALIDATION_ERROR, "Aggregations must have at least one 'groupBy' field or metric.", AGGREGATION_ERR_CODE).withField("aggregations")
+	}
+	if len(jm.ProjectionFields) > 0 {
+		return newResponse(TESOQL_VALIDATION_ERROR, "Projection fields cannot be combined with aggregations.", AGGREGATION_ERR_CODE).withField("projectionFields")
+	}
+	if jm.Pagination.Cursor != "" {
+		return newResponse(TESOQL_VALIDATION_ERROR, "Cursor pagination is not supported with aggregations.", AGGREGATION_ERR_CODE).withField("pagination.cursor")
+	}
+
+	var aggregationFields map[string]string
+	if fm != nil {
+		aggregationFields = fm.AggregationFields
+	}
+	names := make(map[string]bool)
+	for _, field := range aggregations.GroupBy {
+		if _, exists := aggregationFields[field]; !exists {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Field : '%v' cannot be aggregated.", field), AGGREGATION_ERR_CODE).withField("aggregations.groupBy." + field)
+		}
+		if names[field] {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Field : '%v' is grouped by more than once.", field), AGGREGATION_ERR_CODE).withField("aggregations.groupBy." + field)
+		}
+		names[field] = true
+	}
+	for i, metric := range aggregations.Metrics {
+		path := fmt.Sprintf("aggregations.metrics.%d", i)
+		if err := validateMetric(aggregationFields, metric, AGGREGATION_ERR_CODE); err != nil {
+			return err.withField(path)
+		}
+		name := metric.name()
+		if names[name] {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Name '%v' is used more than once in aggregations.", name), AGGREGATION_ERR_CODE).withField(path)
+		}
+		names[name] = true
+	}
+
+	for _, sortInput := range jm.SortConditions {
+		if !names[sortInput.Field] {
+			return newResponse(
+				TESOQL_VALIDATION_ERROR,
+				fmt.Sprintf("Field : '%v' is not a group or a metric of the aggregations.", sortInput.Field),
+				SORTABLE_ERR_CODE).withField("sortConditions." + sortInput.Field)
+		}
+		if sortInput.SortCondition != "ASC" && sortInput.SortCondition != "DESC" {
+			return newResponse(
+				TESOQL_VALIDATION_ERROR,
+				"Sort condition operators cannot be different than 'ASC' or 'DESC' (type : string)!",
+				SORTABLE_ERR_CODE).withField("sortConditions." + sortInput.Field)
+		}
+	}
+	return nil
+}
+
+// validateMetric checks that the function of the metric is supported, that its field is
+// an aggregation field and that its name is valid. Errors get the given code.
+func validateMetric(aggregationFields map[string]string, metric Metric, code int) *ErrorResponseDTO {
+	switch metric.Function {
+	case METRIC_COUNT, METRIC_SUM, METRIC_AVG, METRIC_MIN, METRIC_MAX:
+	default:
+		return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Metric function '%v' is not supported, it must be one of COUNT, SUM, AVG, MIN or MAX.", metric.Function), code)
+	}
+	if metric.Field == "" && metric.Function != METRIC_COUNT {
+		return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Metric %s requires a field.", metric.Function), code)
+	}
+	if metric.Field != "" {
+		if _, exists := aggregationFields[metric.Field]; !exists {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Field : '%v' cannot be aggregated.", metric.Field), code)
+		}
+	}
+	if name := metric.name(); !identifierPattern.MatchString(name) {
+		return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Metric name '%v' is not valid, letters, digits and underscores are allowed.", name), code)
+	}
+	return nil
+}
+
+// aggregationSort returns the sort order of the groups: the requested sort conditions on
+// group and metric names, followed by the other group fields in ascending order, which
+// identify a group and make the order total.
+func aggregationSort(jm *JsonMap) []keysetColumn {
+	var columns []keysetColumn
+	sorted := make(map[string]bool)
+	for _, sortInput := range jm.SortConditions {
+		columns = append(columns, keysetColumn{column: sortInput.Field, desc: sortInput.SortCondition == "DESC"})
+		sorted[sortInput.Field] = true
+	}
+	for _, field := range jm.Aggregations.GroupBy {
+		if !sorted[field] {
+			columns = append(columns, keysetColumn{column: field})
+		}
+	}
+	return columns
+}
+
+// getSqlAggregation returns the select list of the groups and metrics, each under its
+// name, and the GROUP BY clause.
+func getSqlAggregation(fm *FieldsMap, jm *JsonMap, d *Dialect) (string, string) {
+	var fields, groups []string
+	for _, field := range jm.Aggregations.GroupBy {
+		column := d.quoteIdentifier(fm.AggregationFields[field])
+		fields = append(fields, fmt.Sprintf("%s AS %s", column, d.quoteIdentifier(field)))
+		groups = append(groups, column)
+	}
+	for _, metric := range jm.Aggregations.Metrics {
+		fields = append(fields, fmt.Sprintf("%s AS %s", sqlMetric(fm, metric, d), d.quoteIdentifier(metric.name())))
+	}
+	groupBy := ""
+	if len(groups) > 0 {
+		groupBy = fmt.Sprintf(" GROUP BY %s", strings.Join(groups, ", "))
+	}
+	return strings.Join(fields, ", "), groupBy
+}
+
+// sqlMetric returns the aggregate function call of the metric.
+func sqlMetric(fm *FieldsMap, metric Metric, d *Dialect) string {
+	argument := "*"
+	if metric.Field != "" {
+		argument = d.quoteIdentifier(fm.AggregationFields[metric.Field])
+	}
+	return fmt.Sprintf("%s(%s)", metric.Function, argument)
+}
+
+// getMongoGroup returns the $group stage of the aggregations, the group fields being
+// the fields of its _id, and the $project stage moving them up next to the metrics.
+func getMongoGroup(fm *FieldsMap, jm *JsonMap) (*bson.D, *bson.D) {
+	var id interface{}
+	project := bson.D{{"_id", 0}}
+	if len(jm.Aggregations.GroupBy) > 0 {
+		groupID := bson.D{}
+		for _, field := range jm.Aggregations.GroupBy {
+			groupID = append(groupID, bson.E{Key: field, Value: "$" + fm.AggregationFields[field]})
+			project = append(project, bson.E{Key: field, Value: "$_id." + field})
+		}
+		id = groupID
+	}
+
+	group := bson.D{{"_id", id}}
+	for _, metric := range jm.Aggregations.Metrics {
+		group = append(group, bson.E{Key: metric.name(), Value: mongoAccumulator(fm, metric)})
+		project = append(project, bson.E{Key: metric.name(), Value: 1})
+	}
+	return &group, &project
+}
+
+// mongoAccumulator returns the $group accumulator of the metric.
+func mongoAccumulator(fm *FieldsMap, metric Metric) bson.D {
+	field := "$" + fm.AggregationFields[metric.Field]
+	switch metric.Function {
+	case METRIC_COUNT:
+		if metric.Field == "" {
+			return bson.D{{"$sum", 1}}
+		}
+		// COUNT(field) counts the values that are neither null nor missing
+		return bson.D{{"$sum", bson.D{{"$cond", bson.A{bson.D{{"$gt", bson.A{field, nil}}}, 1, 0}}}}}
+	case METRIC_SUM:
+		return bson.D{{"$sum", field}}
+	case METRIC_AVG:
+		return bson.D{{"$avg", field}}
+	case METRIC_MIN:
+		return bson.D{{"$min", field}}
+	}
+	return bson.D{{"$max", field}}
+}
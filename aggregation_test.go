@@ -0,0 +1,162 @@
+package tesoql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSqlAggregation(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields:   map[string]string{"amount": "amount"},
+		AggregationFields: map[string]string{"status": "order_status", "amount": "amount"},
+	}
+	tests := []struct {
+		name      string
+		dialect   *Dialect
+		jsonMap   *JsonMap
+		statement string
+		count     string
+		args      []interface{}
+	}{
+		{
+			name:    "groups",
+			dialect: PostgresDialect,
+			jsonMap: &JsonMap{
+				Conditions: map[string]ConditionOperators{"amount": {GreaterThan: 5}},
+				Aggregations: &Aggregations{GroupBy: []string{"status"}, Metrics: []Metric{
+					{Function: METRIC_SUM, Field: "amount", Alias: "revenue"},
+					{Function: METRIC_COUNT},
+					{Function: METRIC_COUNT, Field: "amount"},
+				}},
+				SortConditions: []SortInput{{Field: "revenue", SortCondition: "DESC"}},
+				Pagination:     Pagination{Limit: 2, Offset: 4},
+			},
+			statement: `SELECT "order_status" AS "status", SUM("amount") AS "revenue", COUNT(*) AS "count", COUNT("amount") AS "count_amount" FROM "orders" WHERE 1=1 AND "amount" > $1 GROUP BY "order_status" ORDER BY "revenue" DESC, "status" ASC LIMIT 2 OFFSET 4`,
+			count:     `SELECT COUNT(*) FROM (SELECT COUNT(*) AS tesoql_group_size FROM "orders" WHERE 1=1 AND "amount" > $1 GROUP BY "order_status") tesoql_groups`,
+			args:      []interface{}{5},
+		},
+		{
+			name:      "global metrics",
+			dialect:   SqlServerDialect,
+			jsonMap:   &JsonMap{Aggregations: &Aggregations{Metrics: []Metric{{Function: METRIC_MIN, Field: "amount"}}}},
+			statement: `SELECT MIN([amount]) AS [min_amount] FROM [orders] WHERE 1=1`,
+			count:     `SELECT COUNT(*) FROM (SELECT COUNT(*) AS tesoql_group_size FROM [orders] WHERE 1=1) tesoql_groups`,
+			args:      []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.jsonMap.NewSqlQueryWithDialect(fm, tt.dialect)
+			if statement := query.statement("orders", false); statement != tt.statement {
+				t.Errorf("statement = %s\nwant        %s", statement, tt.statement)
+			}
+			count, countArgs := query.CountQuery("orders")
+			if count != tt.count {
+				t.Errorf("count = %s\nwant    %s", count, tt.count)
+			}
+			if len(countArgs) != len(tt.args) || (len(tt.args) > 0 && !reflect.DeepEqual(countArgs, tt.args)) {
+				t.Errorf("count args = %v, want %v", countArgs, tt.args)
+			}
+		})
+	}
+}
+
+func TestMongoAggregationPipeline(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields:   map[string]string{"amount": "amount"},
+		AggregationFields: map[string]string{"status": "order_status", "amount": "amount"},
+	}
+	tests := []struct {
+		name     string
+		jsonMap  *JsonMap
+		pipeline string
+		count    string
+	}{
+		{
+			name: "groups",
+			jsonMap: &JsonMap{
+				Conditions: map[string]ConditionOperators{"amount": {GreaterThan: 5}},
+				Aggregations: &Aggregations{GroupBy: []string{"status"}, Metrics: []Metric{
+					{Function: METRIC_SUM, Field: "amount", Alias: "revenue"},
+					{Function: METRIC_COUNT},
+					{Function: METRIC_COUNT, Field: "amount"},
+				}},
+				SortConditions: []SortInput{{Field: "revenue", SortCondition: "DESC"}},
+				Pagination:     Pagination{Limit: 2, Offset: 4},
+			},
+			pipeline: `{"v":[{"$match":{"$and":[{"$and":[{"amount":{"$gt":5}}]}]}},` +
+				`{"$group":{"_id":{"status":"$order_status"},"revenue":{"$sum":"$amount"},"count":{"$sum":1},"count_amount":{"$sum":{"$cond":[{"$gt":["$amount",null]},1,0]}}}},` +
+				`{"$project":{"_id":0,"status":"$_id.status","revenue":1,"count":1,"count_amount":1}},{"$sort":{"revenue":-1,"status":1}},{"$skip":4},{"$limit":2}]}`,
+			count: `{"v":[{"$match":{"$and":[{"$and":[{"amount":{"$gt":5}}]}]}},{"$group":{"_id":{"status":"$order_status"}}},{"$count":"count"}]}`,
+		},
+		{
+			name:     "global metrics",
+			jsonMap:  &JsonMap{Aggregations: &Aggregations{Metrics: []Metric{{Function: METRIC_MIN, Field: "amount"}}}},
+			pipeline: `{"v":[{"$match":{}},{"$group":{"_id":null,"min_amount":{"$min":"$amount"}}},{"$project":{"_id":0,"min_amount":1}}]}`,
+			count:    `{"v":[{"$match":{}},{"$group":{"_id":null}},{"$count":"count"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.jsonMap.NewMongoQuery(fm)
+			if pipeline := mongoJSON(t, query.Pipeline()); pipeline != tt.pipeline {
+				t.Errorf("pipeline = %s\nwant       %s", pipeline, tt.pipeline)
+			}
+			if count := mongoJSON(t, query.CountPipeline()); count != tt.count {
+				t.Errorf("count pipeline = %s\nwant             %s", count, tt.count)
+			}
+		})
+	}
+	if pipeline := (&JsonMap{}).NewMongoQuery(fm).Pipeline(); pipeline != nil {
+		t.Errorf("pipeline without aggregations = %v, want nil", pipeline)
+	}
+}
+
+func TestValidateAggregations(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields:   map[string]string{"amount": "amount"},
+		AggregationFields: map[string]string{"status": "order_status", "amount": "amount"},
+	}
+	tests := []struct {
+		name    string
+		jsonMap *JsonMap
+		field   string
+		code    int
+	}{
+		{name: "valid", jsonMap: &JsonMap{Aggregations: &Aggregations{GroupBy: []string{"status"}, Metrics: []Metric{{Function: METRIC_COUNT}}}, SortConditions: []SortInput{{Field: "count", SortCondition: "DESC"}}}},
+		{name: "empty", jsonMap: &JsonMap{Aggregations: &Aggregations{}}, field: "aggregations", code: AGGREGATION_ERR_CODE},
+		{name: "projection", jsonMap: &JsonMap{Aggregations: &Aggregations{GroupBy: []string{"status"}}, ProjectionFields: []string{"id"}}, field: "projectionFields", code: AGGREGATION_ERR_CODE},
+		{name: "cursor", jsonMap: &JsonMap{Aggregations: &Aggregations{GroupBy: []string{"status"}}, Pagination: Pagination{Cursor: "abc"}}, field: "pagination.cursor", code: AGGREGATION_ERR_CODE},
+		{name: "unknown group", jsonMap: &JsonMap{Aggregations: &Aggregations{GroupBy: []string{"name"}}}, field: "aggregations.groupBy.name", code: AGGREGATION_ERR_CODE},
+		{name: "repeated group", jsonMap: &JsonMap{Aggregations: &Aggregations{GroupBy: []string{"status", "status"}}}, field: "aggregations.groupBy.status", code: AGGREGATION_ERR_CODE},
+		{name: "unknown function", jsonMap: &JsonMap{Aggregations: &Aggregations{Metrics: []Metric{{Function: "MEDIAN", Field: "amount"}}}}, field: "aggregations.metrics.0", code: AGGREGATION_ERR_CODE},
+		{name: "metric without field", jsonMap: &JsonMap{Aggregations: &Aggregations{Metrics: []Metric{{Function: METRIC_SUM}}}}, field: "aggregations.metrics.0", code: AGGREGATION_ERR_CODE},
+		{name: "metric alias", jsonMap: &JsonMap{Aggregations: &Aggregations{Metrics: []Metric{{Function: METRIC_SUM, Field: "amount", Alias: "total; --"}}}}, field: "aggregations.metrics.0", code: AGGREGATION_ERR_CODE},
+		{
+			name:    "repeated name",
+			jsonMap: &JsonMap{Aggregations: &Aggregations{GroupBy: []string{"status"}, Metrics: []Metric{{Function: METRIC_COUNT, Alias: "status"}}}},
+			field:   "aggregations.metrics.0",
+			code:    AGGREGATION_ERR_CODE,
+		},
+		{
+			name:    "sort on other field",
+			jsonMap: &JsonMap{Aggregations: &Aggregations{GroupBy: []string{"status"}}, SortConditions: []SortInput{{Field: "amount", SortCondition: "ASC"}}},
+			field:   "sortConditions.amount",
+			code:    SORTABLE_ERR_CODE,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.jsonMap.validateAggregations(fm)
+			if tt.code == 0 {
+				if err != nil {
+					t.Errorf("validateAggregations() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.ErrorCode != tt.code || err.Field != tt.field {
+				t.Errorf("validateAggregations() = %v, want %d on %s", err, tt.code, tt.field)
+			}
+		})
+	}
+}
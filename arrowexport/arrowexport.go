@@ -0,0 +1,216 @@
+// Package arrowexport exports tesoql query results in columnar formats: Apache Arrow
+// record batches and Parquet files. It lives in its own package so that the Arrow
+// packages are only compiled into the applications that import it.
+package arrowexport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/tesodev-com/tesoql"
+)
+
+// DEFAULT_BATCH_SIZE is the number of rows of a record batch when Options.BatchSize is zero.
+const DEFAULT_BATCH_SIZE = 10000
+
+// Options tune the export.
+type Options struct {
+	BatchSize int              // Number of rows of a record batch, and of a Parquet row group.
+	Allocator memory.Allocator // Allocator of the record batches, memory.DefaultAllocator when nil.
+}
+
+// Schema returns the Arrow schema of the columns: COLUMN_STRING as utf8, COLUMN_INT64 as
+// int64, COLUMN_FLOAT64 as float64, COLUMN_BOOL as boolean, COLUMN_TIME as a UTC
+// microsecond timestamp, COLUMN_BINARY as binary, and COLUMN_DECIMAL as a decimal128 of the
+// precision and scale of the column, or as utf8 when its precision is unknown or above 38
+// digits. Every field is nullable.
+func Schema(columns []tesoql.Column) *arrow.Schema {
+	fields := make([]arrow.Field, len(columns))
+	for i, column := range columns {
+		fields[i] = arrow.Field{Name: column.Name, Type: arrowType(column), Nullable: true}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// WriteRecords streams the records matching the JsonMap, as Service.Stream does, and
+// hands them to fn as Arrow record batches of Options.BatchSize rows. The column types
+// come from tesoql.Rows.Columns: inferred from the SQL column types, declared in
+// FieldsMap.FieldTypes for Mongo. A record batch is released once fn returns: fn must
+// Retain it to keep it.
+//
+// Example usage:
+//
+//	err := arrowexport.WriteRecords(ctx, tesoQL.Service, &jsonMapVariable, arrowexport.Options{}, func(record arrow.Record) error {
+//		return flightWriter.Write(record)
+//	})
+//
+// Returns:
+//
+// - error: The error returned by fn, which stops the export, or an *tesoql.ErrorResponseDTO if the records could not be retrieved or converted.
+func WriteRecords(ctx context.Context, service *tesoql.Service, jsonMap *tesoql.JsonMap, opts Options, fn func(record arrow.Record) error) error {
+	return export(ctx, service, jsonMap, opts, func(*arrow.Schema) (func(arrow.Record) error, func() error, error) {
+		return fn, func() error { return nil }, nil
+	})
+}
+
+// WriteParquet streams the records matching the JsonMap to w as a Parquet file, compressed
+// with Snappy, with a row group per Options.BatchSize rows. The Arrow schema is stored in
+// the file metadata.
+//
+// Example usage:
+//
+//	file, _ := os.Create("orders.parquet")
+//	defer file.Close()
+//	err := arrowexport.WriteParquet(ctx, file, tesoQL.Service, &jsonMapVariable, arrowexport.Options{})
+//
+// Returns:
+//
+// - error: An *tesoql.ErrorResponseDTO if the records could not be retrieved, converted or written.
+func WriteParquet(ctx context.Context, w io.Writer, service *tesoql.Service, jsonMap *tesoql.JsonMap, opts Options) error {
+	return export(ctx, service, jsonMap, opts, func(schema *arrow.Schema) (func(arrow.Record) error, func() error, error) {
+		props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy), parquet.WithAllocator(allocator(opts)))
+		writer, err := pqarrow.NewFileWriter(schema, w, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+		if err != nil {
+			return nil, nil, err
+		}
+		return writer.Write, writer.Close, nil
+	})
+}
+
+// export streams the records into record batches, handed to the writer opened for their schema.
+func export(ctx context.Context, service *tesoql.Service, jsonMap *tesoql.JsonMap, opts Options, open func(schema *arrow.Schema) (write func(arrow.Record) error, close func() error, err error)) error {
+	rows, errDTO := service.Stream(ctx, jsonMap)
+	if errDTO != nil {
+		return errDTO
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	schema := Schema(columns)
+	write, closeWriter, err := open(schema)
+	if err != nil {
+		return exportError(err)
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DEFAULT_BATCH_SIZE
+	}
+	builder := array.NewRecordBuilder(allocator(opts), schema)
+	defer builder.Release()
+
+	flush := func() error {
+		record := builder.NewRecord()
+		defer record.Release()
+		return write(record)
+	}
+	size := 0
+	for rows.Next() {
+		record := rows.Row()
+		for i, column := range columns {
+			value, err := column.Value(record)
+			if err != nil {
+				return err
+			}
+			if err := appendValue(builder.Field(i), value); err != nil {
+				return &tesoql.ErrorResponseDTO{ErrorType: tesoql.TESOQL_DECODE_ERROR, ErrorMsg: fmt.Sprintf("Column '%s': %v", column.Name, err),
+					ErrorCode: tesoql.RESULT_DECODE_ERR_CODE, Field: column.Name, Cause: err}
+			}
+		}
+		size++
+		if size == batchSize {
+			if err := flush(); err != nil {
+				return exportError(err)
+			}
+			size = 0
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if size > 0 {
+		if err := flush(); err != nil {
+			return exportError(err)
+		}
+	}
+	if err := closeWriter(); err != nil {
+		return exportError(err)
+	}
+	return nil
+}
+
+func arrowType(column tesoql.Column) arrow.DataType {
+	switch column.Kind {
+	case tesoql.COLUMN_INT64:
+		return arrow.PrimitiveTypes.Int64
+	case tesoql.COLUMN_FLOAT64:
+		return arrow.PrimitiveTypes.Float64
+	case tesoql.COLUMN_BOOL:
+		return arrow.FixedWidthTypes.Boolean
+	case tesoql.COLUMN_TIME:
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+	case tesoql.COLUMN_BINARY:
+		return arrow.BinaryTypes.Binary
+	case tesoql.COLUMN_DECIMAL:
+		if column.Precision > 0 && column.Precision <= 38 {
+			return &arrow.Decimal128Type{Precision: int32(column.Precision), Scale: int32(column.Scale)}
+		}
+	}
+	return arrow.BinaryTypes.String
+}
+
+// appendValue appends a value converted by tesoql.Column.Value to the builder of its column.
+// It fails for a decimal that does not fit the precision of its column.
+func appendValue(builder array.Builder, value interface{}) error {
+	if value == nil {
+		builder.AppendNull()
+		return nil
+	}
+	switch b := builder.(type) {
+	case *array.StringBuilder:
+		b.Append(value.(string))
+	case *array.Int64Builder:
+		b.Append(value.(int64))
+	case *array.Float64Builder:
+		b.Append(value.(float64))
+	case *array.BooleanBuilder:
+		b.Append(value.(bool))
+	case *array.TimestampBuilder:
+		b.Append(arrow.Timestamp(value.(time.Time).UnixMicro()))
+	case *array.BinaryBuilder:
+		b.Append(value.([]byte))
+	case *array.Decimal128Builder:
+		dataType := b.Type().(*arrow.Decimal128Type)
+		n, err := decimal128.FromString(value.(string), dataType.Precision, dataType.Scale)
+		if err != nil {
+			return err
+		}
+		b.Append(n)
+	default:
+		panic(fmt.Sprintf("arrowexport: unexpected builder %T", builder))
+	}
+	return nil
+}
+
+func allocator(opts Options) memory.Allocator {
+	if opts.Allocator != nil {
+		return opts.Allocator
+	}
+	return memory.DefaultAllocator
+}
+
+func exportError(err error) error {
+	return &tesoql.ErrorResponseDTO{ErrorType: tesoql.TESOQL_EXPORT_ERROR, ErrorMsg: err.Error(), ErrorCode: tesoql.EXPORT_WRITE_ERR_CODE, Cause: err}
+}
@@ -0,0 +1,81 @@
+package arrowexport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/tesodev-com/tesoql"
+)
+
+func TestSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   tesoql.Column
+		dataType arrow.DataType
+	}{
+		{name: "string", column: tesoql.Column{Kind: tesoql.COLUMN_STRING}, dataType: arrow.BinaryTypes.String},
+		{name: "int64", column: tesoql.Column{Kind: tesoql.COLUMN_INT64}, dataType: arrow.PrimitiveTypes.Int64},
+		{name: "float64", column: tesoql.Column{Kind: tesoql.COLUMN_FLOAT64}, dataType: arrow.PrimitiveTypes.Float64},
+		{name: "bool", column: tesoql.Column{Kind: tesoql.COLUMN_BOOL}, dataType: arrow.FixedWidthTypes.Boolean},
+		{name: "time", column: tesoql.Column{Kind: tesoql.COLUMN_TIME}, dataType: &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}},
+		{name: "binary", column: tesoql.Column{Kind: tesoql.COLUMN_BINARY}, dataType: arrow.BinaryTypes.Binary},
+		{
+			name:     "decimal",
+			column:   tesoql.Column{Kind: tesoql.COLUMN_DECIMAL, Precision: 12, Scale: 2},
+			dataType: &arrow.Decimal128Type{Precision: 12, Scale: 2},
+		},
+		{name: "decimal of unknown precision", column: tesoql.Column{Kind: tesoql.COLUMN_DECIMAL}, dataType: arrow.BinaryTypes.String},
+		{name: "wide decimal", column: tesoql.Column{Kind: tesoql.COLUMN_DECIMAL, Precision: 65, Scale: 30}, dataType: arrow.BinaryTypes.String},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.column.Name, tt.column.Field = "value", "value"
+			schema := Schema([]tesoql.Column{tt.column})
+			field := schema.Field(0)
+			if field.Name != "value" || !field.Nullable || !arrow.TypeEqual(field.Type, tt.dataType) {
+				t.Errorf("field = %v, want a nullable %v", field, tt.dataType)
+			}
+		})
+	}
+}
+
+func TestAppendValue(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	builder := array.NewTimestampBuilder(memory.NewGoAllocator(), &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"})
+	defer builder.Release()
+	if err := appendValue(builder, created); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendValue(builder, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	timestamps := builder.NewTimestampArray()
+	defer timestamps.Release()
+	if timestamps.Len() != 2 || timestamps.Value(0) != arrow.Timestamp(created.UnixMicro()) || !timestamps.IsNull(1) {
+		t.Errorf("timestamps = %v", timestamps)
+	}
+}
+
+func TestAppendDecimal(t *testing.T) {
+	builder := array.NewDecimal128Builder(memory.NewGoAllocator(), &arrow.Decimal128Type{Precision: 5, Scale: 2})
+	defer builder.Release()
+	for _, value := range []interface{}{"123.45", "0.1", nil} {
+		if err := appendValue(builder, value); err != nil {
+			t.Fatalf("appendValue(%v) = %v", value, err)
+		}
+	}
+	if err := appendValue(builder, "12345.6"); err == nil {
+		t.Error("appendValue(12345.6) = nil, want an error for a decimal wider than the column")
+	}
+
+	decimals := builder.NewDecimal128Array()
+	defer decimals.Release()
+	if decimals.Len() != 3 || decimals.Value(0) != decimal128.FromI64(12345) || decimals.Value(1) != decimal128.FromI64(10) || !decimals.IsNull(2) {
+		t.Errorf("decimals = %v", decimals)
+	}
+}
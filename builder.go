@@ -1,16 +1,72 @@
 package tesoql
 
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
 // TesoQL struct encapsulates the service layer for TesoQL.
 // It holds a reference to the Service, which is responsible for
 // handling the core querying operations.
 type TesoQL struct {
 	Service *Service
+
+	repo Repository
+}
+
+// Build initializes a new instance of TesoQL based on the provided configuration,
+// like NewTesoQL, but returns an error instead of panicking. The repository is built by
+// the EngineFactory registered under Config.Engine (see RegisterEngine). The error is an
+// *ErrorResponseDTO: TESOQL_CONFIG_ERROR when the engine is not registered, the connection
+// config is incomplete or a table or column name is not a valid identifier, and
+// TESOQL_CONNECTION_ERROR when the database cannot be opened or reached.
+//
+// Example usage:
+//
+//	cfg := &tesoql.Config{
+//		Engine: tesoql.POSTGRES_ENGINE,
+//		// Other config fields...
+//	}
+//	tesoQL, err := cfg.Build()
+//	if errors.Is(err, tesoql.ErrConnection) {
+//		// Retry later or degrade
+//	}
+//	defer tesoQL.Close()
+//
+// Returns:
+//
+// - *TesoQL: A pointer to the initialized TesoQL struct, nil on error.
+//
+// - error: A configuration or connection error, if any.
+func (cfg *Config) Build() (*TesoQL, error) {
+	factory, exists := engineFactory(cfg.Engine)
+	if !exists {
+		return nil, newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Engine '%s' is not supported!", cfg.Engine), CONFIG_ENGINE_ERR_CODE)
+	}
+
+	repo, err := factory(cfg)
+	if err != nil {
+		var errDTO *ErrorResponseDTO
+		if errors.As(err, &errDTO) {
+			return nil, errDTO
+		}
+		return nil, newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Engine '%s' could not be built: %v", cfg.Engine, err), CONFIG_ENGINE_ERR_CODE).withCause(err)
+	}
+	if repo == nil {
+		return nil, newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Engine '%s' returned no repository!", cfg.Engine), CONFIG_ENGINE_ERR_CODE)
+	}
+
+	service := newTesoQlService(repo, cfg)
+	return &TesoQL{Service: service, repo: repo}, nil
 }
 
 // NewTesoQL initializes a new instance of TesoQL based on the provided configuration.
 // It determines the appropriate repository implementation (e.g., MongoDB, SQL)
 // based on the engine specified in the Config struct.
-// If the specified engine is not supported, the function panics.
+// It calls Build and panics with the returned error, if any: when the engine is not
+// supported, a table or column name of the configuration is not a valid identifier,
+// or the database cannot be reached. Prefer Build to handle these errors.
 //
 // The method sets up the repository, creates a new TesoQL service with the
 // repository and feature toggles, and returns a pointer to the TesoQL struct.
@@ -37,17 +93,33 @@ type TesoQL struct {
 //
 // - *TesoQL: A pointer to the initialized TesoQL struct.
 func (cfg *Config) NewTesoQL() *TesoQL {
-	var repo iTesoQlRepo
-
-	switch cfg.Engine {
-	case "mongo":
-		repo = newMongoRepository(cfg)
-	case sqlDriverList[cfg.Engine]:
-		repo = newSqlRepository(cfg)
-	default:
-		panic("DB connection could not have been established!")
+	tesoQL, err := cfg.Build()
+	if err != nil {
+		panic(err.Error())
 	}
+	return tesoQL
+}
 
-	service := newTesoQlService(&repo, cfg.Toggles)
-	return &TesoQL{Service: service}
+// Close releases the database connection, only when it was opened by TesoQL from
+// ConnectionConfig.ConnectionString. A client passed through ConnectionConfig.Client
+// is left open for its owner to close. Repositories of registered engines are closed
+// when they implement io.Closer.
+//
+// Example usage:
+//
+//	tesoQL, err := cfg.Build()
+//	if err != nil {
+//		// Handle error
+//	}
+//	defer tesoQL.Close()
+//
+// Returns:
+//
+// - error: An *ErrorResponseDTO with CONNECTION_CLOSE_ERR_CODE if the connection could not be closed.
+func (t *TesoQL) Close() error {
+	closer, ok := t.repo.(io.Closer)
+	if !ok {
+		return nil
+	}
+	return closer.Close()
 }
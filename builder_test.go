@@ -0,0 +1,75 @@
+package tesoql
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    *Config
+		target error
+	}{
+		{
+			name:   "unknown engine",
+			cfg:    &Config{Engine: "unknown", ConnectionConfig: &ConnectionConfig{TableName: "orders"}},
+			target: ErrConfigEngine,
+		},
+		{
+			name:   "table identifier",
+			cfg:    &Config{Engine: POSTGRES_ENGINE, ConnectionConfig: &ConnectionConfig{TableName: "order; --"}},
+			target: ErrConfigIdentifier,
+		},
+		{
+			name:   "column identifier",
+			cfg:    &Config{Engine: MONGO_ENGINE, ConnectionConfig: &ConnectionConfig{DBName: "shop", TableName: "orders"}, FieldsMap: &FieldsMap{SortingFields: map[string]string{"amount": "$where"}}},
+			target: ErrConfigIdentifier,
+		},
+		{
+			name:   "no connection config",
+			cfg:    &Config{Engine: POSTGRES_ENGINE},
+			target: ErrConfigConnection,
+		},
+		{
+			name:   "field type",
+			cfg:    &Config{Engine: POSTGRES_ENGINE, ConnectionConfig: &ConnectionConfig{TableName: "orders"}, FieldsMap: &FieldsMap{FieldTypes: map[string]string{"amount": "money"}}},
+			target: ErrConfigFieldType,
+		},
+		{
+			name:   "mongo without database",
+			cfg:    &Config{Engine: MONGO_ENGINE, ConnectionConfig: &ConnectionConfig{TableName: "orders"}},
+			target: ErrConfigConnection,
+		},
+		{
+			name:   "mongo without collection",
+			cfg:    &Config{Engine: MONGO_ENGINE, ConnectionConfig: &ConnectionConfig{DBName: "shop"}},
+			target: ErrConfigConnection,
+		},
+		{
+			name:   "unregistered driver",
+			cfg:    &Config{Engine: H2_ENGINE, ConnectionConfig: &ConnectionConfig{TableName: "orders"}},
+			target: ErrConnectionOpen,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tesoQL, err := tt.cfg.Build()
+			if tesoQL != nil {
+				t.Errorf("Build() = %v, want nil", tesoQL)
+			}
+			if !errors.Is(err, tt.target) {
+				t.Errorf("Build() error = %v, want %v", err, tt.target)
+			}
+		})
+	}
+}
+
+func TestNewTesoQLPanics(t *testing.T) {
+	defer func() {
+		if recovered := recover(); recovered == nil {
+			t.Error("NewTesoQL() did not panic")
+		}
+	}()
+	(&Config{Engine: "unknown"}).NewTesoQL()
+}
@@ -0,0 +1,285 @@
+package tesoql
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Column describes a column of a stream for columnar exports (Arrow, Parquet): its
+// user-facing name, the database field it is read from, and the kind of its values.
+type Column struct {
+	Name      string // The FieldsMap alias of the field, or the column name when nothing is projected.
+	Field     string // The database field, read from the records.
+	Kind      string // One of the COLUMN_ kinds.
+	Precision int64  // Number of digits of a COLUMN_DECIMAL column, as reported by the driver, zero when unknown.
+	Scale     int64  // Number of digits after the decimal point of a COLUMN_DECIMAL column.
+}
+
+// columnTypedIterator is a RowIterator that knows the types of its columns from the database.
+type columnTypedIterator interface {
+	columnSchema() []Column
+}
+
+// Columns returns the columns of the stream, in the order of the projection as ExportCSV
+// writes them. Kinds declared in FieldsMap.FieldTypes win; otherwise SQL kinds are
+// inferred from the column types reported by the driver, and DateTimeFieldKeys fields
+// are COLUMN_TIME. Mongo documents have no column types: every projected field must be
+// declared in FieldsMap.FieldTypes.
+//
+// Example usage:
+//
+//	rows, err := tesoQL.Service.Stream(ctx, &jsonMapVariable)
+//	columns, columnsErr := rows.Columns()
+//	for rows.Next() {
+//		for _, column := range columns {
+//			value, valueErr := column.Value(rows.Row())
+//		}
+//	}
+//
+// Returns:
+//
+// - []Column: The columns of the stream.
+//
+// - error: An *ErrorResponseDTO with CONFIG_FIELD_TYPE_ERR_CODE if the kind of a column is unknown.
+func (r *Rows) Columns() ([]Column, error) {
+	var dbColumns []Column
+	if typed, ok := r.iterator.(columnTypedIterator); ok {
+		dbColumns = typed.columnSchema()
+	}
+	var fieldTypes, dateTimeFields map[string]string
+	if r.fieldsMap != nil {
+		fieldTypes, dateTimeFields = r.fieldsMap.FieldTypes, r.fieldsMap.DateTimeFieldKeys
+	}
+
+	projected := projectedColumns(r.fieldsMap, r.projection, r.aggregations)
+	if projected == nil {
+		if dbColumns == nil {
+			return nil, newResponse(TESOQL_CONFIG_ERROR, "Columns cannot be inferred without FieldsMap.ProjectionFields.", CONFIG_FIELD_TYPE_ERR_CODE)
+		}
+		for _, c := range dbColumns {
+			projected = append(projected, exportColumn{name: c.Name, field: c.Field})
+		}
+	}
+
+	columns := make([]Column, 0, len(projected))
+	for _, p := range projected {
+		column := Column{Name: p.name, Field: p.field, Kind: fieldTypes[p.name]}
+		var dbColumn Column
+		for _, c := range dbColumns {
+			if c.Field == p.field || c.Field == unqualified(p.field) {
+				dbColumn = c
+			}
+		}
+		if column.Kind == "" {
+			_, dateTime := dateTimeFields[p.name]
+			column.Kind = dbColumn.Kind
+			if dateTime && (column.Kind == "" || column.Kind == COLUMN_STRING) {
+				column.Kind = COLUMN_TIME
+			}
+			if column.Kind == "" && p.metric != nil {
+				column.Kind = metricKind(*p.metric, fieldTypes)
+			}
+			if column.Kind == "" && dbColumns != nil {
+				// expressions without a database type
+				column.Kind = COLUMN_STRING
+			}
+		}
+		if column.Kind == "" {
+			return nil, newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("The kind of field '%s' must be declared in FieldsMap.FieldTypes.", p.name), CONFIG_FIELD_TYPE_ERR_CODE).withField(p.name)
+		}
+		if column.Kind == COLUMN_DECIMAL && dbColumn.Kind == COLUMN_DECIMAL {
+			column.Precision, column.Scale = dbColumn.Precision, dbColumn.Scale
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// Value reads the column from a record, converted to the Go type of its kind: string,
+// int64, float64, bool, time.Time or []byte. COLUMN_DECIMAL values are returned as their
+// decimal text ("12.50"), which keeps every digit. Missing and NULL values are returned as nil.
+//
+// Returns:
+//
+// - interface{}: The value, or nil.
+//
+// - error: An *ErrorResponseDTO with RESULT_DECODE_ERR_CODE if the value cannot be converted to the kind of the column.
+func (c Column) Value(record map[string]interface{}) (interface{}, error) {
+	value, _ := lookupField(record, c.Field)
+	converted, err := convertColumnValue(c.Kind, value)
+	if err != nil {
+		return nil, newResponse(TESOQL_DECODE_ERROR, fmt.Sprintf("Column '%s': %v", c.Name, err), RESULT_DECODE_ERR_CODE).withField(c.Name).withCause(err)
+	}
+	return converted, nil
+}
+
+func convertColumnValue(kind string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if b, ok := value.([]byte); ok && kind != COLUMN_BINARY {
+		value = string(b)
+	}
+	switch kind {
+	case COLUMN_STRING:
+		return csvValue(value), nil
+	case COLUMN_INT64:
+		switch v := value.(type) {
+		case int:
+			return int64(v), nil
+		case int32:
+			return int64(v), nil
+		case int64:
+			return v, nil
+		case uint32:
+			return int64(v), nil
+		case uint64:
+			if v <= math.MaxInt64 {
+				return int64(v), nil
+			}
+		case float64:
+			if v == math.Trunc(v) {
+				return int64(v), nil
+			}
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		}
+	case COLUMN_FLOAT64:
+		switch v := value.(type) {
+		case int:
+			return float64(v), nil
+		case int32:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case float32:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case primitive.Decimal128:
+			return strconv.ParseFloat(v.String(), 64)
+		case string:
+			return strconv.ParseFloat(v, 64)
+		}
+	case COLUMN_BOOL:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case int:
+			return v != 0, nil
+		case int32:
+			return v != 0, nil
+		case int64:
+			return v != 0, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+	case COLUMN_TIME:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case primitive.DateTime:
+			return v.Time(), nil
+		case string:
+			for _, layout := range timeLayouts {
+				if t, err := time.Parse(layout, v); err == nil {
+					return t, nil
+				}
+			}
+		}
+	case COLUMN_BINARY:
+		switch v := value.(type) {
+		case []byte:
+			// drivers may reuse the buffer
+			return append([]byte{}, v...), nil
+		case string:
+			return []byte(v), nil
+		case primitive.Binary:
+			return v.Data, nil
+		}
+	case COLUMN_DECIMAL:
+		switch v := value.(type) {
+		case int:
+			return strconv.Itoa(v), nil
+		case int32:
+			return strconv.FormatInt(int64(v), 10), nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case primitive.Decimal128:
+			return v.String(), nil
+		case string:
+			if decimalPattern.MatchString(v) {
+				return v, nil
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown column kind '%s'", kind)
+	}
+	return nil, fmt.Errorf("%T cannot be converted to %s", value, kind)
+}
+
+// metricKind returns the kind of a metric: COUNT is an integer, AVG a float, and the
+// other functions have the kind declared for their field, if any.
+func metricKind(metric Metric, fieldTypes map[string]string) string {
+	switch metric.Function {
+	case METRIC_COUNT:
+		return COLUMN_INT64
+	case METRIC_AVG:
+		return COLUMN_FLOAT64
+	}
+	return fieldTypes[metric.Field]
+}
+
+func isColumnKind(kind string) bool {
+	switch kind {
+	case COLUMN_STRING, COLUMN_INT64, COLUMN_FLOAT64, COLUMN_BOOL, COLUMN_TIME, COLUMN_BINARY, COLUMN_DECIMAL:
+		return true
+	}
+	return false
+}
+
+// sqlColumnKind infers the kind of a column from the database type name reported by the driver.
+func sqlColumnKind(columnType *sql.ColumnType) string {
+	name := strings.ToUpper(columnType.DatabaseTypeName())
+	switch {
+	case name == "":
+		return ""
+	case strings.Contains(name, "BOOL") || name == "BIT":
+		return COLUMN_BOOL
+	case strings.Contains(name, "INT") && !strings.Contains(name, "INTERVAL") && !strings.Contains(name, "POINT"):
+		return COLUMN_INT64
+	case strings.Contains(name, "DECIMAL") || strings.Contains(name, "NUMERIC"):
+		return COLUMN_DECIMAL
+	case strings.Contains(name, "MONEY"):
+		// formatted with the currency of the server on PostgreSQL ("$1,234.50")
+		return COLUMN_STRING
+	case strings.Contains(name, "REAL") || strings.Contains(name, "FLOAT") || strings.Contains(name, "DOUBLE"):
+		return COLUMN_FLOAT64
+	case strings.Contains(name, "DATE") || strings.Contains(name, "TIMESTAMP"):
+		return COLUMN_TIME
+	case strings.Contains(name, "BLOB") || strings.Contains(name, "BINARY") || name == "BYTEA" || name == "IMAGE":
+		return COLUMN_BINARY
+	}
+	return COLUMN_STRING
+}
+
+// decimalPattern matches the decimal text of exact numbers, as drivers return them.
+var decimalPattern = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+func unqualified(field string) string {
+	return field[strings.LastIndex(field, ".")+1:]
+}
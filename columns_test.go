@@ -0,0 +1,176 @@
+package tesoql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// schemaIterator is a RowIterator that knows the column kinds, as the SQL rows do.
+type schemaIterator struct {
+	sliceIterator
+	schema []Column
+}
+
+func (it *schemaIterator) columnSchema() []Column {
+	return it.schema
+}
+
+func TestRowsColumns(t *testing.T) {
+	fm := &FieldsMap{
+		ProjectionFields:  map[string]string{"id": "id", "name": "product_name", "createdAt": "created_at", "city": "address.city", "price": "price"},
+		DateTimeFieldKeys: map[string]string{"createdAt": "created_at"},
+		FieldTypes:        map[string]string{"id": COLUMN_INT64, "amount": COLUMN_FLOAT64},
+	}
+	schema := []Column{
+		{Name: "product_name", Field: "product_name", Kind: COLUMN_STRING},
+		{Name: "created_at", Field: "created_at", Kind: COLUMN_STRING},
+		{Name: "city", Field: "city", Kind: COLUMN_STRING},
+	}
+	tests := []struct {
+		name         string
+		fm           *FieldsMap
+		schema       []Column
+		projection   []string
+		aggregations *Aggregations
+		columns      []Column
+		error        string
+	}{
+		{
+			name:       "declared kinds",
+			fm:         fm,
+			projection: []string{"id", "createdAt"},
+			columns:    []Column{{Name: "id", Field: "id", Kind: COLUMN_INT64}, {Name: "createdAt", Field: "created_at", Kind: COLUMN_TIME}},
+		},
+		{
+			name:       "undeclared kind",
+			fm:         fm,
+			projection: []string{"id", "name"},
+			error:      "name",
+		},
+		{
+			name:       "database kinds",
+			fm:         fm,
+			schema:     schema,
+			projection: []string{"name", "createdAt", "city"},
+			columns: []Column{
+				{Name: "name", Field: "product_name", Kind: COLUMN_STRING},
+				{Name: "createdAt", Field: "created_at", Kind: COLUMN_TIME},
+				{Name: "city", Field: "address.city", Kind: COLUMN_STRING},
+			},
+		},
+		{
+			name:       "database decimals",
+			fm:         fm,
+			schema:     []Column{{Name: "price", Field: "price", Kind: COLUMN_DECIMAL, Precision: 12, Scale: 2}},
+			projection: []string{"price"},
+			columns:    []Column{{Name: "price", Field: "price", Kind: COLUMN_DECIMAL, Precision: 12, Scale: 2}},
+		},
+		{
+			name:    "database columns without projection fields",
+			schema:  schema[:1],
+			columns: []Column{{Name: "product_name", Field: "product_name", Kind: COLUMN_STRING}},
+		},
+		{
+			name:  "no projection fields nor database columns",
+			error: "ProjectionFields",
+		},
+		{
+			name: "aggregations",
+			fm:   fm,
+			aggregations: &Aggregations{GroupBy: []string{"createdAt"}, Metrics: []Metric{
+				{Function: METRIC_COUNT},
+				{Function: METRIC_AVG, Field: "id"},
+				{Function: METRIC_SUM, Field: "amount", Alias: "total"},
+			}},
+			columns: []Column{
+				{Name: "createdAt", Field: "createdAt", Kind: COLUMN_TIME},
+				{Name: "count", Field: "count", Kind: COLUMN_INT64},
+				{Name: "avg_id", Field: "avg_id", Kind: COLUMN_FLOAT64},
+				{Name: "total", Field: "total", Kind: COLUMN_FLOAT64},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var iterator RowIterator = &sliceIterator{index: -1}
+			if tt.schema != nil {
+				iterator = &schemaIterator{sliceIterator: sliceIterator{index: -1}, schema: tt.schema}
+			}
+			rows := &Rows{iterator: iterator, fieldsMap: tt.fm, projection: tt.projection, aggregations: tt.aggregations, ctx: newRoundTripContext(context.Background(), -1)}
+			columns, err := rows.Columns()
+			if tt.error != "" {
+				var errDTO *ErrorResponseDTO
+				if !errors.As(err, &errDTO) || errDTO.ErrorCode != CONFIG_FIELD_TYPE_ERR_CODE {
+					t.Errorf("Columns() = %v, want CONFIG_FIELD_TYPE_ERR_CODE about %s", err, tt.error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(columns, tt.columns) {
+				t.Errorf("columns = %v\nwant      %v", columns, tt.columns)
+			}
+		})
+	}
+}
+
+func TestColumnValue(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		kind  string
+		value interface{}
+		want  interface{}
+		error bool
+	}{
+		{name: "null", kind: COLUMN_INT64, value: nil, want: nil},
+		{name: "string of bytes", kind: COLUMN_STRING, value: []byte("tea"), want: "tea"},
+		{name: "string of object id", kind: COLUMN_STRING, value: primitive.ObjectID{0x66}, want: "660000000000000000000000"},
+		{name: "int64 of int32", kind: COLUMN_INT64, value: int32(7), want: int64(7)},
+		{name: "int64 of whole float", kind: COLUMN_INT64, value: 7.0, want: int64(7)},
+		{name: "int64 of fraction", kind: COLUMN_INT64, value: 7.5, error: true},
+		{name: "int64 of bytes", kind: COLUMN_INT64, value: []byte("42"), want: int64(42)},
+		{name: "float64 of decimal", kind: COLUMN_FLOAT64, value: testDecimal("12.5"), want: 12.5},
+		{name: "decimal of bytes", kind: COLUMN_DECIMAL, value: []byte("12345678901234567890.50"), want: "12345678901234567890.50"},
+		{name: "decimal of mongo decimal", kind: COLUMN_DECIMAL, value: testDecimal("12.50"), want: "12.50"},
+		{name: "decimal of int", kind: COLUMN_DECIMAL, value: int64(7), want: "7"},
+		{name: "decimal of text", kind: COLUMN_DECIMAL, value: "$12.50", error: true},
+		{name: "bool of int", kind: COLUMN_BOOL, value: int64(1), want: true},
+		{name: "bool of text", kind: COLUMN_BOOL, value: "yes", error: true},
+		{name: "time of mongo date", kind: COLUMN_TIME, value: primitive.NewDateTimeFromTime(created), want: created.Local()},
+		{name: "time of text", kind: COLUMN_TIME, value: "2024-05-01 10:30:00", want: created},
+		{name: "binary of mongo binary", kind: COLUMN_BINARY, value: primitive.Binary{Data: []byte{1, 2}}, want: []byte{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column := Column{Name: "value", Field: "value", Kind: tt.kind}
+			value, err := column.Value(map[string]interface{}{"value": tt.value})
+			if tt.error {
+				if !errors.Is(err, ErrResultDecode) {
+					t.Errorf("Value() = %v, %v, want ErrResultDecode", value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(value, tt.want) {
+				t.Errorf("Value() = %#v, want %#v", value, tt.want)
+			}
+		})
+	}
+}
+
+func testDecimal(s string) primitive.Decimal128 {
+	decimal, err := primitive.ParseDecimal128(s)
+	if err != nil {
+		panic(err)
+	}
+	return decimal
+}
@@ -1,26 +1,45 @@
 package tesoql
 
+import "time"
+
 // Config holds the configuration settings for initializing a TesoQL instance.
 // It includes database engine settings, connection configurations, feature toggles,
-// field mappings, pagination settings, and a flag to print SQL queries.
+// field mappings, pagination settings, a flag to print SQL queries and the SQL dialect.
 type Config struct {
-	Engine           string            // The database engine to use (e.g., "mongo", "mysql").
-	ConnectionConfig *ConnectionConfig // The configuration for database connection details.
-	Toggles          *ToggleConfig     // Feature toggles to enable or disable specific behaviors.
-	FieldsMap        *FieldsMap        // Mappings for different fields like search, sorting, etc.
-	Pagination       *PaginationConfig // Configuration for pagination settings.
-	PrintSqlQuery    bool              // Flag to determine if SQL queries should be printed.
+	Engine            string            // The database engine to use (e.g., "mongo", "mysql").
+	ConnectionConfig  *ConnectionConfig // The configuration for database connection details.
+	Toggles           *ToggleConfig     // Feature toggles to enable or disable specific behaviors.
+	FieldsMap         *FieldsMap        // Mappings for different fields like search, sorting, etc.
+	Pagination        *PaginationConfig // Configuration for pagination settings.
+	FilterLimits      *FilterLimits     // Limits on the size of JsonMap.Filter trees.
+	PrintSqlQuery     bool              // Flag to determine if SQL queries should be printed.
+	Dialect           *Dialect          // SQL dialect override, derived from Engine when nil.
+	WindowTotalCount  bool              // Flag to fetch the total count with COUNT(*) OVER() in the data query, when the dialect supports it.
+	CursorSigningKey  []byte            // Key signing pagination cursors, cursors are disabled when empty.
+	DefaultTimeout    time.Duration     // Timeout of the queries when the caller's context has no deadline, DEFAULT_QUERY_TIMEOUT when zero, none when negative.
+	MongoDateToString bool              // Flag to bucket date histograms with $dateToString instead of $dateTrunc, for MongoDB servers older than 5.0.
 }
 
 // FieldsMap defines the mappings for various field types.
 // These include datetime fields, search fields, sorting fields,
-// projection fields, and condition fields.
+// projection fields, condition fields, and the tiebreaker field.
 type FieldsMap struct {
-	DateTimeFieldKeys map[string]string // Mappings for datetime fields.
-	SearchFields      map[string]string // Mappings for search fields.
-	SortingFields     map[string]string // Mappings for sorting fields.
-	ProjectionFields  map[string]string // Mappings for projection fields.
-	ConditionFields   map[string]string // Mappings for condition fields.
+	DateTimeFieldKeys     map[string]string // Mappings for datetime fields.
+	SearchFields          map[string]string // Mappings for search fields.
+	SortingFields         map[string]string // Mappings for sorting fields.
+	ProjectionFields      map[string]string // Mappings for projection fields.
+	ConditionFields       map[string]string // Mappings for condition fields.
+	TiebreakerField       string            // Database field that uniquely identifies a record, appended to every sort order.
+	FieldTypes            map[string]string // Column kinds of projection fields (COLUMN_INT64, COLUMN_DECIMAL...), for columnar exports.
+	AggregationFields     map[string]string // Mappings for fields that can be grouped by or aggregated.
+	FacetFields           map[string]string // Mappings for fields that facet counts can be computed for.
+	DistinctFields        map[string]string // Mappings for fields whose distinct values can be listed.
+	SearchModes           map[string]string // Search mode of search fields (SEARCH_MODE_*), SEARCH_MODE_CONTAINS when missing.
+	CaseSensitiveSearch   map[string]bool   // Search fields matched case-sensitively, by key.
+	SearchLocale          string            // Locale of search (e.g. "tr"): values are folded with its case rules and matched accent-insensitively.
+	AccentSensitiveSearch bool              // Flag to keep the accents of search values when SearchLocale is set.
+	GlobalSearchFields    []string          // Keys of the search fields JsonMap.Query is matched against.
+	FoldedSearchFields    map[string]string // Columns holding the folded values (FoldSearchText) of search fields, by key, searched in their place when SearchLocale is set.
 }
 
 // ConnectionConfig holds the database connection details.
@@ -35,14 +54,19 @@ type ConnectionConfig struct {
 // ToggleConfig defines the feature toggles that control
 // the enabling or disabling of specific TesoQL functionalities.
 type ToggleConfig struct {
-	DisableSearch       bool                 // Toggle to disable search functionality.
-	DisableProjection   bool                 // Toggle to disable projection functionality.
-	DisableSorting      bool                 // Toggle to disable sorting functionality.
-	DisableConditioning bool                 // Toggle to disable conditioning functionality.
-	DisablePagination   bool                 // Toggle to disable pagination.
-	DisableTotalCount   bool                 // Toggle to disable total count calculation.
-	SortingToggles      *SortingToggles      // Nested toggles for sorting behavior.
-	ConditioningToggles *ConditioningToggles // Nested toggles for conditioning behavior.
+	DisableSearch        bool                 // Toggle to disable search functionality.
+	DisableProjection    bool                 // Toggle to disable projection functionality.
+	DisableSorting       bool                 // Toggle to disable sorting functionality.
+	DisableConditioning  bool                 // Toggle to disable conditioning functionality.
+	DisablePagination    bool                 // Toggle to disable pagination.
+	DisableTotalCount    bool                 // Toggle to disable total count calculation.
+	DisableAggregations  bool                 // Toggle to disable aggregations.
+	DisableFacets        bool                 // Toggle to disable facet counts.
+	DisableDateHistogram bool                 // Toggle to disable date histograms.
+	DisableDistinct      bool                 // Toggle to disable distinct values.
+	SortingToggles       *SortingToggles      // Nested toggles for sorting behavior.
+	ConditioningToggles  *ConditioningToggles // Nested toggles for conditioning behavior.
+	AggregationToggles   *AggregationToggles  // Nested toggles for aggregation metrics.
 }
 
 // SortingToggles holds the toggles related to sorting behavior.
@@ -65,8 +89,27 @@ type ConditioningToggles struct {
 	DisableValuesToExclude    bool // Toggle to disable exclusion condition.
 }
 
+// AggregationToggles holds the toggles related to aggregations.
+// It allows enabling or disabling specific metric functions.
+type AggregationToggles struct {
+	DisableCount bool // Toggle to disable the COUNT metric.
+	DisableSum   bool // Toggle to disable the SUM metric.
+	DisableAvg   bool // Toggle to disable the AVG metric.
+	DisableMin   bool // Toggle to disable the MIN metric.
+	DisableMax   bool // Toggle to disable the MAX metric.
+}
+
 // PaginationConfig defines the settings related to pagination.
-// It includes the upper bound limit for pagination results.
+// It includes the upper bound limit for pagination results, and the page size of
+// Service.ForEachPage which is not bound by it.
 type PaginationConfig struct {
 	LimitUpperBound int64 // The upper bound for the number of results per page.
+	BatchPageSize   int64 // The page size of Service.ForEachPage, DEFAULT_BATCH_PAGE_SIZE when zero.
+}
+
+// FilterLimits bounds the size of the boolean filter trees (JsonMap.Filter) that
+// clients can send. Zero values fall back to DEFAULT_FILTER_MAX_DEPTH and DEFAULT_FILTER_MAX_NODES.
+type FilterLimits struct {
+	MaxDepth int // The maximum nesting depth of a filter tree, the root being at depth 1.
+	MaxNodes int // The maximum number of nodes in a filter tree.
 }
@@ -1,5 +1,7 @@
 package tesoql
 
+import "time"
+
 const (
 	MONGO_ENGINE      = "mongo"
 	SQLITE_ENGINE     = "sqlite3"
@@ -49,15 +51,25 @@ const (
 	TESOQL_SQL_ERROR        = "TESOQL_SQL_ERROR"
 	TESOQL_TOGGLE_ERROR     = "TESOQL_TOGGLE_ERROR"
 	TESOQL_VALIDATION_ERROR = "TESOQL_VALIDATION_ERROR"
+	TESOQL_CONFIG_ERROR     = "TESOQL_CONFIG_ERROR"
+	TESOQL_CONNECTION_ERROR = "TESOQL_CONNECTION_ERROR"
+	TESOQL_DECODE_ERROR     = "TESOQL_DECODE_ERROR"
+	TESOQL_EXPORT_ERROR     = "TESOQL_EXPORT_ERROR"
 )
 
 // Validation Error Codes
 const (
-	BINDING_ERR_CODE    = 400000
-	SORTABLE_ERR_CODE   = 400001
-	SEARCHABLE_ERR_CODE = 400002
-	PROJECTION_ERR_CODE = 400003
-	CONDITION_ERR_CODE  = 400004
+	BINDING_ERR_CODE     = 400000
+	SORTABLE_ERR_CODE    = 400001
+	SEARCHABLE_ERR_CODE  = 400002
+	PROJECTION_ERR_CODE  = 400003
+	CONDITION_ERR_CODE   = 400004
+	FILTER_ERR_CODE      = 400018
+	CURSOR_ERR_CODE      = 400019
+	AGGREGATION_ERR_CODE = 400020
+	FACET_ERR_CODE       = 400027
+	HISTOGRAM_ERR_CODE   = 400029
+	DISTINCT_ERR_CODE    = 400031
 )
 
 // Toggle Validation Error Codes
@@ -75,6 +87,72 @@ const (
 	VALUESTOEXACTMATCH_CONDITION_TOGGLE_ERR_CODE = 400015
 	LOWTOHIGH_CONDITION_TOGGLE_ERR_CODE          = 400016
 	HIGHTOLOW_CONDITION_TOGGLE_ERR_CODE          = 400017
+	AGGREGATION_TOGGLE_ERR_CODE                  = 400021
+	COUNT_METRIC_TOGGLE_ERR_CODE                 = 400022
+	SUM_METRIC_TOGGLE_ERR_CODE                   = 400023
+	AVG_METRIC_TOGGLE_ERR_CODE                   = 400024
+	MIN_METRIC_TOGGLE_ERR_CODE                   = 400025
+	MAX_METRIC_TOGGLE_ERR_CODE                   = 400026
+	FACET_TOGGLE_ERR_CODE                        = 400028
+	HISTOGRAM_TOGGLE_ERR_CODE                    = 400030
+	DISTINCT_TOGGLE_ERR_CODE                     = 400032
+)
+
+// Facet limits
+const (
+	DEFAULT_FACET_LIMIT = 10  // Number of values of a value facet when Facet.Limit is zero.
+	MAX_FACET_LIMIT     = 100 // Upper bound of Facet.Limit.
+)
+
+// Search modes of FieldsMap.SearchModes
+const (
+	SEARCH_MODE_CONTAINS  = "contains" // Substring match, the default.
+	SEARCH_MODE_PREFIX    = "prefix"   // Match of the start of the field.
+	SEARCH_MODE_SUFFIX    = "suffix"   // Match of the end of the field.
+	SEARCH_MODE_EXACT     = "exact"    // Match of the whole field.
+	SEARCH_MODE_REGEX     = "regex"    // Values are regular expressions, not escaped. Opt-in, for trusted callers only.
+	SEARCH_MODE_FULL_TEXT = "fullText" // Match on the full-text index of the engine.
+)
+
+// Search matches of JsonMap.SearchMatch
+const (
+	SEARCH_MATCH_ANY = "any" // One of the search values of the field has to match, the default.
+	SEARCH_MATCH_ALL = "all" // Every search value of the field has to match.
+)
+
+// RELEVANCE_SCORE_FIELD is the key the Mongo text score is sorted by when JsonMap.SortByRelevance is set.
+const RELEVANCE_SCORE_FIELD = "tesoql_score"
+
+// MAX_QUERY_TERMS is the upper bound of the words of JsonMap.Query.
+const MAX_QUERY_TERMS = 10
+
+// Distinct value limits
+const (
+	DEFAULT_DISTINCT_LIMIT = 100  // Number of values returned by Service.Distinct when DistinctOptions.Limit is zero.
+	MAX_DISTINCT_LIMIT     = 1000 // Upper bound of DistinctOptions.Limit.
+)
+
+// Intervals of JsonMap.DateHistogram
+const (
+	INTERVAL_MINUTE  = "minute"
+	INTERVAL_HOUR    = "hour"
+	INTERVAL_DAY     = "day"
+	INTERVAL_WEEK    = "week" // Weeks start on Monday.
+	INTERVAL_MONTH   = "month"
+	INTERVAL_QUARTER = "quarter"
+	INTERVAL_YEAR    = "year"
+)
+
+// MAX_HISTOGRAM_BUCKETS is the maximum number of buckets of a date histogram, empty buckets included.
+const MAX_HISTOGRAM_BUCKETS = 10000
+
+// Metric functions of JsonMap.Aggregations and JsonMap.DateHistogram
+const (
+	METRIC_COUNT = "COUNT"
+	METRIC_SUM   = "SUM"
+	METRIC_AVG   = "AVG"
+	METRIC_MIN   = "MIN"
+	METRIC_MAX   = "MAX"
 )
 
 // Repository Level Error Codes
@@ -86,8 +164,64 @@ const (
 
 	MONGO_FIND_ERR_CODE   = 500005
 	MONGO_CURSOR_ERR_CODE = 500006
+
+	SQL_PAGING_ERR_CODE     = 500007
+	SQL_DATE_TRUNC_ERR_CODE = 500018
+)
+
+// Configuration and Connection Error Codes
+const (
+	CONFIG_ENGINE_ERR_CODE        = 500008
+	CONFIG_CONNECTION_ERR_CODE    = 500009
+	CONFIG_IDENTIFIER_ERR_CODE    = 500010
+	CONFIG_FIELD_TYPE_ERR_CODE    = 500016
+	CONFIG_FACET_ERR_CODE         = 500017
+	CONFIG_HISTOGRAM_ERR_CODE     = 500019
+	CONFIG_DISTINCT_ERR_CODE      = 500020
+	CONFIG_SEARCH_MODE_ERR_CODE   = 500021
+	CONFIG_SEARCH_LOCALE_ERR_CODE = 500022
+
+	CONNECTION_OPEN_ERR_CODE  = 500011
+	CONNECTION_PING_ERR_CODE  = 500012
+	CONNECTION_CLOSE_ERR_CODE = 500013
+)
+
+// Result Decoding Error Codes
+const (
+	RESULT_DECODE_ERR_CODE = 500014
+)
+
+// Column Kinds
+const (
+	COLUMN_STRING  = "string"
+	COLUMN_INT64   = "int64"
+	COLUMN_FLOAT64 = "float64"
+	COLUMN_BOOL    = "bool"
+	COLUMN_TIME    = "time"
+	COLUMN_BINARY  = "binary"
+	COLUMN_DECIMAL = "decimal" // exact numbers (DECIMAL, NUMERIC), read as their decimal text
+)
+
+// Export Error Codes
+const (
+	EXPORT_WRITE_ERR_CODE  = 500015
+	EXPORT_HEADER_ERR_CODE = 500023
 )
 
+// Filter tree limits
+const (
+	DEFAULT_FILTER_MAX_DEPTH = 5
+	DEFAULT_FILTER_MAX_NODES = 50
+)
+
+// DEFAULT_QUERY_TIMEOUT bounds the queries of a request whose context has no deadline,
+// when Config.DefaultTimeout is not set.
+const DEFAULT_QUERY_TIMEOUT = 10 * time.Second
+
+// DEFAULT_BATCH_PAGE_SIZE is the page size of Service.ForEachPage when
+// PaginationConfig.BatchPageSize is not set.
+const DEFAULT_BATCH_PAGE_SIZE = 1000
+
 //	MONGO_EMPTY_QUERY_ERR_CODE                   = 404018
 //
 // Sql Driver list
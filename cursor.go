@@ -0,0 +1,455 @@
+package tesoql
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// keysetColumn is a column of the effective sort order used for keyset pagination.
+type keysetColumn struct {
+	column  string // database field name
+	desc    bool
+	notNull bool // the tiebreaker field, which never holds null
+}
+
+// nullsAfter reports whether the null values of the column are sorted after the others,
+// given whether the engine sorts them last in ascending order.
+func (c keysetColumn) nullsAfter(nullsLast bool) bool {
+	return !c.notNull && c.desc != nullsLast
+}
+
+// cursorPayload is the signed content of a cursor: the sort shape it was built
+// for and the values of the last row of the page, one per sort column.
+type cursorPayload struct {
+	Sort   []string      `json:"s"`
+	Values []cursorValue `json:"v"`
+}
+
+// cursorValue keeps the type of a value next to it, so that it is bound back to
+// the query with the same type it was read with (int64 vs float64, ObjectID, time...).
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v,omitempty"`
+}
+
+// effectiveSort returns the sort order of the query: the requested sort conditions
+// followed by the tiebreaker field, which makes the order total. When every requested
+// condition is descending, the tiebreaker is descending as well.
+func effectiveSort(fm *FieldsMap, jm *JsonMap) []keysetColumn {
+	if jm.Aggregations != nil {
+		return aggregationSort(jm)
+	}
+	var columns []keysetColumn
+	allDesc := len(jm.SortConditions) > 0
+	hasTiebreaker := false
+	for _, sortInput := range jm.SortConditions {
+		column := keysetColumn{column: fm.SortingFields[sortInput.Field], desc: sortInput.SortCondition == "DESC"}
+		column.notNull = fm.TiebreakerField != "" && column.column == fm.TiebreakerField
+		allDesc = allDesc && column.desc
+		hasTiebreaker = hasTiebreaker || column.notNull
+		columns = append(columns, column)
+	}
+	if fm.TiebreakerField != "" && !hasTiebreaker {
+		columns = append(columns, keysetColumn{column: fm.TiebreakerField, desc: allDesc, notNull: true})
+	}
+	return columns
+}
+
+func sortShape(columns []keysetColumn) []string {
+	shape := make([]string, len(columns))
+	for i, c := range columns {
+		direction := "ASC"
+		if c.desc {
+			direction = "DESC"
+		}
+		shape[i] = c.column + ":" + direction
+	}
+	return shape
+}
+
+// resolveCursor verifies the cursor of the JsonMap and decodes the position it points
+// to. The cursor is rejected when its signature does not match or when it was built
+// for another sort order.
+func (jm *JsonMap) resolveCursor(fm *FieldsMap, key []byte) *ErrorResponseDTO {
+	jm.keyset = nil
+	if jm.Pagination.Cursor == "" {
+		return nil
+	}
+	if len(key) == 0 || fm == nil || fm.TiebreakerField == "" {
+		return newResponse(TESOQL_VALIDATION_ERROR, "Cursor pagination is not configured.", CURSOR_ERR_CODE).withField("pagination.cursor")
+	}
+
+	payload, err := decodeCursor(jm.Pagination.Cursor, key)
+	if err != nil {
+		return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Cursor is not valid: %v", err), CURSOR_ERR_CODE).withField("pagination.cursor")
+	}
+	shape := sortShape(effectiveSort(fm, jm))
+	if strings.Join(payload.Sort, ",") != strings.Join(shape, ",") || len(payload.Values) != len(shape) {
+		return newResponse(TESOQL_VALIDATION_ERROR, "Cursor does not match the sort conditions.", CURSOR_ERR_CODE).withField("pagination.cursor")
+	}
+
+	values := make([]interface{}, len(payload.Values))
+	for i, value := range payload.Values {
+		values[i], err = value.decode()
+		if err != nil {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Cursor is not valid: %v", err), CURSOR_ERR_CODE).withField("pagination.cursor")
+		}
+	}
+	jm.keyset = values
+	return nil
+}
+
+// newCursor builds the cursor pointing after the given row, which has to hold every
+// column of the effective sort order, unless they were projected for the cursor
+// (JsonMap.keysetColumns).
+func newCursor(fm *FieldsMap, jm *JsonMap, row map[string]interface{}, key []byte) (string, *ErrorResponseDTO) {
+	if len(key) == 0 || fm == nil || fm.TiebreakerField == "" {
+		return "", newResponse(TESOQL_VALIDATION_ERROR, "Cursor pagination is not configured.", CURSOR_ERR_CODE).withField("pagination.cursor")
+	}
+	columns := effectiveSort(fm, jm)
+	values, errDTO := rowKeyset(columns, row, jm.keysetColumns)
+	if errDTO != nil {
+		return "", errDTO
+	}
+	payload := cursorPayload{Sort: sortShape(columns)}
+	for i, value := range values {
+		encoded, err := encodeCursorValue(value)
+		if err != nil {
+			return "", newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Sort field '%s' cannot be used in a cursor: %v", columns[i].column, err), CURSOR_ERR_CODE).withField("pagination.cursor")
+		}
+		payload.Values = append(payload.Values, encoded)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", newResponse(TESOQL_VALIDATION_ERROR, err.Error(), CURSOR_ERR_CODE).withField("pagination.cursor")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return base64.RawURLEncoding.EncodeToString(body) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// rowKeyset reads the values of the sort columns from a result row, the position
+// the next page starts after. Null values are kept, the seek selects the rows sorted
+// after them. When the sort columns were projected along with the results, a missing
+// column is a document without the field, which sorts as null.
+func rowKeyset(columns []keysetColumn, row map[string]interface{}, projected bool) ([]interface{}, *ErrorResponseDTO) {
+	values := make([]interface{}, len(columns))
+	for i, c := range columns {
+		value, exists := lookupField(row, c.column)
+		if !exists && (!projected || isRawExpression(c.column)) {
+			return nil, newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Sort field '%s' is missing from the results.", c.column), CURSOR_ERR_CODE).withField("pagination.cursor")
+		}
+		if value == nil && c.notNull {
+			return nil, newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Tiebreaker field '%s' is null.", c.column), CURSOR_ERR_CODE).withField("pagination.cursor")
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func decodeCursor(cursor string, key []byte) (*cursorPayload, error) {
+	encodedBody, encodedSignature, found := strings.Cut(cursor, ".")
+	if !found {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	body, err := base64.RawURLEncoding.DecodeString(encodedBody)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, fmt.Errorf("signature mismatch")
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	return &payload, nil
+}
+
+// lookupField reads a column from a result row. Documents hold dotted paths as nested
+// documents, while SQL rows are keyed by the unqualified name of a table.column.
+func lookupField(row map[string]interface{}, column string) (interface{}, bool) {
+	if value, exists := row[column]; exists {
+		return value, true
+	}
+	parts := strings.Split(column, ".")
+	var current interface{} = row
+	found := true
+	for _, part := range parts {
+		switch doc := current.(type) {
+		case map[string]interface{}:
+			current, found = doc[part]
+		case primitive.M:
+			current, found = doc[part]
+		case primitive.D:
+			current, found = doc.Map()[part]
+		default:
+			found = false
+		}
+		if !found {
+			break
+		}
+	}
+	if found {
+		return current, true
+	}
+	value, exists := row[parts[len(parts)-1]]
+	return value, exists
+}
+
+// keysetProjection returns the sort columns missing from the projection of the JsonMap,
+// which are projected as well when the next cursor is built from the results
+// (JsonMap.keysetColumns). Raw expressions cannot be selected by name and are left out.
+func keysetProjection(fm *FieldsMap, jm *JsonMap) []string {
+	if !jm.keysetColumns || len(jm.ProjectionFields) == 0 {
+		return nil
+	}
+	var projected []string
+	for _, field := range jm.ProjectionFields {
+		projected = append(projected, fm.ProjectionFields[field])
+	}
+	var columns []string
+	for _, c := range effectiveSort(fm, jm) {
+		covered := isRawExpression(c.column)
+		for _, column := range projected {
+			covered = covered || column == c.column || strings.HasPrefix(c.column, column+".")
+		}
+		if !covered {
+			columns = append(columns, c.column)
+			projected = append(projected, c.column)
+		}
+	}
+	return columns
+}
+
+// stripKeysetProjection removes the sort columns added by keysetProjection from the
+// records, once the next cursor is built, so that callers only receive the fields they asked for.
+func stripKeysetProjection(fm *FieldsMap, jm *JsonMap, records []map[string]interface{}) {
+	for _, column := range keysetProjection(fm, jm) {
+		for _, record := range records {
+			removeField(record, column)
+		}
+	}
+}
+
+// removeField deletes a column from a record, following dotted paths into the embedded
+// documents of Mongo records and dropping the documents it leaves empty.
+func removeField(row map[string]interface{}, column string) {
+	if _, exists := row[column]; exists {
+		delete(row, column)
+		return
+	}
+	head, rest, nested := strings.Cut(column, ".")
+	if !nested {
+		return
+	}
+	switch doc := row[head].(type) {
+	case map[string]interface{}:
+		removeField(doc, rest)
+		if len(doc) == 0 {
+			delete(row, head)
+		}
+	case primitive.M:
+		removeField(doc, rest)
+		if len(doc) == 0 {
+			delete(row, head)
+		}
+	case primitive.D:
+		m := doc.Map()
+		removeField(m, rest)
+		if len(m) == 0 {
+			delete(row, head)
+			return
+		}
+		var kept primitive.D
+		for _, e := range doc {
+			if _, exists := m[e.Key]; exists {
+				kept = append(kept, e)
+			}
+		}
+		row[head] = kept
+	}
+}
+
+func encodeCursorValue(value interface{}) (cursorValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return cursorValue{Type: "null"}, nil
+	case bool:
+		return cursorValue{Type: "bool", Value: strconv.FormatBool(v)}, nil
+	case int:
+		return cursorValue{Type: "int", Value: strconv.FormatInt(int64(v), 10)}, nil
+	case int32:
+		return cursorValue{Type: "int32", Value: strconv.FormatInt(int64(v), 10)}, nil
+	case int64:
+		return cursorValue{Type: "int", Value: strconv.FormatInt(v, 10)}, nil
+	case uint64:
+		return cursorValue{Type: "uint", Value: strconv.FormatUint(v, 10)}, nil
+	case float32:
+		return cursorValue{Type: "float", Value: strconv.FormatFloat(float64(v), 'g', -1, 32)}, nil
+	case float64:
+		return cursorValue{Type: "float", Value: strconv.FormatFloat(v, 'g', -1, 64)}, nil
+	case string:
+		return cursorValue{Type: "string", Value: v}, nil
+	case []byte:
+		return cursorValue{Type: "bytes", Value: base64.StdEncoding.EncodeToString(v)}, nil
+	case time.Time:
+		return cursorValue{Type: "time", Value: v.Format(time.RFC3339Nano)}, nil
+	case primitive.DateTime:
+		return cursorValue{Type: "date", Value: strconv.FormatInt(int64(v), 10)}, nil
+	case primitive.ObjectID:
+		return cursorValue{Type: "oid", Value: v.Hex()}, nil
+	case primitive.Decimal128:
+		return cursorValue{Type: "decimal", Value: v.String()}, nil
+	}
+	return cursorValue{}, fmt.Errorf("unsupported type %T", value)
+}
+
+func (v cursorValue) decode() (interface{}, error) {
+	switch v.Type {
+	case "null":
+		return nil, nil
+	case "bool":
+		return strconv.ParseBool(v.Value)
+	case "int":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "int32":
+		n, err := strconv.ParseInt(v.Value, 10, 32)
+		return int32(n), err
+	case "uint":
+		return strconv.ParseUint(v.Value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(v.Value, 64)
+	case "string":
+		return v.Value, nil
+	case "bytes":
+		return base64.StdEncoding.DecodeString(v.Value)
+	case "time":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	case "date":
+		n, err := strconv.ParseInt(v.Value, 10, 64)
+		return primitive.DateTime(n), err
+	case "oid":
+		return primitive.ObjectIDFromHex(v.Value)
+	case "decimal":
+		return primitive.ParseDecimal128(v.Value)
+	}
+	return nil, fmt.Errorf("unsupported value type '%s'", v.Type)
+}
+
+// getSqlSeekCondition translates the keyset position of the JsonMap into a predicate
+// selecting the rows after it, either as a row value comparison (a, b) > (?, ?) or,
+// when directions are mixed, the dialect lacks row values or null values may follow
+// the position, as the expanded (a > ?) OR (a = ? AND b > ?) form. Null values are
+// placed where the dialect sorts them (Dialect.NullsLast).
+func getSqlSeekCondition(fm *FieldsMap, jm *JsonMap, args *sqlArgs) string {
+	if jm.keyset == nil {
+		return ""
+	}
+	columns := effectiveSort(fm, jm)
+	nullsLast := args.dialect.NullsLast
+	rowValues := args.dialect.RowValues && len(columns) > 1
+	for i, c := range columns {
+		// a row value comparison with a null is never true, which is only right for the rows before the position
+		rowValues = rowValues && c.desc == columns[0].desc && jm.keyset[i] != nil && !c.nullsAfter(nullsLast)
+	}
+
+	if rowValues {
+		quoted := make([]string, len(columns))
+		placeholders := make([]string, len(columns))
+		for i, c := range columns {
+			quoted[i] = args.dialect.quoteIdentifier(c.column)
+			placeholders[i] = args.bind(jm.keyset[i])
+		}
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(quoted, ", "), seekOperator(columns[0].desc), strings.Join(placeholders, ", "))
+	}
+
+	var orConditions []string
+	for i, c := range columns {
+		if jm.keyset[i] == nil && c.nullsAfter(nullsLast) {
+			// the position is among the last rows of the column
+			continue
+		}
+		var andConditions []string
+		for j := 0; j < i; j++ {
+			column := args.dialect.quoteIdentifier(columns[j].column)
+			if jm.keyset[j] == nil {
+				andConditions = append(andConditions, column+" IS NULL")
+			} else {
+				andConditions = append(andConditions, fmt.Sprintf("%s = %s", column, args.bind(jm.keyset[j])))
+			}
+		}
+		column := args.dialect.quoteIdentifier(c.column)
+		switch {
+		case jm.keyset[i] == nil:
+			andConditions = append(andConditions, column+" IS NOT NULL")
+		case c.nullsAfter(nullsLast):
+			andConditions = append(andConditions, fmt.Sprintf("(%s %s %s OR %s IS NULL)", column, seekOperator(c.desc), args.bind(jm.keyset[i]), column))
+		default:
+			andConditions = append(andConditions, fmt.Sprintf("%s %s %s", column, seekOperator(c.desc), args.bind(jm.keyset[i])))
+		}
+		orConditions = append(orConditions, fmt.Sprintf("(%s)", strings.Join(andConditions, " AND ")))
+	}
+	return fmt.Sprintf("(%s)", strings.Join(orConditions, " OR "))
+}
+
+func seekOperator(desc bool) string {
+	if desc {
+		return "<"
+	}
+	return ">"
+}
+
+// getMongoSeekFilter translates the keyset position of the JsonMap into an $or chain
+// selecting the documents after it. Null values and missing fields sort first on Mongo.
+func getMongoSeekFilter(fm *FieldsMap, jm *JsonMap) *bson.D {
+	if jm.keyset == nil {
+		return nil
+	}
+	var orFilters bson.A
+	columns := effectiveSort(fm, jm)
+	for i, c := range columns {
+		if jm.keyset[i] == nil && c.nullsAfter(false) {
+			continue
+		}
+		var filter bson.D
+		for j, previous := range columns[:i] {
+			// {field: null} matches the documents without the field as well
+			filter = append(filter, bson.E{Key: previous.column, Value: jm.keyset[j]})
+		}
+		operator := "$gt"
+		if c.desc {
+			operator = "$lt"
+		}
+		switch {
+		case jm.keyset[i] == nil:
+			filter = append(filter, bson.E{Key: c.column, Value: bson.D{{"$ne", nil}}})
+		case c.nullsAfter(false):
+			filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+				bson.D{{c.column, bson.D{{operator, jm.keyset[i]}}}},
+				bson.D{{c.column, nil}},
+			}})
+		default:
+			filter = append(filter, bson.E{Key: c.column, Value: bson.D{{operator, jm.keyset[i]}}})
+		}
+		orFilters = append(orFilters, filter)
+	}
+	seek := bson.D{{"$or", orFilters}}
+	return &seek
+}
@@ -0,0 +1,330 @@
+package tesoql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEffectiveSort(t *testing.T) {
+	fm := &FieldsMap{
+		SortingFields:   map[string]string{"status": "status", "amount": "amount"},
+		TiebreakerField: "id",
+	}
+	tests := []struct {
+		name  string
+		sorts []SortInput
+		shape []string
+	}{
+		{name: "tiebreaker only", shape: []string{"id:ASC"}},
+		{name: "ascending", sorts: []SortInput{{Field: "status", SortCondition: "ASC"}}, shape: []string{"status:ASC", "id:ASC"}},
+		{name: "descending", sorts: []SortInput{{Field: "status", SortCondition: "DESC"}}, shape: []string{"status:DESC", "id:DESC"}},
+		{
+			name:  "mixed",
+			sorts: []SortInput{{Field: "status", SortCondition: "DESC"}, {Field: "amount", SortCondition: "ASC"}},
+			shape: []string{"status:DESC", "amount:ASC", "id:ASC"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if shape := sortShape(effectiveSort(fm, &JsonMap{SortConditions: tt.sorts})); !reflect.DeepEqual(shape, tt.shape) {
+				t.Errorf("shape = %v, want %v", shape, tt.shape)
+			}
+		})
+	}
+}
+
+func TestSqlSeekCondition(t *testing.T) {
+	fm := &FieldsMap{
+		SortingFields:   map[string]string{"status": "status", "amount": "amount"},
+		TiebreakerField: "id",
+	}
+	tests := []struct {
+		name    string
+		dialect *Dialect
+		sorts   []SortInput
+		keyset  []interface{}
+		seek    string
+	}{
+		{
+			name:    "row values",
+			dialect: PostgresDialect,
+			sorts:   []SortInput{{Field: "status", SortCondition: "DESC"}},
+			keyset:  []interface{}{"shipped", int64(42)},
+			seek:    `("status", "id") < ($1, $2)`,
+		},
+		{
+			name:    "mixed directions",
+			dialect: PostgresDialect,
+			sorts:   []SortInput{{Field: "status", SortCondition: "DESC"}, {Field: "amount", SortCondition: "ASC"}},
+			keyset:  []interface{}{"shipped", int64(7), int64(42)},
+			seek:    `(("status" < $1) OR ("status" = $2 AND ("amount" > $3 OR "amount" IS NULL)) OR ("status" = $4 AND "amount" = $5 AND "id" > $6))`,
+		},
+		{
+			name:    "without row values",
+			dialect: SqlServerDialect,
+			sorts:   []SortInput{{Field: "status", SortCondition: "ASC"}},
+			keyset:  []interface{}{"shipped", int64(42)},
+			seek:    `(([status] > @p1) OR ([status] = @p2 AND [id] > @p3))`,
+		},
+		{
+			name:    "nulls after the position",
+			dialect: PostgresDialect,
+			sorts:   []SortInput{{Field: "status", SortCondition: "ASC"}},
+			keyset:  []interface{}{"shipped", int64(42)},
+			seek:    `((("status" > $1 OR "status" IS NULL)) OR ("status" = $2 AND "id" > $3))`,
+		},
+		{
+			name:    "null position sorted last",
+			dialect: PostgresDialect,
+			sorts:   []SortInput{{Field: "status", SortCondition: "ASC"}},
+			keyset:  []interface{}{nil, int64(42)},
+			seek:    `(("status" IS NULL AND "id" > $1))`,
+		},
+		{
+			name:    "null position sorted first",
+			dialect: MySqlDialect,
+			sorts:   []SortInput{{Field: "status", SortCondition: "ASC"}, {Field: "amount", SortCondition: "ASC"}},
+			keyset:  []interface{}{nil, int64(7), int64(42)},
+			seek:    "((`status` IS NOT NULL) OR (`status` IS NULL AND `amount` > ?) OR (`status` IS NULL AND `amount` = ? AND `id` > ?))",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jm := &JsonMap{SortConditions: tt.sorts, keyset: tt.keyset}
+			query := jm.NewSqlQueryWithDialect(fm, tt.dialect)
+			if query.Seek != tt.seek {
+				t.Errorf("seek = %s\nwant   %s", query.Seek, tt.seek)
+			}
+		})
+	}
+}
+
+func TestMongoSeekFilter(t *testing.T) {
+	fm := &FieldsMap{
+		SortingFields:   map[string]string{"status": "status", "amount": "amount"},
+		TiebreakerField: "id",
+	}
+	tests := []struct {
+		name   string
+		sorts  []SortInput
+		keyset []interface{}
+		seek   string
+	}{
+		{
+			name:   "values",
+			sorts:  []SortInput{{Field: "status", SortCondition: "DESC"}, {Field: "amount", SortCondition: "ASC"}},
+			keyset: []interface{}{"shipped", int64(7), int64(42)},
+			seek: `{"v":{"$or":[{"$or":[{"status":{"$lt":"shipped"}},{"status":null}]},{"status":"shipped","amount":{"$gt":7}},` +
+				`{"status":"shipped","amount":7,"id":{"$gt":42}}]}}`,
+		},
+		{
+			name:   "null position sorted first",
+			sorts:  []SortInput{{Field: "status", SortCondition: "ASC"}},
+			keyset: []interface{}{nil, int64(42)},
+			seek:   `{"v":{"$or":[{"status":{"$ne":null}},{"status":null,"id":{"$gt":42}}]}}`,
+		},
+		{
+			name:   "null position sorted last",
+			sorts:  []SortInput{{Field: "status", SortCondition: "DESC"}},
+			keyset: []interface{}{nil, int64(42)},
+			seek:   `{"v":{"$or":[{"status":null,"id":{"$lt":42}}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jm := &JsonMap{SortConditions: tt.sorts, keyset: tt.keyset}
+			if seek := mongoJSON(t, getMongoSeekFilter(fm, jm)); seek != tt.seek {
+				t.Errorf("seek = %s\nwant   %s", seek, tt.seek)
+			}
+			if query := jm.NewMongoQuery(fm); query.Offset != 0 || query.Filter != nil {
+				t.Errorf("query = %+v, want no offset and no filter", query)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	fm := &FieldsMap{
+		SortingFields:   map[string]string{"status": "status", "amount": "amount"},
+		TiebreakerField: "id",
+	}
+	key := []byte("secret")
+	created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	oid := primitive.NewObjectID()
+	tests := []struct {
+		name  string
+		sorts []SortInput
+		row   map[string]interface{}
+		want  []interface{}
+	}{
+		{
+			name:  "sql values",
+			sorts: []SortInput{{Field: "status", SortCondition: "ASC"}, {Field: "amount", SortCondition: "DESC"}},
+			row:   map[string]interface{}{"status": "shipped", "amount": 12.5, "id": int64(42)},
+			want:  []interface{}{"shipped", 12.5, int64(42)},
+		},
+		{
+			name:  "mongo values",
+			sorts: []SortInput{{Field: "amount", SortCondition: "ASC"}},
+			row:   map[string]interface{}{"amount": int32(3), "id": oid},
+			want:  []interface{}{int32(3), oid},
+		},
+		{
+			name:  "time",
+			sorts: []SortInput{{Field: "status", SortCondition: "ASC"}},
+			row:   map[string]interface{}{"status": created, "id": "a"},
+			want:  []interface{}{created, "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := newCursor(fm, &JsonMap{SortConditions: tt.sorts}, tt.row, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			jm := &JsonMap{SortConditions: tt.sorts, Pagination: Pagination{Cursor: cursor}}
+			if err := jm.resolveCursor(fm, key); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(jm.keyset, tt.want) {
+				t.Errorf("keyset = %#v, want %#v", jm.keyset, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursorRejected(t *testing.T) {
+	fm := &FieldsMap{
+		SortingFields:   map[string]string{"status": "status", "amount": "amount"},
+		TiebreakerField: "id",
+	}
+	key := []byte("secret")
+	sorts := []SortInput{{Field: "status", SortCondition: "ASC"}}
+	cursor, err := newCursor(fm, &JsonMap{SortConditions: sorts}, map[string]interface{}{"status": "shipped", "id": int64(42)}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, signature, _ := strings.Cut(cursor, ".")
+	forged, _ := newCursor(fm, &JsonMap{SortConditions: sorts}, map[string]interface{}{"status": "shipped", "id": int64(1)}, []byte("other"))
+	forgedBody, _, _ := strings.Cut(forged, ".")
+
+	tests := []struct {
+		name   string
+		cursor string
+		sorts  []SortInput
+		key    []byte
+		error  string
+	}{
+		{name: "tampered body", cursor: forgedBody + "." + signature, sorts: sorts, key: key, error: "signature"},
+		{name: "other key", cursor: cursor, sorts: sorts, key: []byte("rotated"), error: "signature"},
+		{name: "malformed", cursor: body, sorts: sorts, key: key, error: "malformed"},
+		{name: "other sort", cursor: cursor, sorts: []SortInput{{Field: "amount", SortCondition: "ASC"}}, key: key, error: "does not match"},
+		{name: "not configured", cursor: cursor, sorts: sorts, error: "not configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jm := &JsonMap{SortConditions: tt.sorts, Pagination: Pagination{Cursor: tt.cursor}}
+			err := jm.resolveCursor(fm, tt.key)
+			if err == nil || err.ErrorCode != CURSOR_ERR_CODE || !strings.Contains(err.ErrorMsg, tt.error) {
+				t.Errorf("resolveCursor() = %v, want %q", err, tt.error)
+			}
+			if jm.keyset != nil {
+				t.Errorf("keyset = %v, want nil", jm.keyset)
+			}
+		})
+	}
+}
+
+func TestCursorNullSortValues(t *testing.T) {
+	fm := &FieldsMap{
+		SortingFields:   map[string]string{"status": "status", "amount": "amount"},
+		TiebreakerField: "id",
+	}
+	key := []byte("secret")
+	tests := []struct {
+		name      string
+		row       map[string]interface{}
+		projected bool
+		keyset    []interface{}
+	}{
+		{name: "null", row: map[string]interface{}{"status": nil, "id": int64(1)}, keyset: []interface{}{nil, int64(1)}},
+		{name: "missing from a projection", row: map[string]interface{}{"id": int64(1)}, projected: true, keyset: []interface{}{nil, int64(1)}},
+		{name: "missing from the results", row: map[string]interface{}{"id": int64(1)}},
+		{name: "null tiebreaker", row: map[string]interface{}{"status": "shipped", "id": nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorts := []SortInput{{Field: "status", SortCondition: "ASC"}}
+			cursor, err := newCursor(fm, &JsonMap{SortConditions: sorts, keysetColumns: tt.projected}, tt.row, key)
+			if tt.keyset == nil {
+				if err == nil || err.ErrorCode != CURSOR_ERR_CODE {
+					t.Errorf("newCursor() = %v, want CURSOR_ERR_CODE", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			jm := &JsonMap{SortConditions: sorts, Pagination: Pagination{Cursor: cursor}}
+			if err := jm.resolveCursor(fm, key); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(jm.keyset, tt.keyset) {
+				t.Errorf("keyset = %#v, want %#v", jm.keyset, tt.keyset)
+			}
+		})
+	}
+}
+
+func TestKeysetProjection(t *testing.T) {
+	fm := &FieldsMap{
+		SortingFields:    map[string]string{"status": "status"},
+		ProjectionFields: map[string]string{"name": "name", "status": "status", "city": "address.city"},
+		TiebreakerField:  "id",
+	}
+	jm := &JsonMap{ProjectionFields: []string{"name", "city"}, SortConditions: []SortInput{{Field: "status", SortCondition: "ASC"}}}
+
+	if projection := getSqlProjection(fm, jm, PostgresDialect); projection != `"name", "address"."city"` {
+		t.Errorf("projection without cursor = %s", projection)
+	}
+	jm.keysetColumns = true
+	if projection := getSqlProjection(fm, jm, PostgresDialect); projection != `"name", "address"."city", "status", "id"` {
+		t.Errorf("projection with cursor = %s", projection)
+	}
+	want := `{"v":{"name":1,"address.city":1,"status":1,"id":1}}`
+	if projection := mongoJSON(t, getMongoProjection(fm, jm)); projection != want {
+		t.Errorf("mongo projection = %s\nwant               %s", projection, want)
+	}
+
+	records := []map[string]interface{}{
+		{"name": "a", "address": map[string]interface{}{"city": "x"}, "status": "s", "id": 1},
+		{"name": "b", "address": primitive.D{{Key: "city", Value: "y"}}, "status": "s", "id": 2},
+	}
+	stripKeysetProjection(fm, jm, records)
+	wantRecords := []map[string]interface{}{
+		{"name": "a", "address": map[string]interface{}{"city": "x"}},
+		{"name": "b", "address": primitive.D{{Key: "city", Value: "y"}}},
+	}
+	if !reflect.DeepEqual(records, wantRecords) {
+		t.Errorf("records = %v, want %v", records, wantRecords)
+	}
+}
+
+func TestRemoveField(t *testing.T) {
+	record := map[string]interface{}{
+		"meta":  primitive.M{"created": 1, "title": "a"},
+		"stats": primitive.D{{Key: "views", Value: 3}},
+		"id":    1,
+	}
+	removeField(record, "meta.created")
+	removeField(record, "stats.views")
+	removeField(record, "id")
+	want := map[string]interface{}{"meta": primitive.M{"title": "a"}}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("record = %v, want %v", record, want)
+	}
+}
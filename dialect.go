@@ -0,0 +1,455 @@
+package tesoql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Placeholder styles
+const (
+	PLACEHOLDER_QUESTION = "QUESTION" // ?
+	PLACEHOLDER_DOLLAR   = "DOLLAR"   // $1, $2, ...
+	PLACEHOLDER_AT       = "AT"       // @p1, @p2, ... bound positionally
+	PLACEHOLDER_COLON    = "COLON"    // :1, :2, ...
+	PLACEHOLDER_NAMED    = "NAMED"    // @p1, @p2, ... bound with sql.Named
+)
+
+// Internal column names, they are removed from the rows before they are returned.
+const (
+	ROWNUM_COLUMN      = "tesoql_rownum"      // added to the rows of a statement paged with ROWNUM
+	TOTAL_COUNT_COLUMN = "tesoql_total_count" // added to the rows when the total count is windowed
+
+	FACET_VALUE_COLUMN        = "tesoql_value"  // value of a value facet bucket
+	FACET_COUNT_COLUMN        = "tesoql_count"  // count of a value facet bucket
+	FACET_RANGE_COLUMN_PREFIX = "tesoql_range_" // count of a range facet bucket, followed by the index of the range
+	HISTOGRAM_BUCKET_COLUMN   = "tesoql_bucket" // start of a date histogram bucket
+	HISTOGRAM_COUNT_COLUMN    = "tesoql_count"  // count of a date histogram bucket
+	DISTINCT_VALUE_COLUMN     = "tesoql_value"  // distinct value of a field
+	DISTINCT_COUNT_COLUMN     = "tesoql_count"  // count of a distinct value
+)
+
+// Date truncation styles, used by date histograms
+const (
+	DATE_TRUNC_POSTGRES  = "POSTGRES"  // date_trunc('day', to_timestamp(EXTRACT(EPOCH FROM column)) AT TIME ZONE 'zone')
+	DATE_TRUNC_MYSQL     = "MYSQL"     // DATE_FORMAT(CONVERT_TZ(column, '+00:00', 'zone'), '%Y-%m-%d')
+	DATE_TRUNC_SQLITE    = "SQLITE"    // strftime('%Y-%m-%d', column, '+180 minutes'), fixed offsets only
+	DATE_TRUNC_SQLSERVER = "SQLSERVER" // DATEADD(day, DATEDIFF(day, 0, column), 0), fixed offsets only
+	DATE_TRUNC_ORACLE    = "ORACLE"    // TRUNC(FROM_TZ(CAST(column AS TIMESTAMP), 'UTC') AT TIME ZONE 'zone', 'DD')
+)
+
+// Full-text search styles
+const (
+	FULL_TEXT_POSTGRES = "POSTGRES" // to_tsvector(column) @@ plainto_tsquery(?), ranked with ts_rank
+	FULL_TEXT_MYSQL    = "MYSQL"    // MATCH (column) AGAINST (? IN NATURAL LANGUAGE MODE), requires a FULLTEXT index
+	FULL_TEXT_SQLITE   = "FTS5"     // column MATCH ?, on an FTS5 virtual table, ranked with rank
+)
+
+// Regex search styles
+const (
+	REGEX_POSTGRES      = "POSTGRES"    // column ~ ?, or column ~* ? case-insensitively
+	REGEX_LIKE_FUNCTION = "REGEXP_LIKE" // REGEXP_LIKE(column, ?, 'c'), or 'i' case-insensitively (MySQL 8.0, Oracle)
+)
+
+// Case-folding styles, used by case-insensitive search
+const (
+	CASE_FOLD_LOWER    = "LOWER"    // LOWER(column) LIKE LOWER(?)
+	CASE_FOLD_ILIKE    = "ILIKE"    // column ILIKE ?, LOWER(column) = LOWER(?) for exact matches
+	CASE_FOLD_COLLATE  = "COLLATE"  // column COLLATE collation LIKE ?, with the collations of the dialect
+	CASE_FOLD_UNACCENT = "UNACCENT" // unaccent(column) ILIKE unaccent(?), requires the unaccent extension of PostgreSQL
+)
+
+// DEFAULT_LIKE_ESCAPE is the character escaping the wildcards of search values, unless
+// Dialect.LikeEscape is set.
+const DEFAULT_LIKE_ESCAPE = "!"
+
+// Paging styles
+const (
+	PAGING_LIMIT_OFFSET = "LIMIT_OFFSET" // ... LIMIT n OFFSET m
+	PAGING_OFFSET_FETCH = "OFFSET_FETCH" // ... OFFSET m ROWS FETCH NEXT n ROWS ONLY
+	PAGING_TOP          = "TOP"          // SELECT TOP n ...
+	PAGING_ROWNUM       = "ROWNUM"       // SELECT * FROM (SELECT ..., ROWNUM ...) WHERE ...
+)
+
+// Identifier quotes
+const (
+	QUOTE_NONE     = ""   // identifiers are written as they are
+	QUOTE_DOUBLE   = "\"" // "identifier"
+	QUOTE_BACKTICK = "`"  // `identifier`
+	QUOTE_BRACKET  = "["  // [identifier]
+)
+
+// Dialect describes the SQL syntax differences between engines that matter to the
+// query builder: how placeholders are written, how a page of rows is selected,
+// how boolean values are spelled, which operator is used for pattern matching,
+// how wildcards are escaped, how search is made case-insensitive, how table and
+// column names are quoted, how dates are truncated and how full-text and regex
+// search are written.
+//
+// A Dialect is picked from Config.Engine, and can be overridden with Config.Dialect.
+type Dialect struct {
+	Name             string // Name of the dialect, used in error messages.
+	PlaceholderStyle string // One of the PLACEHOLDER_* styles.
+	PagingStyle      string // One of the PAGING_* styles.
+	TrueLiteral      string // Literal used in place of a true boolean value.
+	FalseLiteral     string // Literal used in place of a false boolean value.
+	LikeOperator     string // Operator used for substring search.
+	LikeEscape       string // Character escaping the wildcards of search values, DEFAULT_LIKE_ESCAPE when empty. No ESCAPE clause is written for a backslash.
+	LikeWildcards    string // Wildcard characters of LIKE patterns, escaped in search values, "%_" when empty.
+	IdentifierQuote  string // One of the QUOTE_* styles.
+	WindowCount      bool   // Whether COUNT(*) OVER() is supported.
+	RowValues        bool   // Whether row values can be compared, as in (a, b) > (?, ?).
+	NullsLast        bool   // Whether null values sort after the others in ascending order, they sort first otherwise.
+	DateTruncStyle   string // One of the DATE_TRUNC_* styles, date histograms are not supported when empty.
+	FullTextStyle    string // One of the FULL_TEXT_* styles, full-text search is not supported when empty.
+	RegexStyle       string // One of the REGEX_* styles, regex search is not supported when empty.
+
+	CaseFoldStyle              string // One of the CASE_FOLD_* styles, CASE_FOLD_LOWER when empty.
+	CaseInsensitiveCollation   string // Collation of case-insensitive search, for CASE_FOLD_COLLATE. The collation of the column is kept when empty.
+	CaseSensitiveCollation     string // Collation of case-sensitive search, for CASE_FOLD_COLLATE.
+	AccentFoldStyle            string // One of the CASE_FOLD_* styles, folding accents as well as case. Locale-aware search needs folded columns when empty.
+	AccentInsensitiveCollation string // Collation of accent-insensitive search, for an AccentFoldStyle of CASE_FOLD_COLLATE.
+}
+
+// GenericDialect is used for engines without a dedicated dialect and by the
+// dialect-less query builders (NewSqlQuery, GetSqlQuery).
+var GenericDialect = &Dialect{
+	Name:             "generic",
+	PlaceholderStyle: PLACEHOLDER_QUESTION,
+	PagingStyle:      PAGING_LIMIT_OFFSET,
+	TrueLiteral:      "TRUE",
+	FalseLiteral:     "FALSE",
+	LikeOperator:     "LIKE",
+	IdentifierQuote:  QUOTE_NONE,
+}
+
+// MySqlDialect is the dialect of MySQL and MySQL compatible engines. Case-insensitive
+// search is left to the collation of the columns, case-insensitive (_ci) by default.
+// It has no AccentFoldStyle, since accent-insensitive collations depend on the version
+// and the character set; on MySQL 8.0 with utf8mb4 columns, a copy setting
+// CASE_FOLD_COLLATE with utf8mb4_0900_ai_ci can be passed through Config.Dialect.
+var MySqlDialect = &Dialect{
+	Name:             MYSQL_ENGINE,
+	PlaceholderStyle: PLACEHOLDER_QUESTION,
+	PagingStyle:      PAGING_LIMIT_OFFSET,
+	TrueLiteral:      "TRUE",
+	FalseLiteral:     "FALSE",
+	LikeOperator:     "LIKE",
+	IdentifierQuote:  QUOTE_BACKTICK,
+	WindowCount:      true,
+	RowValues:        true,
+	DateTruncStyle:   DATE_TRUNC_MYSQL,
+	RegexStyle:       REGEX_LIKE_FUNCTION,
+	FullTextStyle:    FULL_TEXT_MYSQL,
+
+	CaseFoldStyle:          CASE_FOLD_COLLATE,
+	CaseSensitiveCollation: "utf8mb4_bin",
+}
+
+// SqliteDialect is the dialect of SQLite.
+var SqliteDialect = &Dialect{
+	Name:             SQLITE_ENGINE,
+	PlaceholderStyle: PLACEHOLDER_QUESTION,
+	PagingStyle:      PAGING_LIMIT_OFFSET,
+	TrueLiteral:      "1",
+	FalseLiteral:     "0",
+	LikeOperator:     "LIKE",
+	IdentifierQuote:  QUOTE_DOUBLE,
+	WindowCount:      true,
+	RowValues:        true,
+	DateTruncStyle:   DATE_TRUNC_SQLITE,
+	FullTextStyle:    FULL_TEXT_SQLITE,
+}
+
+// PostgresDialect is the dialect of PostgreSQL.
+var PostgresDialect = &Dialect{
+	Name:             POSTGRES_ENGINE,
+	PlaceholderStyle: PLACEHOLDER_DOLLAR,
+	PagingStyle:      PAGING_LIMIT_OFFSET,
+	TrueLiteral:      "TRUE",
+	FalseLiteral:     "FALSE",
+	LikeOperator:     "LIKE",
+	IdentifierQuote:  QUOTE_DOUBLE,
+	WindowCount:      true,
+	RowValues:        true,
+	NullsLast:        true,
+	DateTruncStyle:   DATE_TRUNC_POSTGRES,
+	FullTextStyle:    FULL_TEXT_POSTGRES,
+	RegexStyle:       REGEX_POSTGRES,
+	CaseFoldStyle:    CASE_FOLD_ILIKE,
+	AccentFoldStyle:  CASE_FOLD_UNACCENT,
+}
+
+// SqlServerDialect is the dialect of Microsoft SQL Server (2012 and later).
+var SqlServerDialect = &Dialect{
+	Name:             SQLSERVER_ENGINE,
+	PlaceholderStyle: PLACEHOLDER_AT,
+	PagingStyle:      PAGING_OFFSET_FETCH,
+	TrueLiteral:      "1",
+	FalseLiteral:     "0",
+	LikeOperator:     "LIKE",
+	LikeWildcards:    "%_[",
+	IdentifierQuote:  QUOTE_BRACKET,
+	WindowCount:      true,
+	DateTruncStyle:   DATE_TRUNC_SQLSERVER,
+
+	CaseFoldStyle:              CASE_FOLD_COLLATE,
+	CaseInsensitiveCollation:   "Latin1_General_CI_AS",
+	CaseSensitiveCollation:     "Latin1_General_CS_AS",
+	AccentFoldStyle:            CASE_FOLD_COLLATE,
+	AccentInsensitiveCollation: "Latin1_General_CI_AI",
+}
+
+// OracleDialect is the dialect of Oracle Database 12c and later.
+var OracleDialect = &Dialect{
+	Name:             ORACLE_ENGINE,
+	PlaceholderStyle: PLACEHOLDER_COLON,
+	PagingStyle:      PAGING_OFFSET_FETCH,
+	TrueLiteral:      "1",
+	FalseLiteral:     "0",
+	LikeOperator:     "LIKE",
+	IdentifierQuote:  QUOTE_DOUBLE,
+	WindowCount:      true,
+	NullsLast:        true,
+	DateTruncStyle:   DATE_TRUNC_ORACLE,
+	RegexStyle:       REGEX_LIKE_FUNCTION,
+}
+
+// OracleLegacyDialect is the dialect of Oracle Database releases before 12c,
+// which have to page with ROWNUM. It is never picked automatically; set it
+// through Config.Dialect.
+var OracleLegacyDialect = &Dialect{
+	Name:             ORACLE_ENGINE,
+	PlaceholderStyle: PLACEHOLDER_COLON,
+	PagingStyle:      PAGING_ROWNUM,
+	TrueLiteral:      "1",
+	FalseLiteral:     "0",
+	LikeOperator:     "LIKE",
+	IdentifierQuote:  QUOTE_DOUBLE,
+	WindowCount:      true,
+	NullsLast:        true,
+	DateTruncStyle:   DATE_TRUNC_ORACLE,
+	RegexStyle:       REGEX_LIKE_FUNCTION,
+}
+
+// Db2Dialect is the dialect of IBM Db2.
+var Db2Dialect = &Dialect{
+	Name:             DB2_ENGINE,
+	PlaceholderStyle: PLACEHOLDER_QUESTION,
+	PagingStyle:      PAGING_OFFSET_FETCH,
+	TrueLiteral:      "TRUE",
+	FalseLiteral:     "FALSE",
+	LikeOperator:     "LIKE",
+	IdentifierQuote:  QUOTE_DOUBLE,
+	WindowCount:      true,
+	NullsLast:        true,
+}
+
+// FirebirdDialect is the dialect of Firebird 3 and later.
+var FirebirdDialect = &Dialect{
+	Name:             FIREBIRD_ENGINE,
+	PlaceholderStyle: PLACEHOLDER_QUESTION,
+	PagingStyle:      PAGING_OFFSET_FETCH,
+	TrueLiteral:      "TRUE",
+	FalseLiteral:     "FALSE",
+	LikeOperator:     "LIKE",
+	IdentifierQuote:  QUOTE_DOUBLE,
+	WindowCount:      true,
+}
+
+// SybaseDialect is the dialect of SAP ASE, which can only limit rows with TOP.
+var SybaseDialect = &Dialect{
+	Name:             ASE_ENGINE,
+	PlaceholderStyle: PLACEHOLDER_QUESTION,
+	PagingStyle:      PAGING_TOP,
+	TrueLiteral:      "1",
+	FalseLiteral:     "0",
+	LikeOperator:     "LIKE",
+	LikeWildcards:    "%_[",
+	IdentifierQuote:  QUOTE_BRACKET,
+}
+
+// BigQueryDialect is the dialect of Google BigQuery, which only accepts named parameters.
+var BigQueryDialect = &Dialect{
+	Name:             BIGQUERY_ENGINE,
+	PlaceholderStyle: PLACEHOLDER_NAMED,
+	PagingStyle:      PAGING_LIMIT_OFFSET,
+	TrueLiteral:      "TRUE",
+	FalseLiteral:     "FALSE",
+	LikeOperator:     "LIKE",
+	LikeEscape:       `\`,
+	IdentifierQuote:  QUOTE_BACKTICK,
+	WindowCount:      true,
+}
+
+// Sql dialect list, engines missing from the list use GenericDialect.
+var sqlDialects = map[string]*Dialect{
+	SQLITE_ENGINE:    SqliteDialect,
+	MYSQL_ENGINE:     MySqlDialect,
+	VITESS_ENGINE:    MySqlDialect,
+	POSTGRES_ENGINE:  PostgresDialect,
+	SQLSERVER_ENGINE: SqlServerDialect,
+	ORACLE_ENGINE:    OracleDialect,
+	DB2_ENGINE:       Db2Dialect,
+	FIREBIRD_ENGINE:  FirebirdDialect,
+	ASE_ENGINE:       SybaseDialect,
+	BIGQUERY_ENGINE:  BigQueryDialect,
+	SPANNER_ENGINE:   BigQueryDialect,
+}
+
+// DialectFor returns the SQL dialect of the given engine.
+// Engines without a dedicated dialect use GenericDialect.
+//
+// Example usage:
+//
+//	dialect := tesoql.DialectFor(tesoql.POSTGRES_ENGINE)
+//	query, whereClause, args := jm.GetSqlQueryWithDialect(fm, "orders", dialect, false)
+func DialectFor(engine string) *Dialect {
+	if dialect, exists := sqlDialects[engine]; exists {
+		return dialect
+	}
+	return GenericDialect
+}
+
+func (cfg *Config) sqlDialect() *Dialect {
+	if cfg.Dialect != nil {
+		return cfg.Dialect
+	}
+	return DialectFor(cfg.Engine)
+}
+
+func dialectOrGeneric(d *Dialect) *Dialect {
+	if d == nil {
+		return GenericDialect
+	}
+	return d
+}
+
+func (d *Dialect) placeholder(index int) string {
+	switch d.PlaceholderStyle {
+	case PLACEHOLDER_DOLLAR:
+		return fmt.Sprintf("$%d", index)
+	case PLACEHOLDER_AT, PLACEHOLDER_NAMED:
+		return fmt.Sprintf("@p%d", index)
+	case PLACEHOLDER_COLON:
+		return fmt.Sprintf(":%d", index)
+	default:
+		return "?"
+	}
+}
+
+func (d *Dialect) booleanLiteral(value bool) string {
+	if value {
+		return d.TrueLiteral
+	}
+	return d.FalseLiteral
+}
+
+func (d *Dialect) bindArgs(values []interface{}) []interface{} {
+	if d.PlaceholderStyle != PLACEHOLDER_NAMED {
+		return values
+	}
+	named := make([]interface{}, len(values))
+	for i, value := range values {
+		named[i] = sql.Named(fmt.Sprintf("p%d", i+1), value)
+	}
+	return named
+}
+
+// pagingClauses returns the limit and offset fragments of the dialect.
+// They are empty when the dialect has to wrap the statement instead (ROWNUM),
+// or when there is nothing to limit or skip.
+func (d *Dialect) pagingClauses(limit int64, offset int64) (string, string) {
+	var limitClause, offsetClause string
+	switch d.PagingStyle {
+	case PAGING_OFFSET_FETCH:
+		if limit > 0 {
+			limitClause = fmt.Sprintf("FETCH NEXT %d ROWS ONLY", limit)
+		}
+		if offset > 0 || limit > 0 {
+			offsetClause = fmt.Sprintf("OFFSET %d ROWS", offset)
+		}
+	case PAGING_TOP:
+		if limit > 0 {
+			limitClause = fmt.Sprintf("TOP %d", limit)
+		}
+	case PAGING_ROWNUM:
+	default:
+		if limit > 0 {
+			limitClause = fmt.Sprintf("LIMIT %d", limit)
+		}
+		if offset > 0 || limit > 0 {
+			offsetClause = fmt.Sprintf("OFFSET %d", offset)
+		}
+	}
+	return limitClause, offsetClause
+}
+
+// paginate assembles a select statement from its clauses and applies the
+// dialect's paging syntax. fromWhere holds the FROM and WHERE clauses,
+// orderBy is either empty or starts with " ORDER BY".
+func (d *Dialect) paginate(selectClause string, fromWhere string, orderBy string, limit int64, offset int64) string {
+	switch d.PagingStyle {
+	case PAGING_TOP:
+		if limitClause, _ := d.pagingClauses(limit, offset); limitClause != "" {
+			selectClause = limitClause + " " + selectClause
+		}
+		return fmt.Sprintf("SELECT %s %s%s", selectClause, fromWhere, orderBy)
+	case PAGING_ROWNUM:
+		inner := fmt.Sprintf("SELECT %s %s%s", selectClause, fromWhere, orderBy)
+		if limit <= 0 && offset <= 0 {
+			return inner
+		}
+		upper := ""
+		if limit > 0 {
+			upper = fmt.Sprintf(" WHERE ROWNUM <= %d", limit+offset)
+		}
+		return fmt.Sprintf("SELECT * FROM (SELECT tesoql_page.*, ROWNUM AS %s FROM (%s) tesoql_page%s) WHERE %s > %d",
+			ROWNUM_COLUMN, inner, upper, ROWNUM_COLUMN, offset)
+	default:
+		return fmt.Sprintf("SELECT %s %s%s", selectClause, fromWhere, d.pagingSuffix(orderBy, limit, offset))
+	}
+}
+
+// pagingSuffix returns the ORDER BY clause followed by the dialect's trailing
+// paging clauses. Dialects that page elsewhere in the statement only get orderBy back.
+func (d *Dialect) pagingSuffix(orderBy string, limit int64, offset int64) string {
+	limitClause, offsetClause := d.pagingClauses(limit, offset)
+	switch d.PagingStyle {
+	case PAGING_OFFSET_FETCH:
+		if offsetClause == "" {
+			return orderBy
+		}
+		if orderBy == "" {
+			// OFFSET ... FETCH is part of the ORDER BY clause on these engines.
+			orderBy = " ORDER BY (SELECT NULL)"
+		}
+		return strings.TrimRight(fmt.Sprintf("%s %s %s", orderBy, offsetClause, limitClause), " ")
+	case PAGING_TOP, PAGING_ROWNUM:
+		return orderBy
+	default:
+		return strings.TrimRight(fmt.Sprintf("%s %s %s", orderBy, limitClause, offsetClause), " ")
+	}
+}
+
+// sqlArgs collects the arguments of a statement while it is being built and
+// hands out the dialect's placeholder for each of them.
+type sqlArgs struct {
+	dialect *Dialect
+	values  []interface{}
+}
+
+func newSqlArgs(d *Dialect) *sqlArgs {
+	return &sqlArgs{dialect: dialectOrGeneric(d)}
+}
+
+// bind registers the value as an argument and returns its placeholder.
+// Boolean values are written as the dialect's literals instead, since several
+// engines have no boolean type to bind them to.
+func (a *sqlArgs) bind(value interface{}) string {
+	if b, ok := value.(bool); ok {
+		return a.dialect.booleanLiteral(b)
+	}
+	a.values = append(a.values, value)
+	return a.dialect.placeholder(len(a.values))
+}
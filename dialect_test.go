@@ -0,0 +1,158 @@
+package tesoql
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestGetSqlQueryWithDialect(t *testing.T) {
+	fm := &FieldsMap{
+		SortingFields:   map[string]string{"id": "id"},
+		ConditionFields: map[string]string{"amount": "amount", "active": "active"},
+	}
+	conditions := map[string]ConditionOperators{
+		"active": {ValuesToExactMatch: []interface{}{true}},
+		"amount": {GreaterThan: 10, ValuesToExclude: []interface{}{20, 30}},
+	}
+	positional := []interface{}{10, 20, 30}
+	tests := []struct {
+		name    string
+		dialect *Dialect
+		limit   int64
+		offset  int64
+		query   string
+		args    []interface{}
+	}{
+		{
+			name:    "generic",
+			dialect: GenericDialect,
+			limit:   10,
+			offset:  20,
+			query:   "SELECT * FROM orders WHERE 1=1 AND active IN (TRUE) AND amount > ? AND amount NOT IN (?, ?) ORDER BY id ASC LIMIT 10 OFFSET 20",
+			args:    positional,
+		},
+		{
+			name:    "mysql",
+			dialect: MySqlDialect,
+			limit:   10,
+			offset:  20,
+			query:   "SELECT * FROM `orders` WHERE 1=1 AND `active` IN (TRUE) AND `amount` > ? AND `amount` NOT IN (?, ?) ORDER BY `id` ASC LIMIT 10 OFFSET 20",
+			args:    positional,
+		},
+		{
+			name:    "postgres",
+			dialect: PostgresDialect,
+			limit:   10,
+			offset:  20,
+			query:   `SELECT * FROM "orders" WHERE 1=1 AND "active" IN (TRUE) AND "amount" > $1 AND "amount" NOT IN ($2, $3) ORDER BY "id" ASC LIMIT 10 OFFSET 20`,
+			args:    positional,
+		},
+		{
+			name:    "sqlserver",
+			dialect: SqlServerDialect,
+			limit:   10,
+			offset:  20,
+			query:   "SELECT * FROM [orders] WHERE 1=1 AND [active] IN (1) AND [amount] > @p1 AND [amount] NOT IN (@p2, @p3) ORDER BY [id] ASC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY",
+			args:    positional,
+		},
+		{
+			name:    "oracle",
+			dialect: OracleDialect,
+			limit:   10,
+			offset:  20,
+			query:   `SELECT * FROM "orders" WHERE 1=1 AND "active" IN (1) AND "amount" > :1 AND "amount" NOT IN (:2, :3) ORDER BY "id" ASC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY`,
+			args:    positional,
+		},
+		{
+			name:    "oracle rownum",
+			dialect: OracleLegacyDialect,
+			limit:   10,
+			offset:  20,
+			query:   `SELECT * FROM (SELECT tesoql_page.*, ROWNUM AS tesoql_rownum FROM (SELECT * FROM "orders" WHERE 1=1 AND "active" IN (1) AND "amount" > :1 AND "amount" NOT IN (:2, :3) ORDER BY "id" ASC) tesoql_page WHERE ROWNUM <= 30) WHERE tesoql_rownum > 20`,
+			args:    positional,
+		},
+		{
+			name:    "oracle rownum without limit",
+			dialect: OracleLegacyDialect,
+			offset:  20,
+			query:   `SELECT * FROM (SELECT tesoql_page.*, ROWNUM AS tesoql_rownum FROM (SELECT * FROM "orders" WHERE 1=1 AND "active" IN (1) AND "amount" > :1 AND "amount" NOT IN (:2, :3) ORDER BY "id" ASC) tesoql_page) WHERE tesoql_rownum > 20`,
+			args:    positional,
+		},
+		{
+			name:    "oracle rownum without paging",
+			dialect: OracleLegacyDialect,
+			query:   `SELECT * FROM "orders" WHERE 1=1 AND "active" IN (1) AND "amount" > :1 AND "amount" NOT IN (:2, :3) ORDER BY "id" ASC`,
+			args:    positional,
+		},
+		{
+			name:    "sybase top",
+			dialect: SybaseDialect,
+			limit:   10,
+			query:   "SELECT TOP 10 * FROM [orders] WHERE 1=1 AND [active] IN (1) AND [amount] > ? AND [amount] NOT IN (?, ?) ORDER BY [id] ASC",
+			args:    positional,
+		},
+		{
+			name:    "bigquery named",
+			dialect: BigQueryDialect,
+			limit:   10,
+			query:   "SELECT * FROM `orders` WHERE 1=1 AND `active` IN (TRUE) AND `amount` > @p1 AND `amount` NOT IN (@p2, @p3) ORDER BY `id` ASC LIMIT 10 OFFSET 0",
+			args:    []interface{}{sql.Named("p1", 10), sql.Named("p2", 20), sql.Named("p3", 30)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jm := &JsonMap{
+				Conditions:     conditions,
+				SortConditions: []SortInput{{Field: "id", SortCondition: "ASC"}},
+				Pagination:     Pagination{Limit: tt.limit, Offset: tt.offset},
+			}
+			query, _, args := jm.GetSqlQueryWithDialect(fm, "orders", tt.dialect, false)
+			if query != tt.query {
+				t.Errorf("query = %s\nwant    %s", query, tt.query)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestPagingSuffixWithoutOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect *Dialect
+		limit   int64
+		offset  int64
+		suffix  string
+	}{
+		{name: "limit offset", dialect: PostgresDialect, limit: 10, offset: 5, suffix: " LIMIT 10 OFFSET 5"},
+		{name: "limit offset without paging", dialect: PostgresDialect, suffix: ""},
+		{name: "offset fetch orders by a constant", dialect: SqlServerDialect, limit: 10, suffix: " ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{name: "offset fetch without limit", dialect: Db2Dialect, offset: 5, suffix: " ORDER BY (SELECT NULL) OFFSET 5 ROWS"},
+		{name: "offset fetch without paging", dialect: FirebirdDialect, suffix: ""},
+		{name: "top pages in the select clause", dialect: SybaseDialect, limit: 10, suffix: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if suffix := tt.dialect.pagingSuffix("", tt.limit, tt.offset); suffix != tt.suffix {
+				t.Errorf("pagingSuffix = %q, want %q", suffix, tt.suffix)
+			}
+		})
+	}
+}
+
+func TestDialectFor(t *testing.T) {
+	tests := map[string]*Dialect{
+		POSTGRES_ENGINE:  PostgresDialect,
+		VITESS_ENGINE:    MySqlDialect,
+		SPANNER_ENGINE:   BigQueryDialect,
+		SQLSERVER_ENGINE: SqlServerDialect,
+		"unknown":        GenericDialect,
+	}
+	for engine, want := range tests {
+		if got := DialectFor(engine); got != want {
+			t.Errorf("DialectFor(%q) = %s, want %s", engine, got.Name, want.Name)
+		}
+	}
+}
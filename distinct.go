@@ -0,0 +1,178 @@
+package tesoql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DistinctRepository is a Repository that can list the distinct values of a field.
+// The built-in Mongo and SQL repositories implement it.
+type DistinctRepository interface {
+	Repository
+	Distinct(ctx context.Context, field string, jsonMap *JsonMap) ([]DistinctValue, *ErrorResponseDTO)
+}
+
+// Distinct lists the distinct values of a field of FieldsMap.DistinctFields over the records
+// matching the filters of the JsonMap, in ascending order, to populate filter dropdowns.
+// JsonMap.Distinct limits the values, restricts them to a prefix and requests their counts.
+// Null values are not returned. The JsonMap goes through the same validations as GetContext,
+// its sorting and pagination are ignored.
+//
+// Example usage:
+//
+//	jsonMapVariable.Distinct = &tesoql.DistinctOptions{Prefix: "ist", Limit: 20, Counts: true}
+//	values, err := tesoQL.Service.Distinct(r.Context(), "city", &jsonMapVariable)
+//	if err != nil {
+//		// Handle error
+//	}
+//	for _, value := range values {
+//		fmt.Println(value.Value, value.Count)
+//	}
+//
+// Returns:
+//
+// - []DistinctValue: The distinct values in ascending order.
+//
+// - *ErrorResponseDTO: An error response, if any occurred during validation or while running the query.
+func (s *Service) Distinct(ctx context.Context, field string, jsonMap *JsonMap) ([]DistinctValue, *ErrorResponseDTO) {
+	if s.toggles != nil && s.toggles.DisableDistinct {
+		return nil, newResponse(TESOQL_TOGGLE_ERROR, "DisableDistinct toggle is open.", DISTINCT_TOGGLE_ERR_CODE).withField("distinct")
+	}
+	validationErr := s.validate(jsonMap)
+	if validationErr != nil {
+		return nil, validationErr
+	}
+	validationErr = jsonMap.validateDistinct(s.fieldsMap, field)
+	if validationErr != nil {
+		return nil, validationErr
+	}
+	repo, ok := s.repo.(DistinctRepository)
+	if !ok {
+		return nil, newResponse(TESOQL_CONFIG_ERROR, "The repository does not support distinct values.", CONFIG_DISTINCT_ERR_CODE).withField("distinct")
+	}
+	ctx, cancel := withDefaultTimeout(ctx, s.defaultTimeout)
+	defer cancel()
+	return repo.Distinct(ctx, field, jsonMap)
+}
+
+// validateDistinct checks that the field is a distinct field and that the limit of the
+// distinct options is not negative.
+//
+// Returns:
+//
+// - *ErrorResponseDTO: An error response if validation fails, or nil if validation passes.
+func (jm *JsonMap) validateDistinct(fm *FieldsMap, field string) *ErrorResponseDTO {
+	var distinctFields map[string]string
+	if fm != nil {
+		distinctFields = fm.DistinctFields
+	}
+	if _, exists := distinctFields[field]; !exists {
+		return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Field : '%v' is not a distinct field.", field), DISTINCT_ERR_CODE).withField("distinct")
+	}
+	if jm.Distinct != nil && jm.Distinct.Limit < 0 {
+		return newResponse(TESOQL_VALIDATION_ERROR, "Distinct limit cannot be negative.", DISTINCT_ERR_CODE).withField("distinct.limit")
+	}
+	return nil
+}
+
+// distinctOptions returns the distinct options of the JsonMap, with the limit clamped to
+// MAX_DISTINCT_LIMIT.
+func (jm *JsonMap) distinctOptions() DistinctOptions {
+	var options DistinctOptions
+	if jm.Distinct != nil {
+		options = *jm.Distinct
+	}
+	switch {
+	case options.Limit <= 0:
+		options.Limit = DEFAULT_DISTINCT_LIMIT
+	case options.Limit > MAX_DISTINCT_LIMIT:
+		options.Limit = MAX_DISTINCT_LIMIT
+	}
+	return options
+}
+
+// getSqlDistinct returns the query listing the distinct values of the field into
+// DISTINCT_VALUE_COLUMN, and their counts into DISTINCT_COUNT_COLUMN when requested.
+// The values are grouped rather than selected with DISTINCT, which dialects paging
+// with TOP would not accept.
+func getSqlDistinct(fm *FieldsMap, jm *JsonMap, field string, d *Dialect) *SqlQuery {
+	d = dialectOrGeneric(d)
+	args := newSqlArgs(d)
+	options := jm.distinctOptions()
+	column := d.quoteIdentifier(fm.DistinctFields[field])
+
+	query := &SqlQuery{dialect: d}
+	query.Select = fmt.Sprintf("%s AS %s", column, d.quoteIdentifier(DISTINCT_VALUE_COLUMN))
+	if options.Counts {
+		query.Select += fmt.Sprintf(", COUNT(*) AS %s", d.quoteIdentifier(DISTINCT_COUNT_COLUMN))
+	}
+	conditions := []string{fmt.Sprintf("%s IS NOT NULL", column)}
+	if options.Prefix != "" {
+		conditions = append(conditions, d.sqlLike(column, args.bind(d.escapeLike(options.Prefix)+"%"), false))
+	}
+	if where := getSqlFilter(fm, jm, args); where != "" {
+		conditions = append(conditions, where)
+	}
+	query.Where = strings.Join(conditions, " AND ")
+	query.GroupBy = fmt.Sprintf(" GROUP BY %s", column)
+	query.OrderBy = fmt.Sprintf(" ORDER BY %s ASC", column)
+	query.limit = int64(options.Limit)
+	query.Limit, query.Offset = d.pagingClauses(query.limit, 0)
+	query.Args = d.bindArgs(args.values)
+	return query
+}
+
+// sqlDistinctValues reads the distinct values from the rows of their query.
+func sqlDistinctValues(rows []map[string]interface{}) []DistinctValue {
+	values := []DistinctValue{}
+	for _, row := range rows {
+		value := row[DISTINCT_VALUE_COLUMN]
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		values = append(values, DistinctValue{Value: value, Count: toInt(row[DISTINCT_COUNT_COLUMN])})
+	}
+	return values
+}
+
+// getMongoDistinctPipeline returns the aggregation pipeline listing the distinct values
+// of the field, grouped by value with their counts when requested. A $group stage is used
+// instead of collection.Distinct, which can neither limit nor count the values.
+func getMongoDistinctPipeline(fm *FieldsMap, jm *JsonMap, field string) mongo.Pipeline {
+	options := jm.distinctOptions()
+	column := fm.DistinctFields[field]
+
+	var pipeline mongo.Pipeline
+	if filter := getMongoFilter(fm, jm); filter != nil {
+		pipeline = append(pipeline, bson.D{{"$match", *filter}})
+	}
+	value := bson.D{{"$ne", nil}}
+	if options.Prefix != "" {
+		value = bson.D{{"$regex", primitive.Regex{Pattern: "^" + regexp.QuoteMeta(options.Prefix), Options: "i"}}}
+	}
+	group := bson.D{{"_id", "$" + column}}
+	if options.Counts {
+		group = append(group, bson.E{Key: DISTINCT_COUNT_COLUMN, Value: bson.D{{"$sum", 1}}})
+	}
+	return append(pipeline,
+		bson.D{{"$match", bson.D{{column, value}}}},
+		bson.D{{"$group", group}},
+		bson.D{{"$sort", bson.D{{"_id", 1}}}},
+		bson.D{{"$limit", options.Limit}},
+	)
+}
+
+// mongoDistinctValues reads the distinct values from the output of their pipeline.
+func mongoDistinctValues(documents []bson.M) []DistinctValue {
+	values := []DistinctValue{}
+	for _, document := range documents {
+		values = append(values, DistinctValue{Value: document["_id"], Count: toInt(document[DISTINCT_COUNT_COLUMN])})
+	}
+	return values
+}
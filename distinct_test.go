@@ -0,0 +1,152 @@
+package tesoql
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSqlDistinct(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields: map[string]string{"amount": "amount"},
+		DistinctFields:  map[string]string{"city": "address.city"},
+	}
+	prefixed := &JsonMap{
+		Conditions: map[string]ConditionOperators{"amount": {GreaterThan: 5}},
+		Distinct:   &DistinctOptions{Prefix: "Is_t%", Counts: true, Limit: 5000},
+	}
+	tests := []struct {
+		name      string
+		dialect   *Dialect
+		jsonMap   *JsonMap
+		statement string
+		args      []interface{}
+	}{
+		{
+			name:    "prefix and counts",
+			dialect: PostgresDialect,
+			jsonMap: prefixed,
+			statement: `SELECT "address"."city" AS "tesoql_value", COUNT(*) AS "tesoql_count" FROM "orders" ` +
+				`WHERE 1=1 AND "address"."city" IS NOT NULL AND "address"."city" ILIKE $1 ESCAPE '!' AND "amount" > $2 ` +
+				`GROUP BY "address"."city" ORDER BY "address"."city" ASC LIMIT 1000 OFFSET 0`,
+			args: []interface{}{"Is!_t!%%", 5},
+		},
+		{
+			name:    "offset fetch",
+			dialect: SqlServerDialect,
+			jsonMap: prefixed,
+			statement: `SELECT [address].[city] AS [tesoql_value], COUNT(*) AS [tesoql_count] FROM [orders] ` +
+				`WHERE 1=1 AND [address].[city] IS NOT NULL AND [address].[city] COLLATE Latin1_General_CI_AS LIKE @p1 ESCAPE '!' AND [amount] > @p2 ` +
+				`GROUP BY [address].[city] ORDER BY [address].[city] ASC OFFSET 0 ROWS FETCH NEXT 1000 ROWS ONLY`,
+			args: []interface{}{"Is!_t!%%", 5},
+		},
+		{
+			name:    "rownum",
+			dialect: OracleLegacyDialect,
+			jsonMap: prefixed,
+			statement: `SELECT * FROM (SELECT tesoql_page.*, ROWNUM AS tesoql_rownum FROM (` +
+				`SELECT "address"."city" AS "tesoql_value", COUNT(*) AS "tesoql_count" FROM "orders" ` +
+				`WHERE 1=1 AND "address"."city" IS NOT NULL AND LOWER("address"."city") LIKE LOWER(:1) ESCAPE '!' AND "amount" > :2 ` +
+				`GROUP BY "address"."city" ORDER BY "address"."city" ASC) tesoql_page WHERE ROWNUM <= 1000) WHERE tesoql_rownum > 0`,
+			args: []interface{}{"Is!_t!%%", 5},
+		},
+		{
+			name:      "defaults",
+			dialect:   PostgresDialect,
+			jsonMap:   &JsonMap{},
+			statement: `SELECT "address"."city" AS "tesoql_value" FROM "orders" WHERE 1=1 AND "address"."city" IS NOT NULL GROUP BY "address"."city" ORDER BY "address"."city" ASC LIMIT 100 OFFSET 0`,
+			args:      []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := getSqlDistinct(fm, tt.jsonMap, "city", tt.dialect)
+			if statement := query.statement("orders", false); statement != tt.statement {
+				t.Errorf("statement = %s\nwant        %s", statement, tt.statement)
+			}
+			if len(query.Args) != len(tt.args) || (len(tt.args) > 0 && !reflect.DeepEqual(query.Args, tt.args)) {
+				t.Errorf("args = %v, want %v", query.Args, tt.args)
+			}
+		})
+	}
+}
+
+func TestMongoDistinctPipeline(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields: map[string]string{"amount": "amount"},
+		DistinctFields:  map[string]string{"city": "address.city"},
+	}
+	prefixed := &JsonMap{
+		Conditions: map[string]ConditionOperators{"amount": {GreaterThan: 5}},
+		Distinct:   &DistinctOptions{Prefix: "Is_t%", Counts: true, Limit: 5000},
+	}
+	tests := []struct {
+		name     string
+		jsonMap  *JsonMap
+		pipeline string
+	}{
+		{
+			name:    "prefix and counts",
+			jsonMap: prefixed,
+			pipeline: `{"v":[{"$match":{"$and":[{"$and":[{"amount":{"$gt":5}}]}]}},` +
+				`{"$match":{"address.city":{"$regex":{"$regularExpression":{"pattern":"^Is_t%","options":"i"}}}}},` +
+				`{"$group":{"_id":"$address.city","tesoql_count":{"$sum":1}}},{"$sort":{"_id":1}},{"$limit":1000}]}`,
+		},
+		{
+			name:     "defaults",
+			jsonMap:  &JsonMap{},
+			pipeline: `{"v":[{"$match":{"address.city":{"$ne":null}}},{"$group":{"_id":"$address.city"}},{"$sort":{"_id":1}},{"$limit":100}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if pipeline := mongoJSON(t, getMongoDistinctPipeline(fm, tt.jsonMap, "city")); pipeline != tt.pipeline {
+				t.Errorf("pipeline = %s\nwant       %s", pipeline, tt.pipeline)
+			}
+		})
+	}
+}
+
+func TestDistinctValues(t *testing.T) {
+	want := []DistinctValue{{Value: "Istanbul", Count: 3}, {Value: "Izmir", Count: 1}}
+	rows := []map[string]interface{}{{DISTINCT_VALUE_COLUMN: []byte("Istanbul"), DISTINCT_COUNT_COLUMN: int64(3)}, {DISTINCT_VALUE_COLUMN: "Izmir", DISTINCT_COUNT_COLUMN: int64(1)}}
+	if values := sqlDistinctValues(rows); !reflect.DeepEqual(values, want) {
+		t.Errorf("sql values = %v, want %v", values, want)
+	}
+	documents := []bson.M{{"_id": "Istanbul", DISTINCT_COUNT_COLUMN: int32(3)}, {"_id": "Izmir", DISTINCT_COUNT_COLUMN: int32(1)}}
+	if values := mongoDistinctValues(documents); !reflect.DeepEqual(values, want) {
+		t.Errorf("mongo values = %v, want %v", values, want)
+	}
+}
+
+func TestValidateDistinct(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields: map[string]string{"amount": "amount"},
+		DistinctFields:  map[string]string{"city": "address.city"},
+	}
+	tests := []struct {
+		name    string
+		field   string
+		options *DistinctOptions
+		path    string
+	}{
+		{name: "valid", field: "city", options: &DistinctOptions{Prefix: "Is", Limit: 10}},
+		{name: "not a distinct field", field: "amount", path: "distinct"},
+		{name: "negative limit", field: "city", options: &DistinctOptions{Limit: -1}, path: "distinct.limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&JsonMap{Distinct: tt.options}).validateDistinct(fm, tt.field)
+			if tt.path == "" {
+				if err != nil {
+					t.Errorf("validateDistinct() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.ErrorCode != DISTINCT_ERR_CODE || err.Field != tt.path {
+				t.Errorf("validateDistinct() = %v, want DISTINCT_ERR_CODE on %s", err, tt.path)
+			}
+		})
+	}
+}
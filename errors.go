@@ -0,0 +1,155 @@
+package tesoql
+
+import "fmt"
+
+// Sentinel errors, one per error code, to be matched with errors.Is.
+//
+// Example usage:
+//
+//	results, totalCount, size, err := tesoQL.Service.Get(&jsonMapVariable)
+//	if errors.Is(err.AsError(), tesoql.ErrSortable) {
+//		// Handle a field that is not sortable
+//	}
+var (
+	ErrBinding     error = &ErrorResponseDTO{ErrorType: BINDING_ERR, ErrorMsg: "request cannot be bound", ErrorCode: BINDING_ERR_CODE}
+	ErrSortable    error = &ErrorResponseDTO{ErrorType: TESOQL_VALIDATION_ERROR, ErrorMsg: "field is not sortable", ErrorCode: SORTABLE_ERR_CODE}
+	ErrSearchable  error = &ErrorResponseDTO{ErrorType: TESOQL_VALIDATION_ERROR, ErrorMsg: "field is not searchable", ErrorCode: SEARCHABLE_ERR_CODE}
+	ErrProjection  error = &ErrorResponseDTO{ErrorType: TESOQL_VALIDATION_ERROR, ErrorMsg: "field cannot be projected", ErrorCode: PROJECTION_ERR_CODE}
+	ErrCondition   error = &ErrorResponseDTO{ErrorType: TESOQL_VALIDATION_ERROR, ErrorMsg: "field cannot be conditioned", ErrorCode: CONDITION_ERR_CODE}
+	ErrFilter      error = &ErrorResponseDTO{ErrorType: TESOQL_VALIDATION_ERROR, ErrorMsg: "filter is not valid", ErrorCode: FILTER_ERR_CODE}
+	ErrCursor      error = &ErrorResponseDTO{ErrorType: TESOQL_VALIDATION_ERROR, ErrorMsg: "cursor is not valid", ErrorCode: CURSOR_ERR_CODE}
+	ErrAggregation error = &ErrorResponseDTO{ErrorType: TESOQL_VALIDATION_ERROR, ErrorMsg: "aggregation is not valid", ErrorCode: AGGREGATION_ERR_CODE}
+	ErrFacet       error = &ErrorResponseDTO{ErrorType: TESOQL_VALIDATION_ERROR, ErrorMsg: "facet is not valid", ErrorCode: FACET_ERR_CODE}
+	ErrHistogram   error = &ErrorResponseDTO{ErrorType: TESOQL_VALIDATION_ERROR, ErrorMsg: "date histogram is not valid", ErrorCode: HISTOGRAM_ERR_CODE}
+	ErrDistinct    error = &ErrorResponseDTO{ErrorType: TESOQL_VALIDATION_ERROR, ErrorMsg: "distinct field is not valid", ErrorCode: DISTINCT_ERR_CODE}
+
+	ErrSortableToggle           error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "sorting is disabled", ErrorCode: SORTABLE_TOGGLE_ERR_CODE}
+	ErrSearchableToggle         error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "search is disabled", ErrorCode: SEARCHABLE_TOGGLE_ERR_CODE}
+	ErrProjectionToggle         error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "projection is disabled", ErrorCode: PROJECTION_TOGGLE_ERR_CODE}
+	ErrConditionToggle          error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "conditioning is disabled", ErrorCode: CONDITION_TOGGLE_ERR_CODE}
+	ErrPaginationToggle         error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "pagination is disabled", ErrorCode: PAGINATION_TOGGLE_ERR_CODE}
+	ErrGreaterThanToggle        error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "greaterThan is disabled", ErrorCode: GREATERTHAN_CONDITION_TOGGLE_ERR_CODE}
+	ErrGreaterOrEqualToggle     error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "greaterOrEqual is disabled", ErrorCode: GREATEROREQUAL_CONDITION_TOGGLE_ERR_CODE}
+	ErrLowerThanToggle          error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "lowerThan is disabled", ErrorCode: LOWERTHAN_CONDITION_TOGGLE_ERR_CODE}
+	ErrLowerOrEqualToggle       error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "lowerOrEqual is disabled", ErrorCode: LOWEROREQUAL_CONDITION_TOGGLE_ERR_CODE}
+	ErrValuesToExcludeToggle    error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "valuesToExclude is disabled", ErrorCode: VALUESTOEXCLUDE_CONDITION_TOGGLE_ERR_CODE}
+	ErrValuesToExactMatchToggle error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "valuesToExactMatch is disabled", ErrorCode: VALUESTOEXACTMATCH_CONDITION_TOGGLE_ERR_CODE}
+	ErrLowToHighToggle          error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "ascending sort is disabled", ErrorCode: LOWTOHIGH_CONDITION_TOGGLE_ERR_CODE}
+	ErrHighToLowToggle          error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "descending sort is disabled", ErrorCode: HIGHTOLOW_CONDITION_TOGGLE_ERR_CODE}
+	ErrAggregationToggle        error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "aggregations are disabled", ErrorCode: AGGREGATION_TOGGLE_ERR_CODE}
+	ErrCountMetricToggle        error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "COUNT metric is disabled", ErrorCode: COUNT_METRIC_TOGGLE_ERR_CODE}
+	ErrSumMetricToggle          error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "SUM metric is disabled", ErrorCode: SUM_METRIC_TOGGLE_ERR_CODE}
+	ErrAvgMetricToggle          error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "AVG metric is disabled", ErrorCode: AVG_METRIC_TOGGLE_ERR_CODE}
+	ErrMinMetricToggle          error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "MIN metric is disabled", ErrorCode: MIN_METRIC_TOGGLE_ERR_CODE}
+	ErrMaxMetricToggle          error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "MAX metric is disabled", ErrorCode: MAX_METRIC_TOGGLE_ERR_CODE}
+	ErrFacetToggle              error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "facets are disabled", ErrorCode: FACET_TOGGLE_ERR_CODE}
+	ErrHistogramToggle          error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "date histograms are disabled", ErrorCode: HISTOGRAM_TOGGLE_ERR_CODE}
+	ErrDistinctToggle           error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "distinct values are disabled", ErrorCode: DISTINCT_TOGGLE_ERR_CODE}
+
+	ErrSqlQueryExec      error = &ErrorResponseDTO{ErrorType: TESOQL_SQL_ERROR, ErrorMsg: "query failed", ErrorCode: SQL_QUERYEXEC_ERR_CODE}
+	ErrSqlColumns        error = &ErrorResponseDTO{ErrorType: TESOQL_SQL_ERROR, ErrorMsg: "columns cannot be read", ErrorCode: SQL_COLUMNS_ERR_CODE}
+	ErrSqlScan           error = &ErrorResponseDTO{ErrorType: TESOQL_SQL_ERROR, ErrorMsg: "rows cannot be scanned", ErrorCode: SQL_SCAN_ERR_CODE}
+	ErrSqlCountQueryExec error = &ErrorResponseDTO{ErrorType: TESOQL_SQL_ERROR, ErrorMsg: "count query failed", ErrorCode: SQL_COUNT_QUERYEXEC_ERR_CODE}
+	ErrMongoFind         error = &ErrorResponseDTO{ErrorType: TESOQL_MONGO_ERROR, ErrorMsg: "find failed", ErrorCode: MONGO_FIND_ERR_CODE}
+	ErrMongoCursor       error = &ErrorResponseDTO{ErrorType: TESOQL_MONGO_ERROR, ErrorMsg: "documents cannot be decoded", ErrorCode: MONGO_CURSOR_ERR_CODE}
+	ErrSqlPaging         error = &ErrorResponseDTO{ErrorType: TESOQL_SQL_ERROR, ErrorMsg: "paging is not supported", ErrorCode: SQL_PAGING_ERR_CODE}
+	ErrSqlDateTrunc      error = &ErrorResponseDTO{ErrorType: TESOQL_SQL_ERROR, ErrorMsg: "date truncation is not supported", ErrorCode: SQL_DATE_TRUNC_ERR_CODE}
+
+	ErrConfigEngine       error = &ErrorResponseDTO{ErrorType: TESOQL_CONFIG_ERROR, ErrorMsg: "engine is not supported", ErrorCode: CONFIG_ENGINE_ERR_CODE}
+	ErrConfigConnection   error = &ErrorResponseDTO{ErrorType: TESOQL_CONFIG_ERROR, ErrorMsg: "connection config is not valid", ErrorCode: CONFIG_CONNECTION_ERR_CODE}
+	ErrConfigIdentifier   error = &ErrorResponseDTO{ErrorType: TESOQL_CONFIG_ERROR, ErrorMsg: "identifier is not valid", ErrorCode: CONFIG_IDENTIFIER_ERR_CODE}
+	ErrConfigFieldType    error = &ErrorResponseDTO{ErrorType: TESOQL_CONFIG_ERROR, ErrorMsg: "field type is not valid", ErrorCode: CONFIG_FIELD_TYPE_ERR_CODE}
+	ErrConfigFacet        error = &ErrorResponseDTO{ErrorType: TESOQL_CONFIG_ERROR, ErrorMsg: "facets are not supported by the repository", ErrorCode: CONFIG_FACET_ERR_CODE}
+	ErrConfigHistogram    error = &ErrorResponseDTO{ErrorType: TESOQL_CONFIG_ERROR, ErrorMsg: "date histograms are not supported by the repository", ErrorCode: CONFIG_HISTOGRAM_ERR_CODE}
+	ErrConfigDistinct     error = &ErrorResponseDTO{ErrorType: TESOQL_CONFIG_ERROR, ErrorMsg: "distinct values are not supported by the repository", ErrorCode: CONFIG_DISTINCT_ERR_CODE}
+	ErrConfigSearchMode   error = &ErrorResponseDTO{ErrorType: TESOQL_CONFIG_ERROR, ErrorMsg: "search mode is not valid", ErrorCode: CONFIG_SEARCH_MODE_ERR_CODE}
+	ErrConfigSearchLocale error = &ErrorResponseDTO{ErrorType: TESOQL_CONFIG_ERROR, ErrorMsg: "search locale is not valid", ErrorCode: CONFIG_SEARCH_LOCALE_ERR_CODE}
+	ErrConnectionOpen     error = &ErrorResponseDTO{ErrorType: TESOQL_CONNECTION_ERROR, ErrorMsg: "connection cannot be opened", ErrorCode: CONNECTION_OPEN_ERR_CODE}
+	ErrConnectionPing     error = &ErrorResponseDTO{ErrorType: TESOQL_CONNECTION_ERROR, ErrorMsg: "database is not reachable", ErrorCode: CONNECTION_PING_ERR_CODE}
+	ErrConnectionClose    error = &ErrorResponseDTO{ErrorType: TESOQL_CONNECTION_ERROR, ErrorMsg: "connection cannot be closed", ErrorCode: CONNECTION_CLOSE_ERR_CODE}
+
+	ErrResultDecode error = &ErrorResponseDTO{ErrorType: TESOQL_DECODE_ERROR, ErrorMsg: "records cannot be decoded", ErrorCode: RESULT_DECODE_ERR_CODE}
+	ErrExportWrite  error = &ErrorResponseDTO{ErrorType: TESOQL_EXPORT_ERROR, ErrorMsg: "export cannot be written", ErrorCode: EXPORT_WRITE_ERR_CODE}
+	ErrExportHeader error = &ErrorResponseDTO{ErrorType: TESOQL_EXPORT_ERROR, ErrorMsg: "record does not fit the export header", ErrorCode: EXPORT_HEADER_ERR_CODE}
+)
+
+// Sentinel errors per error type, matching every error code of the type.
+var (
+	ErrValidation error = &ErrorResponseDTO{ErrorType: TESOQL_VALIDATION_ERROR, ErrorMsg: "validation failed"}
+	ErrToggle     error = &ErrorResponseDTO{ErrorType: TESOQL_TOGGLE_ERROR, ErrorMsg: "feature is disabled"}
+	ErrSql        error = &ErrorResponseDTO{ErrorType: TESOQL_SQL_ERROR, ErrorMsg: "sql repository failed"}
+	ErrMongo      error = &ErrorResponseDTO{ErrorType: TESOQL_MONGO_ERROR, ErrorMsg: "mongo repository failed"}
+	ErrConfig     error = &ErrorResponseDTO{ErrorType: TESOQL_CONFIG_ERROR, ErrorMsg: "config is not valid"}
+	ErrConnection error = &ErrorResponseDTO{ErrorType: TESOQL_CONNECTION_ERROR, ErrorMsg: "connection failed"}
+)
+
+// Error implements the error interface.
+//
+// Returns:
+//
+// - string: The error type, code, field (if any) and message.
+func (e *ErrorResponseDTO) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("%s %d (%s): %s", e.ErrorType, e.ErrorCode, e.Field, e.ErrorMsg)
+	}
+	return fmt.Sprintf("%s %d: %s", e.ErrorType, e.ErrorCode, e.ErrorMsg)
+}
+
+// Unwrap returns the underlying error of the database driver or of the context, if any,
+// so that errors.Is(err, context.DeadlineExceeded) and errors.As work through the DTO.
+//
+// Returns:
+//
+// - error: The underlying error, or nil.
+func (e *ErrorResponseDTO) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether the error matches target: an ErrorResponseDTO with the same
+// error code, or with the same error type when target has no error code (ErrValidation...).
+//
+// Returns:
+//
+// - bool: true when the error matches target.
+func (e *ErrorResponseDTO) Is(target error) bool {
+	t, ok := target.(*ErrorResponseDTO)
+	if !ok || t == nil {
+		return false
+	}
+	if t.ErrorCode == 0 {
+		return t.ErrorType == e.ErrorType
+	}
+	return t.ErrorCode == e.ErrorCode
+}
+
+// AsError converts the DTO into an error, keeping nil as a nil error. Assigning a nil
+// *ErrorResponseDTO to an error variable directly gives a non-nil error.
+//
+// Example usage:
+//
+//	_, _, _, errDTO := tesoQL.Service.Get(&jsonMapVariable)
+//	if err := errDTO.AsError(); err != nil {
+//		return fmt.Errorf("listing orders: %w", err)
+//	}
+//
+// Returns:
+//
+// - error: The DTO as an error, or nil.
+func (e *ErrorResponseDTO) AsError() error {
+	if e == nil {
+		return nil
+	}
+	return e
+}
+
+// withField sets the JsonMap field the error is about, as a dotted JSON path.
+func (e *ErrorResponseDTO) withField(field string) *ErrorResponseDTO {
+	e.Field = field
+	return e
+}
+
+// withCause keeps the underlying error for Unwrap.
+func (e *ErrorResponseDTO) withCause(err error) *ErrorResponseDTO {
+	e.Cause = err
+	return e
+}
@@ -0,0 +1,63 @@
+package tesoql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorResponseDTOIs(t *testing.T) {
+	sortErr := newResponse(TESOQL_VALIDATION_ERROR, "Field : 'secret' is not sortable.", SORTABLE_ERR_CODE).withField("sortConditions.secret")
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		is     bool
+	}{
+		{name: "same code", err: sortErr, target: ErrSortable, is: true},
+		{name: "other code", err: sortErr, target: ErrSearchable, is: false},
+		{name: "same type", err: sortErr, target: ErrValidation, is: true},
+		{name: "other type", err: sortErr, target: ErrSql, is: false},
+		{name: "wrapped", err: fmt.Errorf("listing orders: %w", sortErr), target: ErrSortable, is: true},
+		{
+			name:   "cause",
+			err:    newResponse(TESOQL_SQL_ERROR, "canceled", SQL_QUERYEXEC_ERR_CODE).withCause(context.DeadlineExceeded),
+			target: context.DeadlineExceeded,
+			is:     true,
+		},
+		{name: "plain error", err: sortErr, target: errors.New("field is not sortable"), is: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if is := errors.Is(tt.err, tt.target); is != tt.is {
+				t.Errorf("errors.Is() = %t, want %t", is, tt.is)
+			}
+		})
+	}
+}
+
+func TestErrorResponseDTOAs(t *testing.T) {
+	err := fmt.Errorf("listing orders: %w", newResponse(TESOQL_VALIDATION_ERROR, "Cursor is not valid.", CURSOR_ERR_CODE).withField("pagination.cursor"))
+	var errDTO *ErrorResponseDTO
+	if !errors.As(err, &errDTO) {
+		t.Fatal("errors.As() = false")
+	}
+	if errDTO.ErrorCode != CURSOR_ERR_CODE || errDTO.Field != "pagination.cursor" {
+		t.Errorf("errDTO = %+v", errDTO)
+	}
+	if want := "TESOQL_VALIDATION_ERROR 400019 (pagination.cursor): Cursor is not valid."; errDTO.Error() != want {
+		t.Errorf("Error() = %s, want %s", errDTO.Error(), want)
+	}
+}
+
+func TestAsError(t *testing.T) {
+	var errDTO *ErrorResponseDTO
+	if err := errDTO.AsError(); err != nil {
+		t.Errorf("AsError() of nil = %v, want nil", err)
+	}
+	errDTO = newResponse(TESOQL_MONGO_ERROR, "Mongo find failed.", MONGO_FIND_ERR_CODE)
+	if err := errDTO.AsError(); !errors.Is(err, ErrMongoFind) {
+		t.Errorf("AsError() = %v, want ErrMongoFind", err)
+	}
+}
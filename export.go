@@ -0,0 +1,357 @@
+package tesoql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// exportColumn is a column of an export, named after the FieldsMap alias of a field.
+type exportColumn struct {
+	name     string // header, the alias of the field
+	field    string // database field, read from the records
+	dateTime bool
+	scalar   bool    // declared in FieldsMap.FieldTypes, written whole instead of flattened
+	metric   *Metric // metric of the aggregations, nil for the other columns
+}
+
+// exportField is a value of a flattened record.
+type exportField struct {
+	name  string
+	value interface{}
+}
+
+// ExportCSV streams the records matching the JsonMap to w as CSV, the way Stream reads
+// them. The header holds the ProjectionFields of the JsonMap in their order, under their
+// FieldsMap aliases, or every FieldsMap.ProjectionFields alias when the JsonMap does not
+// project. Nested documents are flattened into dotted columns (address.city), arrays are
+// written as JSON. DateTimeFieldKeys fields, and every time value, are written as RFC3339
+// in UTC. The flattened columns of the header are read from the first record, a later
+// record with a value outside of them fails with EXPORT_HEADER_ERR_CODE instead of losing
+// it. Fields declared in FieldsMap.FieldTypes are not flattened, a document is written
+// as JSON in their column.
+//
+// Example usage:
+//
+//	w.Header().Set("Content-Type", "text/csv")
+//	count, err := tesoQL.Service.ExportCSV(r.Context(), w, &jsonMapVariable)
+//
+// Returns:
+//
+// - int: The number of records written.
+//
+// - *ErrorResponseDTO: An error response, if any occurred during validation, retrieval or writing.
+func (s *Service) ExportCSV(ctx context.Context, w io.Writer, jsonMap *JsonMap) (int, *ErrorResponseDTO) {
+	writer := csv.NewWriter(w)
+	var header []string
+	inHeader := make(map[string]bool)
+	record := 0
+	count, err := s.export(ctx, jsonMap, func(columns []exportColumn, fields []exportField) error {
+		record++
+		if header == nil {
+			header = make([]string, 0, len(fields))
+			if fields == nil {
+				// no record, the header is made of the columns alone
+				for _, c := range columns {
+					header = append(header, c.name)
+				}
+			}
+			for _, f := range fields {
+				header = append(header, f.name)
+				inHeader[f.name] = true
+			}
+			if len(header) > 0 {
+				if err := writer.Write(header); err != nil {
+					return err
+				}
+			}
+			if fields == nil {
+				return nil
+			}
+		}
+		values := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			if !inHeader[f.name] && f.value != nil {
+				// e.g. address.city, when address was null in the first record
+				return newResponse(TESOQL_EXPORT_ERROR, fmt.Sprintf("Field '%s' of record %d is not a column of the CSV header, which is built from the first record. Declare the kind of its field in FieldsMap.FieldTypes to write it in one column.", f.name, record), EXPORT_HEADER_ERR_CODE).withField(f.name)
+			}
+			values[f.name] = f.value
+		}
+		record := make([]string, len(header))
+		for i, name := range header {
+			record[i] = csvValue(values[name])
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		return count, err
+	}
+	writer.Flush()
+	if flushErr := writer.Error(); flushErr != nil {
+		return count, newResponse(TESOQL_EXPORT_ERROR, flushErr.Error(), EXPORT_WRITE_ERR_CODE).withCause(flushErr)
+	}
+	return count, nil
+}
+
+// ExportNDJSON streams the records matching the JsonMap to w as newline delimited JSON,
+// one object per record. The keys follow the same rules as the ExportCSV columns, in the
+// same order: FieldsMap aliases, nested documents flattened with dotted keys, time values
+// written as RFC3339 in UTC.
+//
+// Example usage:
+//
+//	w.Header().Set("Content-Type", "application/x-ndjson")
+//	count, err := tesoQL.Service.ExportNDJSON(r.Context(), w, &jsonMapVariable)
+//
+// Returns:
+//
+// - int: The number of records written.
+//
+// - *ErrorResponseDTO: An error response, if any occurred during validation, retrieval or writing.
+func (s *Service) ExportNDJSON(ctx context.Context, w io.Writer, jsonMap *JsonMap) (int, *ErrorResponseDTO) {
+	writer := bufio.NewWriter(w)
+	count, err := s.export(ctx, jsonMap, func(columns []exportColumn, fields []exportField) error {
+		if fields == nil {
+			return nil
+		}
+		var line bytes.Buffer
+		line.WriteByte('{')
+		for i, f := range fields {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			key, err := json.Marshal(f.name)
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(jsonValue(f.value))
+			if err != nil {
+				return err
+			}
+			line.Write(key)
+			line.WriteByte(':')
+			line.Write(value)
+		}
+		line.WriteString("}\n")
+		_, err := writer.Write(line.Bytes())
+		return err
+	})
+	if err != nil {
+		return count, err
+	}
+	if flushErr := writer.Flush(); flushErr != nil {
+		return count, newResponse(TESOQL_EXPORT_ERROR, flushErr.Error(), EXPORT_WRITE_ERR_CODE).withCause(flushErr)
+	}
+	return count, nil
+}
+
+// export streams the records of the JsonMap and hands them to write flattened. write is
+// called once with nil fields when there is no record.
+func (s *Service) export(ctx context.Context, jsonMap *JsonMap, write func(columns []exportColumn, fields []exportField) error) (int, *ErrorResponseDTO) {
+	rows, errDTO := s.Stream(ctx, jsonMap)
+	if errDTO != nil {
+		return 0, errDTO
+	}
+	defer rows.Close()
+
+	columns := projectedColumns(s.fieldsMap, jsonMap.ProjectionFields, jsonMap.Aggregations)
+	count := 0
+	for rows.Next() {
+		if err := write(columns, flattenRecord(columns, rows.Row())); err != nil {
+			if dto, ok := err.(*ErrorResponseDTO); ok {
+				return count, dto
+			}
+			return count, newResponse(TESOQL_EXPORT_ERROR, err.Error(), EXPORT_WRITE_ERR_CODE).withCause(err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		if dto, ok := err.(*ErrorResponseDTO); ok {
+			return count, dto
+		}
+		return count, newResponse(TESOQL_EXPORT_ERROR, err.Error(), EXPORT_WRITE_ERR_CODE).withCause(err)
+	}
+	if count == 0 {
+		if err := write(columns, nil); err != nil {
+			return 0, newResponse(TESOQL_EXPORT_ERROR, err.Error(), EXPORT_WRITE_ERR_CODE).withCause(err)
+		}
+	}
+	return count, nil
+}
+
+// projectedColumns returns the projected columns of a JsonMap, every FieldsMap projection
+// field when the JsonMap does not project, or nil when neither is known, in which case
+// the records are exported with all of their fields. With aggregations, the columns are
+// the groups followed by the metrics.
+func projectedColumns(fieldsMap *FieldsMap, projection []string, aggregations *Aggregations) []exportColumn {
+	var fm FieldsMap
+	if fieldsMap != nil {
+		fm = *fieldsMap
+	}
+	if aggregations != nil {
+		var columns []exportColumn
+		for _, field := range aggregations.GroupBy {
+			_, dateTime := fm.DateTimeFieldKeys[field]
+			columns = append(columns, exportColumn{name: field, field: field, dateTime: dateTime})
+		}
+		for i := range aggregations.Metrics {
+			metric := &aggregations.Metrics[i]
+			columns = append(columns, exportColumn{name: metric.name(), field: metric.name(), metric: metric})
+		}
+		return columns
+	}
+	aliases := projection
+	if len(aliases) == 0 {
+		aliases = sortedKeys(fm.ProjectionFields)
+	}
+	var columns []exportColumn
+	for _, alias := range aliases {
+		field, exists := fm.ProjectionFields[alias]
+		if !exists || isRawExpression(field) {
+			// raw expressions are selected under their alias
+			field = alias
+		}
+		_, dateTime := fm.DateTimeFieldKeys[alias]
+		_, scalar := fm.FieldTypes[alias]
+		columns = append(columns, exportColumn{name: alias, field: field, dateTime: dateTime, scalar: scalar})
+	}
+	return columns
+}
+
+// flattenRecord reads the columns from a record, flattening nested documents with dotted keys.
+func flattenRecord(columns []exportColumn, record map[string]interface{}) []exportField {
+	fields := []exportField{}
+	if columns == nil {
+		for _, key := range sortedKeys(record) {
+			fields = flattenValue(fields, key, record[key], false)
+		}
+		return fields
+	}
+	for _, c := range columns {
+		value, _ := lookupField(record, c.field)
+		if c.scalar {
+			if c.dateTime {
+				value = parseExportDateTime(value)
+			}
+			fields = append(fields, exportField{name: c.name, value: value})
+			continue
+		}
+		fields = flattenValue(fields, c.name, value, c.dateTime)
+	}
+	return fields
+}
+
+func flattenValue(fields []exportField, name string, value interface{}, dateTime bool) []exportField {
+	switch doc := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(doc) {
+			fields = flattenValue(fields, name+"."+key, doc[key], false)
+		}
+		return fields
+	case primitive.M:
+		for _, key := range sortedKeys(doc) {
+			fields = flattenValue(fields, name+"."+key, doc[key], false)
+		}
+		return fields
+	case primitive.D:
+		for _, e := range doc {
+			fields = flattenValue(fields, name+"."+e.Key, e.Value, false)
+		}
+		return fields
+	}
+	if dateTime {
+		value = parseExportDateTime(value)
+	}
+	return append(fields, exportField{name: name, value: value})
+}
+
+// parseExportDateTime reads the textual date times of SQL drivers, so that they are
+// written in the same format as native time values.
+func parseExportDateTime(value interface{}) interface{} {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return value
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return value
+}
+
+// jsonValue converts driver and bson values into values with a stable JSON encoding.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case primitive.DateTime:
+		return v.Time().UTC().Format(time.RFC3339Nano)
+	case primitive.Timestamp:
+		return time.Unix(int64(v.T), 0).UTC().Format(time.RFC3339Nano)
+	case primitive.ObjectID:
+		return v.Hex()
+	case primitive.Decimal128:
+		return v.String()
+	case primitive.Binary:
+		return base64.StdEncoding.EncodeToString(v.Data)
+	case primitive.A:
+		return jsonArray(v)
+	case []interface{}:
+		return jsonArray(v)
+	case map[string]interface{}, primitive.M, primitive.D:
+		object := make(map[string]interface{})
+		for _, f := range flattenValue(nil, "", v, false) {
+			object[f.name[1:]] = jsonValue(f.value)
+		}
+		return object
+	}
+	return value
+}
+
+func jsonArray(values []interface{}) []interface{} {
+	array := make([]interface{}, len(values))
+	for i, value := range values {
+		array[i] = jsonValue(value)
+	}
+	return array
+}
+
+func csvValue(value interface{}) string {
+	switch v := jsonValue(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case []interface{}, map[string]interface{}:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprint(v)
+	}
+}
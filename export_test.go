@@ -0,0 +1,161 @@
+package tesoql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newExportService(fm *FieldsMap, records ...map[string]interface{}) *Service {
+	return newTesoQlService(&fakeRepository{fieldsMap: fm, records: records}, &Config{FieldsMap: fm})
+}
+
+func TestExport(t *testing.T) {
+	oid, _ := primitive.ObjectIDFromHex("6630b2f0c2a4b1d2e3f40516")
+	tests := []struct {
+		name    string
+		fm      *FieldsMap
+		records []map[string]interface{}
+		csv     string
+		ndjson  string
+	}{
+		{
+			name:    "flattened subdocuments",
+			fm:      &FieldsMap{ProjectionFields: map[string]string{"id": "id", "address": "address"}},
+			records: []map[string]interface{}{{"id": int64(1), "address": map[string]interface{}{"zip": "35", "city": "Izmir"}}},
+			csv:     "address.city,address.zip,id\nIzmir,35,1\n",
+			ndjson:  `{"address.city":"Izmir","address.zip":"35","id":1}` + "\n",
+		},
+		{
+			name:    "scalar columns",
+			fm:      &FieldsMap{ProjectionFields: map[string]string{"id": "id", "address": "address"}, FieldTypes: map[string]string{"address": COLUMN_STRING}},
+			records: []map[string]interface{}{{"id": int64(1), "address": primitive.D{{Key: "city", Value: "Izmir"}}}},
+			csv:     "address,id\n\"{\"\"city\"\":\"\"Izmir\"\"}\",1\n",
+			ndjson:  `{"address":{"city":"Izmir"},"id":1}` + "\n",
+		},
+		{
+			name:    "values",
+			fm:      &FieldsMap{ProjectionFields: map[string]string{"id": "_id", "note": "note", "paid": "paid", "amount": "amount", "tags": "tags", "code": "code"}},
+			records: []map[string]interface{}{{"_id": oid, "note": nil, "paid": true, "amount": 12.5, "tags": primitive.A{"a", "b"}, "code": []byte("x1")}},
+			csv:     "amount,code,id,note,paid,tags\n12.5,x1,6630b2f0c2a4b1d2e3f40516,,true,\"[\"\"a\"\",\"\"b\"\"]\"\n",
+			ndjson:  `{"amount":12.5,"code":"x1","id":"6630b2f0c2a4b1d2e3f40516","note":null,"paid":true,"tags":["a","b"]}` + "\n",
+		},
+		{
+			name:    "date time",
+			fm:      &FieldsMap{ProjectionFields: map[string]string{"createdAt": "created_at"}, DateTimeFieldKeys: map[string]string{"createdAt": "created_at"}},
+			records: []map[string]interface{}{{"created_at": []byte("2024-05-01 10:30:00")}, {"created_at": primitive.NewDateTimeFromTime(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC))}},
+			csv:     "createdAt\n2024-05-01T10:30:00Z\n2024-05-01T10:30:00Z\n",
+			ndjson:  `{"createdAt":"2024-05-01T10:30:00Z"}` + "\n" + `{"createdAt":"2024-05-01T10:30:00Z"}` + "\n",
+		},
+		{
+			name:   "no records",
+			fm:     &FieldsMap{ProjectionFields: map[string]string{"id": "id", "name": "name"}},
+			csv:    "id,name\n",
+			ndjson: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newExportService(tt.fm, tt.records...)
+			var csv, ndjson bytes.Buffer
+			if count, err := service.ExportCSV(context.Background(), &csv, &JsonMap{}); err != nil || count != len(tt.records) {
+				t.Fatalf("ExportCSV() = %d, %v", count, err)
+			}
+			if csv.String() != tt.csv {
+				t.Errorf("csv = %q\nwant  %q", csv.String(), tt.csv)
+			}
+			if count, err := service.ExportNDJSON(context.Background(), &ndjson, &JsonMap{}); err != nil || count != len(tt.records) {
+				t.Fatalf("ExportNDJSON() = %d, %v", count, err)
+			}
+			if ndjson.String() != tt.ndjson {
+				t.Errorf("ndjson = %q\nwant     %q", ndjson.String(), tt.ndjson)
+			}
+		})
+	}
+}
+
+func TestExportCSVHeader(t *testing.T) {
+	fm := &FieldsMap{ProjectionFields: map[string]string{"id": "id", "address": "address"}}
+	service := newExportService(fm,
+		map[string]interface{}{"id": int64(1), "address": map[string]interface{}{"city": "Izmir"}},
+		map[string]interface{}{"id": int64(2), "address": map[string]interface{}{"city": "Bursa", "zip": "16"}},
+	)
+	var csv bytes.Buffer
+	count, err := service.ExportCSV(context.Background(), &csv, &JsonMap{})
+	if !errors.Is(err, ErrExportHeader) || err.Field != "address.zip" || count != 1 {
+		t.Errorf("ExportCSV() = %d, %v, want ErrExportHeader on address.zip", count, err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestExportWriteError(t *testing.T) {
+	service, _ := newFakeService(&Config{}, 3)
+	if _, err := service.ExportCSV(context.Background(), failingWriter{}, &JsonMap{}); !errors.Is(err, ErrExportWrite) {
+		t.Errorf("ExportCSV() = %v, want ErrExportWrite", err)
+	}
+	if _, err := service.ExportNDJSON(context.Background(), failingWriter{}, &JsonMap{}); !errors.Is(err, ErrExportWrite) {
+		t.Errorf("ExportNDJSON() = %v, want ErrExportWrite", err)
+	}
+}
+
+// slowRepository streams its records with a delay per round-trip, the round-trip
+// reading the record at block (1-based) waiting until the context is done.
+type slowRepository struct {
+	fakeRepository
+	delay time.Duration
+	block int
+}
+
+func (r *slowRepository) Stream(ctx context.Context, jsonMap *JsonMap) (RowIterator, *ErrorResponseDTO) {
+	return &slowIterator{sliceIterator: sliceIterator{records: r.records, index: -1}, ctx: ctx, delay: r.delay, block: r.block}, nil
+}
+
+type slowIterator struct {
+	sliceIterator
+	ctx   context.Context
+	delay time.Duration
+	block int
+	err   error
+}
+
+func (it *slowIterator) Next() bool {
+	if it.index+2 == it.block {
+		<-it.ctx.Done()
+		it.err = it.ctx.Err()
+		return false
+	}
+	time.Sleep(it.delay)
+	return it.sliceIterator.Next()
+}
+
+func (it *slowIterator) Err() error {
+	return it.err
+}
+
+func TestExportTimeout(t *testing.T) {
+	fm := &FieldsMap{ProjectionFields: map[string]string{"id": "id"}}
+	records := []map[string]interface{}{{"id": int64(1)}, {"id": int64(2)}, {"id": int64(3)}, {"id": int64(4)}, {"id": int64(5)}}
+	// the export takes longer than the timeout, none of its round-trips does
+	service := newTesoQlService(&slowRepository{fakeRepository: fakeRepository{fieldsMap: fm, records: records}, delay: 20 * time.Millisecond},
+		&Config{FieldsMap: fm, DefaultTimeout: 50 * time.Millisecond})
+	var ndjson bytes.Buffer
+	if count, err := service.ExportNDJSON(context.Background(), &ndjson, &JsonMap{}); err != nil || count != len(records) {
+		t.Errorf("ExportNDJSON() = %d, %v, want %d records", count, err, len(records))
+	}
+
+	service = newTesoQlService(&slowRepository{fakeRepository: fakeRepository{fieldsMap: fm, records: records}, block: 3},
+		&Config{FieldsMap: fm, DefaultTimeout: 50 * time.Millisecond})
+	var csv bytes.Buffer
+	if count, err := service.ExportCSV(context.Background(), &csv, &JsonMap{}); !errors.Is(err, context.DeadlineExceeded) || count != 2 {
+		t.Errorf("ExportCSV() = %d, %v, want 2 records and context.DeadlineExceeded", count, err)
+	}
+}
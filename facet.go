@@ -0,0 +1,320 @@
+package tesoql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FacetRepository is a Repository that can compute the facet counts of a JsonMap.
+// The built-in Mongo and SQL repositories implement it.
+type FacetRepository interface {
+	Repository
+	Facets(ctx context.Context, jsonMap *JsonMap) (map[string][]FacetBucket, *ErrorResponseDTO)
+}
+
+// Facets computes the counts of the facets of the JsonMap over the records matching its
+// filters, ignoring sorting and pagination. The JsonMap goes through the same validations
+// as GetContext. Service.Query returns the facets along with the page of records.
+//
+// Example usage:
+//
+//	jsonMapVariable.Facets = []tesoql.Facet{{Field: "status", Disjunctive: true}}
+//	facets, err := tesoQL.Service.Facets(r.Context(), &jsonMapVariable)
+//	if err != nil {
+//		// Handle error
+//	}
+//	for _, bucket := range facets["status"] {
+//		fmt.Println(bucket.Value, bucket.Count)
+//	}
+//
+// Returns:
+//
+// - map[string][]FacetBucket: The buckets of every facet, by facet name.
+//
+// - *ErrorResponseDTO: An error response, if any occurred during validation or while running the queries.
+func (s *Service) Facets(ctx context.Context, jsonMap *JsonMap) (map[string][]FacetBucket, *ErrorResponseDTO) {
+	validationErr := s.validate(jsonMap)
+	if validationErr != nil {
+		return nil, validationErr
+	}
+	ctx, cancel := withDefaultTimeout(ctx, s.defaultTimeout)
+	defer cancel()
+	return s.facets(ctx, jsonMap)
+}
+
+// facets computes the facets of a validated JsonMap, nil when none is requested.
+func (s *Service) facets(ctx context.Context, jsonMap *JsonMap) (map[string][]FacetBucket, *ErrorResponseDTO) {
+	if len(jsonMap.Facets) == 0 {
+		return nil, nil
+	}
+	repo, ok := s.repo.(FacetRepository)
+	if !ok {
+		return nil, newResponse(TESOQL_CONFIG_ERROR, "The repository does not support facets.", CONFIG_FACET_ERR_CODE).withField("facets")
+	}
+	return repo.Facets(ctx, jsonMap)
+}
+
+// name returns the name of the facet in the results.
+func (f *Facet) name() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return f.Field
+}
+
+// limit returns the number of values of a value facet, clamped to MAX_FACET_LIMIT.
+func (f *Facet) limit() int64 {
+	switch {
+	case f.Limit <= 0:
+		return DEFAULT_FACET_LIMIT
+	case f.Limit > MAX_FACET_LIMIT:
+		return MAX_FACET_LIMIT
+	}
+	return int64(f.Limit)
+}
+
+// key returns the name of the range in the results.
+func (r *FacetRange) key() string {
+	if r.Key != "" {
+		return r.Key
+	}
+	from, to := "*", "*"
+	if r.From != nil {
+		from = fmt.Sprintf("%v", r.From)
+	}
+	if r.To != nil {
+		to = fmt.Sprintf("%v", r.To)
+	}
+	return from + "-" + to
+}
+
+// validateFacets checks that the facets of the JsonMap are on facet fields, that their
+// names are unique and valid, and that their ranges have a bound and unique keys.
+//
+// Returns:
+//
+// - *ErrorResponseDTO: An error response if validation fails, or nil if validation passes.
+func (jm *JsonMap) validateFacets(fm *FieldsMap) *ErrorResponseDTO {
+	var facetFields map[string]string
+	if fm != nil {
+		facetFields = fm.FacetFields
+	}
+	names := make(map[string]bool)
+	for i := range jm.Facets {
+		facet := &jm.Facets[i]
+		path := fmt.Sprintf("facets.%d", i)
+		if _, exists := facetFields[facet.Field]; !exists {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Field : '%v' is not a facet field.", facet.Field), FACET_ERR_CODE).withField(path)
+		}
+		name := facet.name()
+		if !identifierPattern.MatchString(name) {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Facet name '%v' is not valid, letters, digits and underscores are allowed.", name), FACET_ERR_CODE).withField(path)
+		}
+		if names[name] {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Name '%v' is used more than once in facets.", name), FACET_ERR_CODE).withField(path)
+		}
+		names[name] = true
+		if facet.Limit < 0 {
+			return newResponse(TESOQL_VALIDATION_ERROR, "Facet limit cannot be negative.", FACET_ERR_CODE).withField(path + ".limit")
+		}
+
+		keys := make(map[string]bool)
+		for j := range facet.Ranges {
+			rangePath := fmt.Sprintf("%s.ranges.%d", path, j)
+			if facet.Ranges[j].From == nil && facet.Ranges[j].To == nil {
+				return newResponse(TESOQL_VALIDATION_ERROR, "Facet range must have a 'from' or a 'to' bound.", FACET_ERR_CODE).withField(rangePath)
+			}
+			key := facet.Ranges[j].key()
+			if keys[key] {
+				return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Key '%v' is used more than once in the ranges of the facet.", key), FACET_ERR_CODE).withField(rangePath)
+			}
+			keys[key] = true
+		}
+	}
+	return nil
+}
+
+// facetFilters returns the JsonMap whose filters the facet is counted over. A disjunctive
+// facet drops the conditions and searches on its own column. The filter tree is always
+// kept, since its predicates on the column can be combined with any other predicate.
+func (jm *JsonMap) facetFilters(fm *FieldsMap, facet *Facet) *JsonMap {
+	if !facet.Disjunctive {
+		return jm
+	}
+	column := fm.FacetFields[facet.Field]
+	filters := *jm
+	filters.Conditions = make(map[string]ConditionOperators)
+	for key, condition := range jm.Conditions {
+		if fm.ConditionFields[key] != column {
+			filters.Conditions[key] = condition
+		}
+	}
+	filters.Search = make(map[string][]interface{})
+	for key, values := range jm.Search {
+		if fm.SearchFields[key] != column {
+			filters.Search[key] = values
+		}
+	}
+	return &filters
+}
+
+// facetBound returns a range bound as it is compared to the facet column, dates parsed.
+func facetBound(fm *FieldsMap, facet *Facet, bound interface{}) interface{} {
+	return treatDateTime(facet.Field, fm.DateTimeFieldKeys, bound)
+}
+
+// getSqlFacet returns the query counting the records of a facet. A value facet selects
+// the most frequent values into FACET_VALUE_COLUMN and their counts into FACET_COUNT_COLUMN,
+// a range facet selects one count per range into FACET_RANGE_COLUMN_PREFIX and its index.
+func getSqlFacet(fm *FieldsMap, jm *JsonMap, facet *Facet, d *Dialect) *SqlQuery {
+	d = dialectOrGeneric(d)
+	args := newSqlArgs(d)
+	column := d.quoteIdentifier(fm.FacetFields[facet.Field])
+
+	query := &SqlQuery{dialect: d}
+	if len(facet.Ranges) > 0 {
+		var fields []string
+		for i := range facet.Ranges {
+			var bounds []string
+			if facet.Ranges[i].From != nil {
+				bounds = append(bounds, fmt.Sprintf("%s >= %s", column, args.bind(facetBound(fm, facet, facet.Ranges[i].From))))
+			}
+			if facet.Ranges[i].To != nil {
+				bounds = append(bounds, fmt.Sprintf("%s < %s", column, args.bind(facetBound(fm, facet, facet.Ranges[i].To))))
+			}
+			fields = append(fields, fmt.Sprintf("SUM(CASE WHEN %s THEN 1 ELSE 0 END) AS %s",
+				strings.Join(bounds, " AND "), d.quoteIdentifier(fmt.Sprintf("%s%d", FACET_RANGE_COLUMN_PREFIX, i))))
+		}
+		query.Select = strings.Join(fields, ", ")
+	} else {
+		query.Select = fmt.Sprintf("%s AS %s, COUNT(*) AS %s", column, d.quoteIdentifier(FACET_VALUE_COLUMN), d.quoteIdentifier(FACET_COUNT_COLUMN))
+		query.GroupBy = fmt.Sprintf(" GROUP BY %s", column)
+		query.OrderBy = fmt.Sprintf(" ORDER BY COUNT(*) DESC, %s ASC", column)
+		query.limit = facet.limit()
+		query.Limit, query.Offset = d.pagingClauses(query.limit, 0)
+	}
+	query.Where = getSqlFilter(fm, jm.facetFilters(fm, facet), args)
+	query.Args = d.bindArgs(args.values)
+	return query
+}
+
+// sqlFacetBuckets reads the buckets of a facet from the rows of its query.
+func sqlFacetBuckets(facet *Facet, rows []map[string]interface{}) []FacetBucket {
+	buckets := []FacetBucket{}
+	if len(facet.Ranges) > 0 {
+		var counts map[string]interface{}
+		if len(rows) > 0 {
+			counts = rows[0]
+		}
+		for i := range facet.Ranges {
+			count := toInt(counts[fmt.Sprintf("%s%d", FACET_RANGE_COLUMN_PREFIX, i)])
+			buckets = append(buckets, FacetBucket{Value: facet.Ranges[i].key(), Count: count})
+		}
+		return buckets
+	}
+	for _, row := range rows {
+		value := row[FACET_VALUE_COLUMN]
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		buckets = append(buckets, FacetBucket{Value: value, Count: toInt(row[FACET_COUNT_COLUMN])})
+	}
+	return buckets
+}
+
+// mongoFacetPipeline is an aggregation computing facets of a JsonMap. The facets that
+// share the filters of the JsonMap are computed together by a $facet stage, one
+// sub-pipeline per facet name; a disjunctive facet is computed alone, its stages following
+// the match of its own filters.
+type mongoFacetPipeline struct {
+	pipeline mongo.Pipeline
+	filters  *JsonMap // filters matched by the pipeline
+	facet    *Facet   // disjunctive facet computed alone, nil for the $facet stage
+}
+
+// getMongoFacetPipelines returns the aggregation pipelines computing the facets of the
+// JsonMap. Every pipeline starts with the $match of its filters, so that indexes can be
+// used, and a full-text search is allowed, $text being rejected inside $facet.
+func getMongoFacetPipelines(fm *FieldsMap, jm *JsonMap) []mongoFacetPipeline {
+	var pipelines []mongoFacetPipeline
+	stages := bson.D{}
+	for i := range jm.Facets {
+		facet := &jm.Facets[i]
+		if facet.Disjunctive {
+			filters := jm.facetFilters(fm, facet)
+			pipeline := mongoFacetMatch(fm, filters)
+			for _, stage := range mongoFacetStages(fm, facet) {
+				pipeline = append(pipeline, stage.(bson.D))
+			}
+			pipelines = append(pipelines, mongoFacetPipeline{pipeline: pipeline, filters: filters, facet: facet})
+			continue
+		}
+		stages = append(stages, bson.E{Key: facet.name(), Value: mongoFacetStages(fm, facet)})
+	}
+	if len(stages) > 0 {
+		pipeline := append(mongoFacetMatch(fm, jm), bson.D{{"$facet", stages}})
+		pipelines = append([]mongoFacetPipeline{{pipeline: pipeline, filters: jm}}, pipelines...)
+	}
+	return pipelines
+}
+
+// mongoFacetMatch returns the $match stage of the filters of a facet pipeline, none when
+// nothing is filtered.
+func mongoFacetMatch(fm *FieldsMap, jm *JsonMap) mongo.Pipeline {
+	if filter := getMongoFilter(fm, jm); filter != nil {
+		return mongo.Pipeline{{{"$match", *filter}}}
+	}
+	return mongo.Pipeline{}
+}
+
+// mongoFacetStages returns the stages counting the buckets of a facet over the matched documents.
+func mongoFacetStages(fm *FieldsMap, facet *Facet) bson.A {
+	field := "$" + fm.FacetFields[facet.Field]
+	if len(facet.Ranges) > 0 {
+		group := bson.D{{"_id", nil}}
+		for j := range facet.Ranges {
+			// null and missing values sort before any bound in aggregation expressions
+			bounds := bson.A{bson.D{{"$gt", bson.A{field, nil}}}}
+			if facet.Ranges[j].From != nil {
+				bounds = append(bounds, bson.D{{"$gte", bson.A{field, facetBound(fm, facet, facet.Ranges[j].From)}}})
+			}
+			if facet.Ranges[j].To != nil {
+				bounds = append(bounds, bson.D{{"$lt", bson.A{field, facetBound(fm, facet, facet.Ranges[j].To)}}})
+			}
+			group = append(group, bson.E{
+				Key:   fmt.Sprintf("%s%d", FACET_RANGE_COLUMN_PREFIX, j),
+				Value: bson.D{{"$sum", bson.D{{"$cond", bson.A{bson.D{{"$and", bounds}}, 1, 0}}}}},
+			})
+		}
+		return bson.A{bson.D{{"$group", group}}}
+	}
+	return bson.A{
+		bson.D{{"$group", bson.D{{"_id", field}, {FACET_COUNT_COLUMN, bson.D{{"$sum", 1}}}}}},
+		bson.D{{"$sort", bson.D{{FACET_COUNT_COLUMN, -1}, {"_id", 1}}}},
+		bson.D{{"$limit", facet.limit()}},
+	}
+}
+
+// mongoFacetBuckets reads the buckets of a facet from the output of its sub-pipeline.
+func mongoFacetBuckets(facet *Facet, documents []bson.M) []FacetBucket {
+	buckets := []FacetBucket{}
+	if len(facet.Ranges) > 0 {
+		var counts bson.M
+		if len(documents) > 0 {
+			counts = documents[0]
+		}
+		for i := range facet.Ranges {
+			count := toInt(counts[fmt.Sprintf("%s%d", FACET_RANGE_COLUMN_PREFIX, i)])
+			buckets = append(buckets, FacetBucket{Value: facet.Ranges[i].key(), Count: count})
+		}
+		return buckets
+	}
+	for _, document := range documents {
+		buckets = append(buckets, FacetBucket{Value: document["_id"], Count: toInt(document[FACET_COUNT_COLUMN])})
+	}
+	return buckets
+}
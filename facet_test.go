@@ -0,0 +1,166 @@
+package tesoql
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSqlFacet(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields: map[string]string{"status": "status", "amount": "amount"},
+		FacetFields:     map[string]string{"status": "status", "amount": "amount"},
+	}
+	jm := &JsonMap{
+		Conditions: map[string]ConditionOperators{"status": {ValuesToExactMatch: []interface{}{"shipped"}}, "amount": {GreaterThan: 5}},
+		Facets: []Facet{
+			{Field: "status", Limit: 3},
+			{Field: "status", Name: "allStatuses", Disjunctive: true},
+			{Field: "amount", Ranges: []FacetRange{{To: 10}, {From: 10, To: 100, Key: "mid"}, {From: 100}}},
+		},
+	}
+	tests := []struct {
+		name      string
+		facet     int
+		dialect   *Dialect
+		statement string
+		args      []interface{}
+	}{
+		{
+			name:      "values",
+			dialect:   PostgresDialect,
+			statement: `SELECT "status" AS "tesoql_value", COUNT(*) AS "tesoql_count" FROM "orders" WHERE 1=1 AND "amount" > $1 AND "status" IN ($2) GROUP BY "status" ORDER BY COUNT(*) DESC, "status" ASC LIMIT 3 OFFSET 0`,
+			args:      []interface{}{5, "shipped"},
+		},
+		{
+			name:      "values with offset fetch",
+			dialect:   SqlServerDialect,
+			statement: `SELECT [status] AS [tesoql_value], COUNT(*) AS [tesoql_count] FROM [orders] WHERE 1=1 AND [amount] > @p1 AND [status] IN (@p2) GROUP BY [status] ORDER BY COUNT(*) DESC, [status] ASC OFFSET 0 ROWS FETCH NEXT 3 ROWS ONLY`,
+			args:      []interface{}{5, "shipped"},
+		},
+		{
+			name:      "disjunctive",
+			facet:     1,
+			dialect:   PostgresDialect,
+			statement: `SELECT "status" AS "tesoql_value", COUNT(*) AS "tesoql_count" FROM "orders" WHERE 1=1 AND "amount" > $1 GROUP BY "status" ORDER BY COUNT(*) DESC, "status" ASC LIMIT 10 OFFSET 0`,
+			args:      []interface{}{5},
+		},
+		{
+			name:    "ranges",
+			facet:   2,
+			dialect: PostgresDialect,
+			statement: `SELECT SUM(CASE WHEN "amount" < $1 THEN 1 ELSE 0 END) AS "tesoql_range_0", ` +
+				`SUM(CASE WHEN "amount" >= $2 AND "amount" < $3 THEN 1 ELSE 0 END) AS "tesoql_range_1", ` +
+				`SUM(CASE WHEN "amount" >= $4 THEN 1 ELSE 0 END) AS "tesoql_range_2" FROM "orders" WHERE 1=1 AND "amount" > $5 AND "status" IN ($6)`,
+			args: []interface{}{10, 10, 100, 100, 5, "shipped"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := getSqlFacet(fm, jm, &jm.Facets[tt.facet], tt.dialect)
+			if statement := query.statement("orders", false); statement != tt.statement {
+				t.Errorf("statement = %s\nwant        %s", statement, tt.statement)
+			}
+			if !reflect.DeepEqual(query.Args, tt.args) {
+				t.Errorf("args = %v, want %v", query.Args, tt.args)
+			}
+		})
+	}
+}
+
+func TestMongoFacetPipelines(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields: map[string]string{"status": "status", "amount": "amount"},
+		FacetFields:     map[string]string{"status": "status", "amount": "amount"},
+	}
+	jm := &JsonMap{
+		Conditions: map[string]ConditionOperators{"status": {ValuesToExactMatch: []interface{}{"shipped"}}, "amount": {GreaterThan: 5}},
+		Facets: []Facet{
+			{Field: "status", Limit: 3},
+			{Field: "status", Name: "allStatuses", Disjunctive: true},
+			{Field: "amount", Ranges: []FacetRange{{To: 10}, {From: 10, To: 100, Key: "mid"}, {From: 100}}},
+		},
+	}
+	pipelines := getMongoFacetPipelines(fm, jm)
+	if len(pipelines) != 2 {
+		t.Fatalf("pipelines = %d, want the shared one and the disjunctive one", len(pipelines))
+	}
+
+	shared := `{"v":[{"$match":{"$and":[{"$and":[{"amount":{"$gt":5}},{"status":{"$in":["shipped"]}}]}]}},{"$facet":{` +
+		`"status":[{"$group":{"_id":"$status","tesoql_count":{"$sum":1}}},{"$sort":{"tesoql_count":-1,"_id":1}},{"$limit":3}],` +
+		`"amount":[{"$group":{"_id":null,` +
+		`"tesoql_range_0":{"$sum":{"$cond":[{"$and":[{"$gt":["$amount",null]},{"$lt":["$amount",10]}]},1,0]}},` +
+		`"tesoql_range_1":{"$sum":{"$cond":[{"$and":[{"$gt":["$amount",null]},{"$gte":["$amount",10]},{"$lt":["$amount",100]}]},1,0]}},` +
+		`"tesoql_range_2":{"$sum":{"$cond":[{"$and":[{"$gt":["$amount",null]},{"$gte":["$amount",100]}]},1,0]}}}}]}}]}`
+	if pipeline := mongoJSON(t, pipelines[0].pipeline); pipeline != shared || pipelines[0].facet != nil {
+		t.Errorf("shared pipeline = %s\nwant              %s", pipeline, shared)
+	}
+
+	disjunctive := `{"v":[{"$match":{"$and":[{"$and":[{"amount":{"$gt":5}}]}]}},` +
+		`{"$group":{"_id":"$status","tesoql_count":{"$sum":1}}},{"$sort":{"tesoql_count":-1,"_id":1}},{"$limit":10}]}`
+	if pipeline := mongoJSON(t, pipelines[1].pipeline); pipeline != disjunctive || pipelines[1].facet != &jm.Facets[1] {
+		t.Errorf("disjunctive pipeline = %s\nwant                   %s", pipeline, disjunctive)
+	}
+}
+
+func TestFacetBuckets(t *testing.T) {
+	values := &Facet{Field: "status", Limit: 3}
+	ranges := &Facet{Field: "amount", Ranges: []FacetRange{{To: 10}, {From: 10, To: 100, Key: "mid"}, {From: 100}}}
+
+	rows := []map[string]interface{}{{FACET_VALUE_COLUMN: []byte("shipped"), FACET_COUNT_COLUMN: int64(4)}, {FACET_VALUE_COLUMN: nil, FACET_COUNT_COLUMN: int64(1)}}
+	if buckets, want := sqlFacetBuckets(values, rows), []FacetBucket{{Value: "shipped", Count: 4}, {Value: nil, Count: 1}}; !reflect.DeepEqual(buckets, want) {
+		t.Errorf("sql value buckets = %v, want %v", buckets, want)
+	}
+	rows = []map[string]interface{}{{"tesoql_range_0": int64(2), "tesoql_range_1": nil, "tesoql_range_2": int64(5)}}
+	wantRanges := []FacetBucket{{Value: "*-10", Count: 2}, {Value: "mid", Count: 0}, {Value: "100-*", Count: 5}}
+	if buckets := sqlFacetBuckets(ranges, rows); !reflect.DeepEqual(buckets, wantRanges) {
+		t.Errorf("sql range buckets = %v, want %v", buckets, wantRanges)
+	}
+
+	documents := []bson.M{{"_id": "shipped", FACET_COUNT_COLUMN: int32(4)}}
+	if buckets, want := mongoFacetBuckets(values, documents), []FacetBucket{{Value: "shipped", Count: 4}}; !reflect.DeepEqual(buckets, want) {
+		t.Errorf("mongo value buckets = %v, want %v", buckets, want)
+	}
+	documents = []bson.M{{"_id": nil, "tesoql_range_0": int32(2), "tesoql_range_1": int32(0), "tesoql_range_2": int32(5)}}
+	if buckets := mongoFacetBuckets(ranges, documents); !reflect.DeepEqual(buckets, wantRanges) {
+		t.Errorf("mongo range buckets = %v, want %v", buckets, wantRanges)
+	}
+	if buckets := mongoFacetBuckets(values, nil); buckets == nil || len(buckets) != 0 {
+		t.Errorf("buckets of no documents = %#v, want an empty slice", buckets)
+	}
+}
+
+func TestValidateFacets(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields: map[string]string{"status": "status", "amount": "amount"},
+		FacetFields:     map[string]string{"status": "status", "amount": "amount"},
+	}
+	tests := []struct {
+		name   string
+		facets []Facet
+		field  string
+	}{
+		{name: "valid", facets: []Facet{{Field: "status", Limit: 3}, {Field: "status", Name: "allStatuses", Disjunctive: true}, {Field: "amount", Ranges: []FacetRange{{To: 10}, {From: 100}}}}},
+		{name: "not a facet field", facets: []Facet{{Field: "name"}}, field: "facets.0"},
+		{name: "name", facets: []Facet{{Field: "status", Name: "a.b"}}, field: "facets.0"},
+		{name: "repeated name", facets: []Facet{{Field: "status"}, {Field: "amount", Name: "status"}}, field: "facets.1"},
+		{name: "negative limit", facets: []Facet{{Field: "status", Limit: -1}}, field: "facets.0.limit"},
+		{name: "unbounded range", facets: []Facet{{Field: "amount", Ranges: []FacetRange{{}}}}, field: "facets.0.ranges.0"},
+		{name: "repeated key", facets: []Facet{{Field: "amount", Ranges: []FacetRange{{From: 1}, {From: 1, Key: "1-*"}}}}, field: "facets.0.ranges.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&JsonMap{Facets: tt.facets}).validateFacets(fm)
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("validateFacets() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.ErrorCode != FACET_ERR_CODE || err.Field != tt.field {
+				t.Errorf("validateFacets() = %v, want FACET_ERR_CODE on %s", err, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package tesoql
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldedRunes are the ranges of letters matched with their case and accent variants by
+// the Mongo regexes of locale-aware search: Latin, Greek and Cyrillic.
+var foldedRunes = [][2]rune{{'A', 'Z'}, {'a', 'z'}, {0x00C0, 0x024F}, {0x0370, 0x04FF}, {0x1E00, 0x1EFF}}
+
+var foldVariantsCache sync.Map // locale and accent sensitivity -> map[rune]string
+
+// FoldSearchText folds a text the way search values are folded when FieldsMap.SearchLocale
+// is set: it is lowercased with the case rules of the locale, so that "İSTANBUL" becomes
+// "istanbul" in Turkish, and its accents are removed unless FieldsMap.AccentSensitiveSearch
+// is set, the dotless ı being folded to i. The columns of FieldsMap.FoldedSearchFields are
+// expected to hold the values of their fields folded by this function.
+//
+// Example usage:
+//
+//	fieldsMap := &tesoql.FieldsMap{SearchLocale: "tr"}
+//	folded := fieldsMap.FoldSearchText("Çağrı Café") // "cagri cafe"
+//
+// Returns:
+//
+// - string: The folded text.
+func (fm *FieldsMap) FoldSearchText(text string) string {
+	tag, err := language.Parse(fm.SearchLocale)
+	if err != nil {
+		tag = language.Und
+	}
+	text = cases.Lower(tag).String(text)
+	if fm.AccentSensitiveSearch {
+		return text
+	}
+	text, _, _ = transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), text)
+	return strings.ReplaceAll(text, "ı", "i")
+}
+
+// foldsSearch reports whether the values of a search field are folded with the search
+// locale. Case-sensitive fields and the regex and full-text modes are not folded.
+func (fm *FieldsMap) foldsSearch(key string) bool {
+	if fm.SearchLocale == "" || fm.CaseSensitiveSearch[key] {
+		return false
+	}
+	switch searchMode(fm, key) {
+	case SEARCH_MODE_REGEX, SEARCH_MODE_FULL_TEXT:
+		return false
+	}
+	return true
+}
+
+// validateSearchLocale checks that the search locale can be parsed, that folded columns are
+// declared for search fields along with a search locale, and that the dialect of SQL engines
+// supports accent-insensitive search of the fields without a folded column.
+func (cfg *Config) validateSearchLocale() *ErrorResponseDTO {
+	fm := cfg.FieldsMap
+	if fm == nil {
+		return nil
+	}
+	if fm.SearchLocale != "" {
+		if _, err := language.Parse(fm.SearchLocale); err != nil {
+			return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Unknown search locale '%s'.", fm.SearchLocale), CONFIG_SEARCH_LOCALE_ERR_CODE).withField("FieldsMap.SearchLocale").withCause(err)
+		}
+	}
+	for _, key := range sortedKeys(fm.FoldedSearchFields) {
+		path := "FieldsMap.FoldedSearchFields." + key
+		if _, exists := fm.SearchFields[key]; !exists {
+			return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Folded column of '%s' is set, but it is not a search field.", key), CONFIG_SEARCH_LOCALE_ERR_CODE).withField(path)
+		}
+		if fm.SearchLocale == "" {
+			return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Folded column of '%s' is set, but SearchLocale is not.", key), CONFIG_SEARCH_LOCALE_ERR_CODE).withField(path)
+		}
+	}
+	if cfg.Engine == MONGO_ENGINE {
+		return nil
+	}
+	d := cfg.sqlDialect()
+	if d.AccentFoldStyle != "" {
+		return nil
+	}
+	for _, key := range sortedKeys(fm.SearchFields) {
+		if _, exists := fm.FoldedSearchFields[key]; !exists && fm.foldsSearch(key) {
+			return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Accent-insensitive search is not supported by the '%s' dialect, declare the folded column of '%s'.", d.Name, key), CONFIG_SEARCH_LOCALE_ERR_CODE).withField("FieldsMap.FoldedSearchFields." + key)
+		}
+	}
+	return nil
+}
+
+// accentInsensitive returns a copy of the dialect whose case-insensitive comparisons
+// fold accents as well.
+func (d *Dialect) accentInsensitive() *Dialect {
+	folding := *d
+	folding.CaseFoldStyle = d.AccentFoldStyle
+	folding.CaseInsensitiveCollation = d.AccentInsensitiveCollation
+	return &folding
+}
+
+// getMongoCollation returns the collation of the Mongo queries with a search locale, of
+// strength 1 comparing base letters only, or 2 comparing accents as well. Queries with a
+// full-text search have none, text indexes only support the simple collation.
+func getMongoCollation(fm *FieldsMap, jm *JsonMap) *options.Collation {
+	if fm == nil || fm.SearchLocale == "" || jm.hasFullTextSearch(fm) {
+		return nil
+	}
+	strength := 1
+	if fm.AccentSensitiveSearch {
+		strength = 2
+	}
+	return &options.Collation{Locale: fm.SearchLocale, Strength: strength}
+}
+
+// foldingRegex quotes a folded text in a regex matching its letters with their case and
+// accent variants, since $regex ignores collations.
+func (fm *FieldsMap) foldingRegex(text string) string {
+	variants := fm.foldVariants()
+	var b strings.Builder
+	for _, r := range text {
+		if class, exists := variants[r]; exists {
+			b.WriteString(class)
+		} else {
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// foldVariants returns the character classes of the letters folding to each folded letter,
+// for the search locale and accent sensitivity of the FieldsMap.
+func (fm *FieldsMap) foldVariants() map[rune]string {
+	cacheKey := fmt.Sprintf("%s/%t", fm.SearchLocale, fm.AccentSensitiveSearch)
+	if cached, ok := foldVariantsCache.Load(cacheKey); ok {
+		return cached.(map[rune]string)
+	}
+	letters := make(map[rune][]rune)
+	for _, bounds := range foldedRunes {
+		for r := bounds[0]; r <= bounds[1]; r++ {
+			folded := fm.FoldSearchText(string(r))
+			if utf8.RuneCountInString(folded) != 1 {
+				continue
+			}
+			f, _ := utf8.DecodeRuneInString(folded)
+			letters[f] = append(letters[f], r)
+		}
+	}
+	variants := make(map[rune]string)
+	for f, rs := range letters {
+		if len(rs) < 2 {
+			continue
+		}
+		sort.Slice(rs, func(i, j int) bool { return rs[i] < rs[j] })
+		variants[f] = "[" + string(rs) + "]"
+	}
+	foldVariantsCache.Store(cacheKey, variants)
+	return variants
+}
@@ -0,0 +1,223 @@
+package tesoql
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestFoldSearchText(t *testing.T) {
+	tests := []struct {
+		name     string
+		fm       *FieldsMap
+		text     string
+		expected string
+	}{
+		{name: "turkish", fm: &FieldsMap{SearchLocale: "tr"}, text: "Çağrı İSTANBUL Café", expected: "cagri istanbul cafe"},
+		{name: "turkish dotless i", fm: &FieldsMap{SearchLocale: "tr"}, text: "IZMIR", expected: "izmir"},
+		{name: "accent-sensitive", fm: &FieldsMap{SearchLocale: "tr", AccentSensitiveSearch: true}, text: "Çağrı İSTANBUL", expected: "çağrı istanbul"},
+		{name: "english", fm: &FieldsMap{SearchLocale: "en"}, text: "ISTANBUL", expected: "istanbul"},
+		{name: "no locale", fm: &FieldsMap{}, text: "İSTANBUL Café", expected: "istanbul cafe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if folded := tt.fm.FoldSearchText(tt.text); folded != tt.expected {
+				t.Errorf("FoldSearchText(%q) = %q, want %q", tt.text, folded, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFoldingRegex(t *testing.T) {
+	accentSensitive := &FieldsMap{SearchLocale: "tr", AccentSensitiveSearch: true}
+	if pattern, want := accentSensitive.foldingRegex("ic.a"), `[iİ][Cc]\.[Aa]`; pattern != want {
+		t.Errorf("accent-sensitive foldingRegex() = %s, want %s", pattern, want)
+	}
+
+	pattern := regexp.MustCompile("^" + (&FieldsMap{SearchLocale: "tr"}).foldingRegex("istanbul cafe") + "$")
+	for _, text := range []string{"İSTANBUL CAFÉ", "istanbul café", "Istanbul Cafe", "ıstanbul cafe"} {
+		if !pattern.MatchString(text) {
+			t.Errorf("foldingRegex() does not match %q", text)
+		}
+	}
+	for _, text := range []string{"istanbul-cafe", "izmir cafe"} {
+		if pattern.MatchString(text) {
+			t.Errorf("foldingRegex() matches %q", text)
+		}
+	}
+}
+
+func TestSqlFoldedSearch(t *testing.T) {
+	fm := &FieldsMap{
+		SearchLocale:       "tr",
+		SearchFields:       map[string]string{"name": "name", "city": "city", "code": "code"},
+		SearchModes:        map[string]string{"city": SEARCH_MODE_EXACT, "code": SEARCH_MODE_PREFIX},
+		FoldedSearchFields: map[string]string{"city": "city_folded"},
+	}
+	mysql80 := *MySqlDialect
+	mysql80.AccentFoldStyle = CASE_FOLD_COLLATE
+	mysql80.AccentInsensitiveCollation = "utf8mb4_0900_ai_ci"
+	tests := []struct {
+		name      string
+		dialect   *Dialect
+		statement string
+	}{
+		{
+			name:    "unaccent",
+			dialect: PostgresDialect,
+			statement: `SELECT * FROM "t" WHERE 1=1 AND ("city_folded" = $1) AND (unaccent("code") ILIKE unaccent($2) ESCAPE '!') AND (unaccent("name") ILIKE unaccent($3) ESCAPE '!') ` +
+				`AND ("city_folded" IS NULL OR NOT ("city_folded" = $4))`,
+		},
+		{
+			name:    "mysql collation",
+			dialect: &mysql80,
+			statement: "SELECT * FROM `t` WHERE 1=1 AND (`city_folded` COLLATE utf8mb4_bin = ?) AND (`code` COLLATE utf8mb4_0900_ai_ci LIKE ? ESCAPE '!') " +
+				"AND (`name` COLLATE utf8mb4_0900_ai_ci LIKE ? ESCAPE '!') AND (`city_folded` IS NULL OR NOT (`city_folded` COLLATE utf8mb4_bin = ?))",
+		},
+		{
+			name:    "sql server collation",
+			dialect: SqlServerDialect,
+			statement: `SELECT * FROM [t] WHERE 1=1 AND ([city_folded] COLLATE Latin1_General_CS_AS = @p1) AND ([code] COLLATE Latin1_General_CI_AI LIKE @p2 ESCAPE '!') ` +
+				`AND ([name] COLLATE Latin1_General_CI_AI LIKE @p3 ESCAPE '!') AND ([city_folded] IS NULL OR NOT ([city_folded] COLLATE Latin1_General_CS_AS = @p4))`,
+		},
+	}
+	jm := &JsonMap{Search: map[string][]interface{}{"name": {"Café"}, "city": {"İSTANBUL"}, "code": {"Iı"}}, SearchExclude: map[string][]interface{}{"city": {"IZMIR"}}}
+	args := []interface{}{"istanbul", "ii%", "%cafe%", "izmir"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := jm.NewSqlQueryWithDialect(fm, tt.dialect)
+			if statement := query.statement("t", false); statement != tt.statement {
+				t.Errorf("statement = %s\nwant        %s", statement, tt.statement)
+			}
+			if !reflect.DeepEqual(query.Args, args) {
+				t.Errorf("args = %v, want %v", query.Args, args)
+			}
+		})
+	}
+}
+
+func TestMongoFoldedSearch(t *testing.T) {
+	fm := &FieldsMap{
+		SearchLocale:       "tr",
+		SearchFields:       map[string]string{"name": "name", "city": "city"},
+		SearchModes:        map[string]string{"city": SEARCH_MODE_EXACT},
+		FoldedSearchFields: map[string]string{"city": "city_folded"},
+	}
+	jm := &JsonMap{Search: map[string][]interface{}{"city": {"İSTANBUL"}}, SearchExclude: map[string][]interface{}{"city": {"IZMIR"}}}
+	filter := `{"v":{"$and":[{"$or":[{"city_folded":"istanbul"}]},{"$nor":[{"city_folded":"izmir"}]}]}}`
+	if got := mongoJSON(t, jm.NewMongoQuery(fm).Filter); got != filter {
+		t.Errorf("filter = %s\nwant     %s", got, filter)
+	}
+
+	fm.AccentSensitiveSearch = true
+	jm = &JsonMap{Search: map[string][]interface{}{"name": {"Ica"}}}
+	filter = `{"v":{"$and":[{"$or":[{"name":{"$regularExpression":{"pattern":"[Iı][Cc][Aa]","options":""}}}]}]}}`
+	if got := mongoJSON(t, jm.NewMongoQuery(fm).Filter); got != filter {
+		t.Errorf("accent-sensitive filter = %s\nwant                     %s", got, filter)
+	}
+}
+
+func TestGetMongoCollation(t *testing.T) {
+	search := &JsonMap{Search: map[string][]interface{}{"name": {"cafe"}}}
+	tests := []struct {
+		name      string
+		fm        *FieldsMap
+		jsonMap   *JsonMap
+		collation *options.Collation
+	}{
+		{name: "base letters", fm: &FieldsMap{SearchLocale: "tr"}, jsonMap: search, collation: &options.Collation{Locale: "tr", Strength: 1}},
+		{name: "accents", fm: &FieldsMap{SearchLocale: "tr", AccentSensitiveSearch: true}, jsonMap: search, collation: &options.Collation{Locale: "tr", Strength: 2}},
+		{name: "no locale", fm: &FieldsMap{}, jsonMap: search},
+		{name: "nil fields map", jsonMap: search},
+		{
+			name:    "full-text search",
+			fm:      &FieldsMap{SearchLocale: "tr", SearchModes: map[string]string{"name": SEARCH_MODE_FULL_TEXT}},
+			jsonMap: search,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if collation := getMongoCollation(tt.fm, tt.jsonMap); !reflect.DeepEqual(collation, tt.collation) {
+				t.Errorf("getMongoCollation() = %+v, want %+v", collation, tt.collation)
+			}
+		})
+	}
+}
+
+func TestValidateSearchLocale(t *testing.T) {
+	mysql80 := *MySqlDialect
+	mysql80.AccentFoldStyle = CASE_FOLD_COLLATE
+	mysql80.AccentInsensitiveCollation = "utf8mb4_0900_ai_ci"
+	tests := []struct {
+		name    string
+		engine  string
+		dialect *Dialect
+		fm      *FieldsMap
+		field   string
+	}{
+		{
+			name:   "valid",
+			engine: POSTGRES_ENGINE,
+			fm:     &FieldsMap{SearchLocale: "tr", SearchFields: map[string]string{"name": "name", "city": "city"}, FoldedSearchFields: map[string]string{"city": "city_folded"}},
+		},
+		{name: "mongo", engine: MONGO_ENGINE, fm: &FieldsMap{SearchLocale: "tr", SearchFields: map[string]string{"name": "name"}}},
+		{
+			name:   "folded columns",
+			engine: SQLITE_ENGINE,
+			fm:     &FieldsMap{SearchLocale: "tr", SearchFields: map[string]string{"name": "name"}, FoldedSearchFields: map[string]string{"name": "name_folded"}},
+		},
+		{
+			name:   "case-sensitive field",
+			engine: SQLITE_ENGINE,
+			fm:     &FieldsMap{SearchLocale: "tr", SearchFields: map[string]string{"name": "name"}, CaseSensitiveSearch: map[string]bool{"name": true}},
+		},
+		{name: "unknown locale", engine: POSTGRES_ENGINE, fm: &FieldsMap{SearchLocale: "!!"}, field: "FieldsMap.SearchLocale"},
+		{
+			name:   "folded column of other field",
+			engine: POSTGRES_ENGINE,
+			fm:     &FieldsMap{SearchLocale: "tr", FoldedSearchFields: map[string]string{"name": "name_folded"}},
+			field:  "FieldsMap.FoldedSearchFields.name",
+		},
+		{
+			name:   "folded column without locale",
+			engine: POSTGRES_ENGINE,
+			fm:     &FieldsMap{SearchFields: map[string]string{"name": "name"}, FoldedSearchFields: map[string]string{"name": "name_folded"}},
+			field:  "FieldsMap.FoldedSearchFields.name",
+		},
+		{
+			name:   "no accent folding",
+			engine: SQLITE_ENGINE,
+			fm:     &FieldsMap{SearchLocale: "tr", SearchFields: map[string]string{"name": "name"}},
+			field:  "FieldsMap.FoldedSearchFields.name",
+		},
+		{
+			name:   "mysql",
+			engine: MYSQL_ENGINE,
+			fm:     &FieldsMap{SearchLocale: "tr", SearchFields: map[string]string{"name": "name"}},
+			field:  "FieldsMap.FoldedSearchFields.name",
+		},
+		{
+			name:    "mysql collation opt-in",
+			engine:  MYSQL_ENGINE,
+			dialect: &mysql80,
+			fm:      &FieldsMap{SearchLocale: "tr", SearchFields: map[string]string{"name": "name"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Config{Engine: tt.engine, Dialect: tt.dialect, FieldsMap: tt.fm}).validateSearchLocale()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("validateSearchLocale() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !errors.Is(err, ErrConfigSearchLocale) || err.Field != tt.field {
+				t.Errorf("validateSearchLocale() = %v, want ErrConfigSearchLocale on %s", err, tt.field)
+			}
+		})
+	}
+}
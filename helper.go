@@ -1,6 +1,9 @@
 package tesoql
 
 import (
+	"context"
+	"sort"
+	"strconv"
 	"time"
 )
 
@@ -37,3 +40,48 @@ func newResponse(errType string, msg string, errCode int) *ErrorResponseDTO {
 		ErrorCode: errCode,
 	}
 }
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toInt converts a numeric value scanned from a driver to int, returning 0 for
+// values that are not numeric.
+func toInt(val interface{}) int {
+	switch v := val.(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case uint64:
+		return int(v)
+	case float64:
+		return int(v)
+	case []byte:
+		n, _ := strconv.Atoi(string(v))
+		return n
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// withDefaultTimeout bounds ctx with the default timeout (DEFAULT_QUERY_TIMEOUT when zero,
+// none when negative), unless it already has a deadline.
+func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, hasDeadline := ctx.Deadline(); hasDeadline || timeout < 0 {
+		return ctx, func() {}
+	}
+	if timeout == 0 {
+		timeout = DEFAULT_QUERY_TIMEOUT
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,570 @@
+package tesoql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// HistogramRepository is a Repository that can compute the date histogram of a JsonMap.
+// The built-in Mongo and SQL repositories implement it. The buckets are returned in
+// ascending order, empty buckets are filled in by the Service.
+type HistogramRepository interface {
+	Repository
+	DateHistogram(ctx context.Context, jsonMap *JsonMap) ([]HistogramBucket, *ErrorResponseDTO)
+}
+
+// DateHistogram computes the date histogram of the JsonMap over the records matching its
+// filters, ignoring sorting and pagination. The JsonMap goes through the same validations
+// as GetContext. Service.Query returns the histogram along with the page of records.
+//
+// Example usage:
+//
+//	jsonMapVariable.DateHistogram = &tesoql.DateHistogram{Field: "created", Interval: tesoql.INTERVAL_DAY, FillEmpty: true}
+//	buckets, err := tesoQL.Service.DateHistogram(r.Context(), &jsonMapVariable)
+//	if err != nil {
+//		// Handle error
+//	}
+//	for _, bucket := range buckets {
+//		fmt.Println(bucket.Start.Format("2006-01-02"), bucket.Count)
+//	}
+//
+// Returns:
+//
+// - []HistogramBucket: The buckets in ascending order.
+//
+// - *ErrorResponseDTO: An error response, if any occurred during validation or while running the query.
+func (s *Service) DateHistogram(ctx context.Context, jsonMap *JsonMap) ([]HistogramBucket, *ErrorResponseDTO) {
+	validationErr := s.validate(jsonMap)
+	if validationErr != nil {
+		return nil, validationErr
+	}
+	ctx, cancel := withDefaultTimeout(ctx, s.defaultTimeout)
+	defer cancel()
+	return s.dateHistogram(ctx, jsonMap)
+}
+
+// dateHistogram computes the date histogram of a validated JsonMap, nil when none is requested.
+func (s *Service) dateHistogram(ctx context.Context, jsonMap *JsonMap) ([]HistogramBucket, *ErrorResponseDTO) {
+	if jsonMap.DateHistogram == nil {
+		return nil, nil
+	}
+	repo, ok := s.repo.(HistogramRepository)
+	if !ok {
+		return nil, newResponse(TESOQL_CONFIG_ERROR, "The repository does not support date histograms.", CONFIG_HISTOGRAM_ERR_CODE).withField("dateHistogram")
+	}
+	buckets, err := repo.DateHistogram(ctx, jsonMap)
+	if err != nil {
+		return nil, err
+	}
+	if len(buckets) > MAX_HISTOGRAM_BUCKETS {
+		return nil, tooManyBuckets()
+	}
+	if jsonMap.DateHistogram.FillEmpty {
+		return jsonMap.fillHistogram(s.fieldsMap, buckets)
+	}
+	return buckets, nil
+}
+
+func tooManyBuckets() *ErrorResponseDTO {
+	return newResponse(TESOQL_VALIDATION_ERROR,
+		fmt.Sprintf("Date histogram has more than %d buckets, narrow the time range or widen the interval.", MAX_HISTOGRAM_BUCKETS),
+		HISTOGRAM_ERR_CODE).withField("dateHistogram.interval")
+}
+
+var (
+	timeZoneOffsetPattern = regexp.MustCompile(`^[+-]\d{2}:\d{2}$`)
+	timeZoneNamePattern   = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+\-/]*$`)
+)
+
+// location returns the location the intervals start in, and whether it is a fixed
+// offset from UTC. Names are checked against the time zone database of the system, and
+// only made of letters, digits and "_+-/" so that they can be written into statements.
+func (h *DateHistogram) location() (*time.Location, bool, error) {
+	switch {
+	case h.TimeZone == "" || h.TimeZone == "UTC":
+		return time.UTC, true, nil
+	case timeZoneOffsetPattern.MatchString(h.TimeZone):
+		var hours, minutes int
+		fmt.Sscanf(h.TimeZone[1:], "%d:%d", &hours, &minutes)
+		if hours <= 14 && minutes < 60 {
+			offset := hours*3600 + minutes*60
+			if h.TimeZone[0] == '-' {
+				offset = -offset
+			}
+			return time.FixedZone(h.TimeZone, offset), true, nil
+		}
+	case h.TimeZone != "Local" && timeZoneNamePattern.MatchString(h.TimeZone):
+		if loc, err := time.LoadLocation(h.TimeZone); err == nil {
+			return loc, false, nil
+		}
+	}
+	return nil, false, fmt.Errorf("time zone '%s' is not valid", h.TimeZone)
+}
+
+// offsetMinutes returns the offset from UTC of a fixed location.
+func offsetMinutes(loc *time.Location) int {
+	_, offset := time.Unix(0, 0).In(loc).Zone()
+	return offset / 60
+}
+
+// validateDateHistogram checks that the date histogram of the JsonMap is on a date field,
+// that its interval and time zone are valid, and that its metrics are on aggregation fields.
+//
+// Returns:
+//
+// - *ErrorResponseDTO: An error response if validation fails, or nil if validation passes.
+func (jm *JsonMap) validateDateHistogram(fm *FieldsMap) *ErrorResponseDTO {
+	h := jm.DateHistogram
+	if h == nil {
+		return nil
+	}
+	var dateFields, aggregationFields map[string]string
+	if fm != nil {
+		dateFields, aggregationFields = fm.DateTimeFieldKeys, fm.AggregationFields
+	}
+	if _, exists := dateFields[h.Field]; !exists {
+		return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Field : '%v' is not a date field.", h.Field), HISTOGRAM_ERR_CODE).withField("dateHistogram.field")
+	}
+	switch h.Interval {
+	case INTERVAL_MINUTE, INTERVAL_HOUR, INTERVAL_DAY, INTERVAL_WEEK, INTERVAL_MONTH, INTERVAL_QUARTER, INTERVAL_YEAR:
+	default:
+		return newResponse(TESOQL_VALIDATION_ERROR,
+			fmt.Sprintf("Interval '%v' is not supported, it must be one of minute, hour, day, week, month, quarter or year.", h.Interval),
+			HISTOGRAM_ERR_CODE).withField("dateHistogram.interval")
+	}
+	if _, _, err := h.location(); err != nil {
+		return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Time zone '%v' is not a time zone name or a '+hh:mm' offset.", h.TimeZone), HISTOGRAM_ERR_CODE).withField("dateHistogram.timeZone")
+	}
+	names := make(map[string]bool)
+	for i, metric := range h.Metrics {
+		path := fmt.Sprintf("dateHistogram.metrics.%d", i)
+		if err := validateMetric(aggregationFields, metric, HISTOGRAM_ERR_CODE); err != nil {
+			return err.withField(path)
+		}
+		if names[metric.name()] {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Name '%v' is used more than once in the date histogram.", metric.name()), HISTOGRAM_ERR_CODE).withField(path)
+		}
+		names[metric.name()] = true
+	}
+	return nil
+}
+
+// truncateTime returns the start of the interval t is in, in the location of t.
+func truncateTime(t time.Time, interval string) time.Time {
+	y, m, d := t.Date()
+	loc := t.Location()
+	switch interval {
+	case INTERVAL_MINUTE:
+		return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc)
+	case INTERVAL_HOUR:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case INTERVAL_WEEK:
+		return time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	case INTERVAL_MONTH:
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case INTERVAL_QUARTER:
+		return time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, loc)
+	case INTERVAL_YEAR:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// nextInterval returns the start of the interval following the one starting at t.
+func nextInterval(t time.Time, interval string) time.Time {
+	y, m, d := t.Date()
+	var next time.Time
+	switch interval {
+	case INTERVAL_MINUTE:
+		next = time.Date(y, m, d, t.Hour(), t.Minute()+1, 0, 0, t.Location())
+	case INTERVAL_HOUR:
+		next = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, t.Location())
+	case INTERVAL_WEEK:
+		next = t.AddDate(0, 0, 7)
+	case INTERVAL_MONTH:
+		next = t.AddDate(0, 1, 0)
+	case INTERVAL_QUARTER:
+		next = t.AddDate(0, 3, 0)
+	case INTERVAL_YEAR:
+		next = t.AddDate(1, 0, 0)
+	default:
+		next = t.AddDate(0, 0, 1)
+	}
+	if !next.After(t) {
+		// the wall clock went back at the end of daylight saving time
+		next = t.Add(time.Hour)
+	}
+	return next
+}
+
+// fillHistogram inserts the empty buckets between the bounds of the condition on the date
+// field, or between the first and last bucket for a missing bound.
+func (jm *JsonMap) fillHistogram(fm *FieldsMap, buckets []HistogramBucket) ([]HistogramBucket, *ErrorResponseDTO) {
+	h := jm.DateHistogram
+	loc, _, _ := h.location()
+
+	var start, end time.Time
+	column := fm.DateTimeFieldKeys[h.Field]
+	for _, key := range sortedKeys(jm.Conditions) {
+		if fm.ConditionFields[key] != column {
+			continue
+		}
+		condition := jm.Conditions[key]
+		if t, ok := conditionTime(condition.GreaterOrEqual); ok {
+			start = truncateTime(t.In(loc), h.Interval)
+		} else if t, ok := conditionTime(condition.GreaterThan); ok {
+			start = truncateTime(t.In(loc), h.Interval)
+		}
+		if t, ok := conditionTime(condition.LowerThan); ok {
+			end = t
+		} else if t, ok := conditionTime(condition.LowerOrEqual); ok {
+			end = t.Add(time.Nanosecond)
+		}
+		break
+	}
+	if len(buckets) > 0 {
+		if start.IsZero() {
+			start = buckets[0].Start
+		}
+		if end.IsZero() {
+			end = nextInterval(buckets[len(buckets)-1].Start, h.Interval)
+		}
+	}
+	if start.IsZero() || end.IsZero() {
+		return buckets, nil
+	}
+
+	counted := make(map[int64]HistogramBucket, len(buckets))
+	for _, bucket := range buckets {
+		counted[bucket.Start.UnixNano()] = bucket
+	}
+	var filled []HistogramBucket
+	for t := start; t.Before(end); t = nextInterval(t, h.Interval) {
+		if len(filled) == MAX_HISTOGRAM_BUCKETS {
+			return nil, tooManyBuckets()
+		}
+		bucket, exists := counted[t.UnixNano()]
+		if !exists {
+			bucket = HistogramBucket{Start: t}
+		}
+		delete(counted, t.UnixNano())
+		filled = append(filled, bucket)
+	}
+	if len(counted) > 0 {
+		// buckets outside the bounds, which the filters of the query let through
+		for _, bucket := range counted {
+			filled = append(filled, bucket)
+		}
+		sort.Slice(filled, func(i, j int) bool { return filled[i].Start.Before(filled[j].Start) })
+	}
+	return filled, nil
+}
+
+// conditionTime returns the time of a condition value on a date field.
+func conditionTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// dateTrunc returns the expression truncating the date column to the start of the
+// intervals of the histogram, in its time zone. The dates are expected in UTC, or
+// in a type carrying the time zone.
+func (d *Dialect) dateTrunc(column string, h *DateHistogram) (string, *ErrorResponseDTO) {
+	loc, fixed, _ := h.location()
+	offset := 0
+	if fixed {
+		offset = offsetMinutes(loc)
+	}
+	if !fixed && (d.DateTruncStyle == DATE_TRUNC_SQLITE || d.DateTruncStyle == DATE_TRUNC_SQLSERVER) {
+		return "", newResponse(TESOQL_SQL_ERROR, fmt.Sprintf("The '%s' dialect only supports UTC and '+hh:mm' offsets in date histograms.", d.Name), SQL_DATE_TRUNC_ERR_CODE).withField("dateHistogram.timeZone")
+	}
+
+	switch d.DateTruncStyle {
+	case DATE_TRUNC_POSTGRES:
+		// the epoch of a timestamptz is its instant, the one of a timestamp reads it as UTC:
+		// both columns become the same timestamptz, whatever the session TimeZone
+		instant := fmt.Sprintf("to_timestamp(EXTRACT(EPOCH FROM %s))", column)
+		local := fmt.Sprintf("(%s AT TIME ZONE '%s')", instant, h.TimeZone)
+		if fixed {
+			local = fmt.Sprintf("(%s AT TIME ZONE 'UTC')", instant)
+			if offset != 0 {
+				local = fmt.Sprintf("(%s + INTERVAL '%d minutes')", local, offset)
+			}
+		}
+		return fmt.Sprintf("date_trunc('%s', %s)", h.Interval, local), nil
+
+	case DATE_TRUNC_MYSQL:
+		local := column
+		if !fixed {
+			local = fmt.Sprintf("CONVERT_TZ(%s, '+00:00', '%s')", column, h.TimeZone)
+		} else if offset != 0 {
+			local = fmt.Sprintf("DATE_ADD(%s, INTERVAL %d MINUTE)", column, offset)
+		}
+		switch h.Interval {
+		case INTERVAL_WEEK:
+			return fmt.Sprintf("DATE_FORMAT(DATE_SUB(%[1]s, INTERVAL WEEKDAY(%[1]s) DAY), '%%Y-%%m-%%d')", local), nil
+		case INTERVAL_QUARTER:
+			return fmt.Sprintf("DATE_FORMAT(MAKEDATE(YEAR(%[1]s), 1) + INTERVAL QUARTER(%[1]s) - 1 QUARTER, '%%Y-%%m-%%d')", local), nil
+		}
+		formats := map[string]string{
+			INTERVAL_MINUTE: "%Y-%m-%d %H:%i:00",
+			INTERVAL_HOUR:   "%Y-%m-%d %H:00:00",
+			INTERVAL_DAY:    "%Y-%m-%d",
+			INTERVAL_MONTH:  "%Y-%m-01",
+			INTERVAL_YEAR:   "%Y-01-01",
+		}
+		return fmt.Sprintf("DATE_FORMAT(%s, '%s')", local, formats[h.Interval]), nil
+
+	case DATE_TRUNC_SQLITE:
+		local := column
+		if offset != 0 {
+			local = fmt.Sprintf("%s, '%+d minutes'", column, offset)
+		}
+		switch h.Interval {
+		case INTERVAL_WEEK:
+			return fmt.Sprintf("strftime('%%Y-%%m-%%d', %s, 'weekday 0', '-6 days')", local), nil
+		case INTERVAL_QUARTER:
+			return fmt.Sprintf("printf('%%s-%%02d-01', strftime('%%Y', %[1]s), (CAST(strftime('%%m', %[1]s) AS INTEGER) + 2) / 3 * 3 - 2)", local), nil
+		}
+		formats := map[string]string{
+			INTERVAL_MINUTE: "%Y-%m-%d %H:%M:00",
+			INTERVAL_HOUR:   "%Y-%m-%d %H:00:00",
+			INTERVAL_DAY:    "%Y-%m-%d",
+			INTERVAL_MONTH:  "%Y-%m-01",
+			INTERVAL_YEAR:   "%Y-01-01",
+		}
+		return fmt.Sprintf("strftime('%s', %s)", formats[h.Interval], local), nil
+
+	case DATE_TRUNC_SQLSERVER:
+		local := column
+		if offset != 0 {
+			local = fmt.Sprintf("DATEADD(minute, %d, %s)", offset, column)
+		}
+		if h.Interval == INTERVAL_WEEK {
+			// day 0 (1900-01-01) is a Monday
+			return fmt.Sprintf("DATEADD(day, DATEDIFF(day, 0, %s) / 7 * 7, 0)", local), nil
+		}
+		return fmt.Sprintf("DATEADD(%[1]s, DATEDIFF(%[1]s, 0, %[2]s), 0)", h.Interval, local), nil
+
+	case DATE_TRUNC_ORACLE:
+		local := column
+		if h.TimeZone != "" && h.TimeZone != "UTC" {
+			local = fmt.Sprintf("(FROM_TZ(CAST(%s AS TIMESTAMP), 'UTC') AT TIME ZONE '%s')", column, h.TimeZone)
+		}
+		formats := map[string]string{
+			INTERVAL_MINUTE:  "MI",
+			INTERVAL_HOUR:    "HH",
+			INTERVAL_DAY:     "DD",
+			INTERVAL_WEEK:    "IW",
+			INTERVAL_MONTH:   "MM",
+			INTERVAL_QUARTER: "Q",
+			INTERVAL_YEAR:    "YYYY",
+		}
+		return fmt.Sprintf("TRUNC(%s, '%s')", local, formats[h.Interval]), nil
+	}
+	return "", newResponse(TESOQL_SQL_ERROR, fmt.Sprintf("Date histograms are not supported by the '%s' dialect.", d.Name), SQL_DATE_TRUNC_ERR_CODE).withField("dateHistogram")
+}
+
+// getSqlDateHistogram returns the query of the date histogram: the start of every interval
+// in HISTOGRAM_BUCKET_COLUMN, its count in HISTOGRAM_COUNT_COLUMN and its metrics under
+// their names, in ascending order.
+func getSqlDateHistogram(fm *FieldsMap, jm *JsonMap, d *Dialect) (*SqlQuery, *ErrorResponseDTO) {
+	d = dialectOrGeneric(d)
+	h := jm.DateHistogram
+	bucket, err := d.dateTrunc(d.quoteIdentifier(fm.DateTimeFieldKeys[h.Field]), h)
+	if err != nil {
+		return nil, err
+	}
+	fields := []string{
+		fmt.Sprintf("%s AS %s", bucket, d.quoteIdentifier(HISTOGRAM_BUCKET_COLUMN)),
+		fmt.Sprintf("COUNT(*) AS %s", d.quoteIdentifier(HISTOGRAM_COUNT_COLUMN)),
+	}
+	for _, metric := range h.Metrics {
+		fields = append(fields, fmt.Sprintf("%s AS %s", sqlMetric(fm, metric, d), d.quoteIdentifier(metric.name())))
+	}
+
+	args := newSqlArgs(d)
+	query := &SqlQuery{dialect: d}
+	query.Select = strings.Join(fields, ", ")
+	query.Where = getSqlFilter(fm, jm, args)
+	query.GroupBy = fmt.Sprintf(" GROUP BY %s", bucket)
+	query.OrderBy = fmt.Sprintf(" ORDER BY %s", bucket)
+	// one more bucket than allowed tells that there are too many
+	query.limit = MAX_HISTOGRAM_BUCKETS + 1
+	query.Limit, query.Offset = d.pagingClauses(query.limit, 0)
+	query.Args = d.bindArgs(args.values)
+	return query, nil
+}
+
+// sqlHistogramBuckets reads the buckets of the date histogram from the rows of its query.
+// Rows of records without date are skipped.
+func sqlHistogramBuckets(h *DateHistogram, rows []map[string]interface{}) []HistogramBucket {
+	loc, _, _ := h.location()
+	buckets := []HistogramBucket{}
+	for _, row := range rows {
+		start, ok := wallClockTime(row[HISTOGRAM_BUCKET_COLUMN], loc)
+		if !ok {
+			continue
+		}
+		bucket := HistogramBucket{Start: start, Count: toInt(row[HISTOGRAM_COUNT_COLUMN])}
+		if len(h.Metrics) > 0 {
+			bucket.Metrics = make(map[string]interface{}, len(h.Metrics))
+			for _, metric := range h.Metrics {
+				value := row[metric.name()]
+				if b, ok := value.([]byte); ok {
+					value = string(b)
+				}
+				bucket.Metrics[metric.name()] = value
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+// wallClockTime returns the time whose wall clock in loc is the one of the value, a
+// time or a string read from the database.
+func wallClockTime(value interface{}, loc *time.Location) (time.Time, bool) {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case []byte:
+		return wallClockTime(string(v), loc)
+	case string:
+		parsed := false
+		for _, layout := range timeLayouts {
+			if p, err := time.Parse(layout, v); err == nil {
+				t, parsed = p, true
+				break
+			}
+		}
+		if !parsed {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc), true
+}
+
+// getMongoDateHistogramPipeline returns the aggregation pipeline of the date histogram,
+// grouping the documents by the $dateTrunc of the date field, or by a key built with
+// $dateToString for servers older than 5.0.
+func getMongoDateHistogramPipeline(fm *FieldsMap, jm *JsonMap, dateToString bool) mongo.Pipeline {
+	h := jm.DateHistogram
+	field := "$" + fm.DateTimeFieldKeys[h.Field]
+	timeZone := h.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+
+	var bucket interface{}
+	if dateToString {
+		formatted := func(format string) bson.D {
+			return bson.D{{"$dateToString", bson.D{{"format", format}, {"date", field}, {"timezone", timeZone}}}}
+		}
+		switch h.Interval {
+		case INTERVAL_WEEK:
+			bucket = formatted("%G-W%V")
+		case INTERVAL_QUARTER:
+			month := bson.D{{"$month", bson.D{{"date", field}, {"timezone", timeZone}}}}
+			quarter := bson.D{{"$toString", bson.D{{"$ceil", bson.D{{"$divide", bson.A{month, 3}}}}}}}
+			bucket = bson.D{{"$concat", bson.A{formatted("%Y"), "-Q", quarter}}}
+		default:
+			formats := map[string]string{
+				INTERVAL_MINUTE: "%Y-%m-%d %H:%M:00",
+				INTERVAL_HOUR:   "%Y-%m-%d %H:00:00",
+				INTERVAL_DAY:    "%Y-%m-%d",
+				INTERVAL_MONTH:  "%Y-%m-01",
+				INTERVAL_YEAR:   "%Y-01-01",
+			}
+			bucket = formatted(formats[h.Interval])
+		}
+	} else {
+		trunc := bson.D{{"date", field}, {"unit", h.Interval}, {"timezone", timeZone}}
+		if h.Interval == INTERVAL_WEEK {
+			trunc = append(trunc, bson.E{Key: "startOfWeek", Value: "monday"})
+		}
+		bucket = bson.D{{"$dateTrunc", trunc}}
+	}
+
+	group := bson.D{{"_id", bucket}, {HISTOGRAM_COUNT_COLUMN, bson.D{{"$sum", 1}}}}
+	for _, metric := range h.Metrics {
+		group = append(group, bson.E{Key: metric.name(), Value: mongoAccumulator(fm, metric)})
+	}
+	var pipeline mongo.Pipeline
+	if filter := getMongoFilter(fm, jm); filter != nil {
+		pipeline = append(pipeline, bson.D{{"$match", *filter}})
+	}
+	return append(pipeline,
+		bson.D{{"$group", group}},
+		bson.D{{"$sort", bson.D{{"_id", 1}}}},
+		bson.D{{"$limit", MAX_HISTOGRAM_BUCKETS + 1}},
+	)
+}
+
+// mongoHistogramBuckets reads the buckets of the date histogram from the documents of its
+// pipeline. Documents without date are skipped.
+func mongoHistogramBuckets(h *DateHistogram, documents []bson.M) []HistogramBucket {
+	loc, _, _ := h.location()
+	buckets := []HistogramBucket{}
+	for _, document := range documents {
+		var start time.Time
+		switch id := document["_id"].(type) {
+		case primitive.DateTime:
+			start = id.Time().In(loc)
+		case string:
+			var ok bool
+			if start, ok = histogramKeyStart(id, h.Interval, loc); !ok {
+				continue
+			}
+		default:
+			continue
+		}
+		bucket := HistogramBucket{Start: start, Count: toInt(document[HISTOGRAM_COUNT_COLUMN])}
+		if len(h.Metrics) > 0 {
+			bucket.Metrics = make(map[string]interface{}, len(h.Metrics))
+			for _, metric := range h.Metrics {
+				bucket.Metrics[metric.name()] = document[metric.name()]
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+// histogramKeyStart returns the start of the interval of a key built with $dateToString:
+// an ISO week ("2024-W05"), a quarter ("2024-Q1") or a date.
+func histogramKeyStart(key string, interval string, loc *time.Location) (time.Time, bool) {
+	var year, number int
+	switch interval {
+	case INTERVAL_WEEK:
+		if _, err := fmt.Sscanf(key, "%d-W%d", &year, &number); err != nil {
+			return time.Time{}, false
+		}
+		// the first ISO week holds January 4th
+		jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, loc)
+		return truncateTime(jan4, INTERVAL_WEEK).AddDate(0, 0, (number-1)*7), true
+	case INTERVAL_QUARTER:
+		if _, err := fmt.Sscanf(key, "%d-Q%d", &year, &number); err != nil {
+			return time.Time{}, false
+		}
+		return time.Date(year, time.Month((number-1)*3+1), 1, 0, 0, 0, 0, loc), true
+	}
+	return wallClockTime(key, loc)
+}
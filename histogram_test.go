@@ -0,0 +1,305 @@
+package tesoql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDateTrunc(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialect   *Dialect
+		interval  string
+		timeZone  string
+		trunc     string
+		errorCode int
+	}{
+		{
+			name:     "postgres utc",
+			dialect:  PostgresDialect,
+			interval: INTERVAL_DAY,
+			trunc:    `date_trunc('day', (to_timestamp(EXTRACT(EPOCH FROM "created_at")) AT TIME ZONE 'UTC'))`,
+		},
+		{
+			name:     "postgres offset",
+			dialect:  PostgresDialect,
+			interval: INTERVAL_HOUR,
+			timeZone: "+03:00",
+			trunc:    `date_trunc('hour', ((to_timestamp(EXTRACT(EPOCH FROM "created_at")) AT TIME ZONE 'UTC') + INTERVAL '180 minutes'))`,
+		},
+		{
+			name:     "postgres zone name",
+			dialect:  PostgresDialect,
+			interval: INTERVAL_MONTH,
+			timeZone: "Europe/Istanbul",
+			trunc:    `date_trunc('month', (to_timestamp(EXTRACT(EPOCH FROM "created_at")) AT TIME ZONE 'Europe/Istanbul'))`,
+		},
+		{
+			name:     "mysql zone name",
+			dialect:  MySqlDialect,
+			interval: INTERVAL_DAY,
+			timeZone: "Europe/Istanbul",
+			trunc:    "DATE_FORMAT(CONVERT_TZ(`created_at`, '+00:00', 'Europe/Istanbul'), '%Y-%m-%d')",
+		},
+		{
+			name:     "mysql week",
+			dialect:  MySqlDialect,
+			interval: INTERVAL_WEEK,
+			timeZone: "-05:30",
+			trunc:    "DATE_FORMAT(DATE_SUB(DATE_ADD(`created_at`, INTERVAL -330 MINUTE), INTERVAL WEEKDAY(DATE_ADD(`created_at`, INTERVAL -330 MINUTE)) DAY), '%Y-%m-%d')",
+		},
+		{
+			name:     "sqlite offset",
+			dialect:  SqliteDialect,
+			interval: INTERVAL_HOUR,
+			timeZone: "+03:00",
+			trunc:    `strftime('%Y-%m-%d %H:00:00', "created_at", '+180 minutes')`,
+		},
+		{
+			name:     "sqlite quarter",
+			dialect:  SqliteDialect,
+			interval: INTERVAL_QUARTER,
+			trunc:    `printf('%s-%02d-01', strftime('%Y', "created_at"), (CAST(strftime('%m', "created_at") AS INTEGER) + 2) / 3 * 3 - 2)`,
+		},
+		{
+			name:      "sqlite zone name",
+			dialect:   SqliteDialect,
+			interval:  INTERVAL_DAY,
+			timeZone:  "Europe/Istanbul",
+			errorCode: SQL_DATE_TRUNC_ERR_CODE,
+		},
+		{
+			name:     "sql server week",
+			dialect:  SqlServerDialect,
+			interval: INTERVAL_WEEK,
+			trunc:    "DATEADD(day, DATEDIFF(day, 0, [created_at]) / 7 * 7, 0)",
+		},
+		{
+			name:     "sql server offset",
+			dialect:  SqlServerDialect,
+			interval: INTERVAL_MONTH,
+			timeZone: "+01:00",
+			trunc:    "DATEADD(month, DATEDIFF(month, 0, DATEADD(minute, 60, [created_at])), 0)",
+		},
+		{
+			name:     "oracle zone name",
+			dialect:  OracleDialect,
+			interval: INTERVAL_QUARTER,
+			timeZone: "Europe/Istanbul",
+			trunc:    `TRUNC((FROM_TZ(CAST("created_at" AS TIMESTAMP), 'UTC') AT TIME ZONE 'Europe/Istanbul'), 'Q')`,
+		},
+		{
+			name:      "unsupported dialect",
+			dialect:   GenericDialect,
+			interval:  INTERVAL_DAY,
+			errorCode: SQL_DATE_TRUNC_ERR_CODE,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &DateHistogram{Field: "createdAt", Interval: tt.interval, TimeZone: tt.timeZone}
+			trunc, err := tt.dialect.dateTrunc(tt.dialect.quoteIdentifier("created_at"), h)
+			if tt.errorCode != 0 {
+				if err == nil || err.ErrorCode != tt.errorCode {
+					t.Errorf("dateTrunc() = %s, %v, want error %d", trunc, err, tt.errorCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if trunc != tt.trunc {
+				t.Errorf("dateTrunc() = %s\nwant         %s", trunc, tt.trunc)
+			}
+		})
+	}
+}
+
+func TestSqlDateHistogram(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields:   map[string]string{"createdAt": "created_at", "status": "status"},
+		DateTimeFieldKeys: map[string]string{"createdAt": "created_at"},
+		AggregationFields: map[string]string{"amount": "amount", "status": "status"},
+	}
+	jm := &JsonMap{
+		Conditions:    map[string]ConditionOperators{"status": {ValuesToExactMatch: []interface{}{"shipped"}}},
+		DateHistogram: &DateHistogram{Field: "createdAt", Interval: INTERVAL_DAY, Metrics: []Metric{{Function: METRIC_SUM, Field: "amount"}}},
+	}
+	query, err := getSqlDateHistogram(fm, jm, SqlServerDialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT DATEADD(day, DATEDIFF(day, 0, [created_at]), 0) AS [tesoql_bucket], COUNT(*) AS [tesoql_count], SUM([amount]) AS [sum_amount] " +
+		"FROM [orders] WHERE 1=1 AND [status] IN (@p1) GROUP BY DATEADD(day, DATEDIFF(day, 0, [created_at]), 0) " +
+		"ORDER BY DATEADD(day, DATEDIFF(day, 0, [created_at]), 0) OFFSET 0 ROWS FETCH NEXT 10001 ROWS ONLY"
+	if statement := query.statement("orders", false); statement != want {
+		t.Errorf("statement = %s\nwant        %s", statement, want)
+	}
+	if !reflect.DeepEqual(query.Args, []interface{}{"shipped"}) {
+		t.Errorf("args = %v", query.Args)
+	}
+}
+
+func TestMongoDateHistogramPipeline(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields:   map[string]string{"createdAt": "created_at", "status": "status"},
+		DateTimeFieldKeys: map[string]string{"createdAt": "created_at"},
+		AggregationFields: map[string]string{"amount": "amount", "status": "status"},
+	}
+	tests := []struct {
+		name         string
+		interval     string
+		timeZone     string
+		dateToString bool
+		bucket       string
+	}{
+		{name: "date trunc", interval: INTERVAL_MONTH, timeZone: "Europe/Istanbul", bucket: `{"$dateTrunc":{"date":"$created_at","unit":"month","timezone":"Europe/Istanbul"}}`},
+		{name: "date trunc week", interval: INTERVAL_WEEK, bucket: `{"$dateTrunc":{"date":"$created_at","unit":"week","timezone":"UTC","startOfWeek":"monday"}}`},
+		{name: "date to string", interval: INTERVAL_DAY, dateToString: true, bucket: `{"$dateToString":{"format":"%Y-%m-%d","date":"$created_at","timezone":"UTC"}}`},
+		{
+			name:         "date to string quarter",
+			interval:     INTERVAL_QUARTER,
+			dateToString: true,
+			bucket: `{"$concat":[{"$dateToString":{"format":"%Y","date":"$created_at","timezone":"UTC"}},"-Q",` +
+				`{"$toString":{"$ceil":{"$divide":[{"$month":{"date":"$created_at","timezone":"UTC"}},3]}}}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jm := &JsonMap{
+				Conditions:    map[string]ConditionOperators{"status": {ValuesToExactMatch: []interface{}{"shipped"}}},
+				DateHistogram: &DateHistogram{Field: "createdAt", Interval: tt.interval, TimeZone: tt.timeZone, Metrics: []Metric{{Function: METRIC_AVG, Field: "amount"}}},
+			}
+			want := `{"v":[{"$match":{"$and":[{"$and":[{"status":{"$in":["shipped"]}}]}]}},` +
+				`{"$group":{"_id":` + tt.bucket + `,"tesoql_count":{"$sum":1},"avg_amount":{"$avg":"$amount"}}},{"$sort":{"_id":1}},{"$limit":10001}]}`
+			if pipeline := mongoJSON(t, getMongoDateHistogramPipeline(fm, jm, tt.dateToString)); pipeline != want {
+				t.Errorf("pipeline = %s\nwant       %s", pipeline, want)
+			}
+		})
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	if err != nil {
+		t.Skip(err)
+	}
+	h := &DateHistogram{Field: "createdAt", Interval: INTERVAL_DAY, TimeZone: "Europe/Istanbul", Metrics: []Metric{{Function: METRIC_SUM, Field: "amount"}}}
+	rows := []map[string]interface{}{
+		{HISTOGRAM_BUCKET_COLUMN: nil, HISTOGRAM_COUNT_COLUMN: int64(9)},
+		{HISTOGRAM_BUCKET_COLUMN: []byte("2024-05-01"), HISTOGRAM_COUNT_COLUMN: int64(2), "sum_amount": []byte("12.50")},
+		{HISTOGRAM_BUCKET_COLUMN: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), HISTOGRAM_COUNT_COLUMN: int64(1), "sum_amount": 3.0},
+	}
+	want := []HistogramBucket{
+		{Start: time.Date(2024, 5, 1, 0, 0, 0, 0, istanbul), Count: 2, Metrics: map[string]interface{}{"sum_amount": "12.50"}},
+		{Start: time.Date(2024, 5, 2, 0, 0, 0, 0, istanbul), Count: 1, Metrics: map[string]interface{}{"sum_amount": 3.0}},
+	}
+	if buckets := sqlHistogramBuckets(h, rows); !reflect.DeepEqual(buckets, want) {
+		t.Errorf("sql buckets = %v\nwant          %v", buckets, want)
+	}
+
+	h = &DateHistogram{Field: "createdAt", Interval: INTERVAL_WEEK, TimeZone: "Europe/Istanbul"}
+	documents := []bson.M{
+		{"_id": primitive.NewDateTimeFromTime(time.Date(2024, 4, 28, 21, 0, 0, 0, time.UTC)), HISTOGRAM_COUNT_COLUMN: int32(4)},
+		{"_id": "2024-W19", HISTOGRAM_COUNT_COLUMN: int32(1)},
+	}
+	buckets := mongoHistogramBuckets(h, documents)
+	if len(buckets) != 2 ||
+		!buckets[0].Start.Equal(time.Date(2024, 4, 29, 0, 0, 0, 0, istanbul)) || buckets[0].Count != 4 ||
+		!buckets[1].Start.Equal(time.Date(2024, 5, 6, 0, 0, 0, 0, istanbul)) || buckets[1].Count != 1 {
+		t.Errorf("mongo buckets = %v", buckets)
+	}
+}
+
+func TestFillHistogram(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields:   map[string]string{"createdAt": "created_at"},
+		DateTimeFieldKeys: map[string]string{"createdAt": "created_at"},
+	}
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name       string
+		conditions map[string]ConditionOperators
+		buckets    []HistogramBucket
+		starts     []time.Time
+	}{
+		{
+			name:    "between buckets",
+			buckets: []HistogramBucket{{Start: day(2), Count: 1}, {Start: day(5), Count: 2}},
+			starts:  []time.Time{day(2), day(3), day(4), day(5)},
+		},
+		{
+			name:       "between condition bounds",
+			conditions: map[string]ConditionOperators{"createdAt": {GreaterOrEqual: "2024-05-01T10:00:00Z", LowerThan: "2024-05-04T00:00:00Z"}},
+			buckets:    []HistogramBucket{{Start: day(2), Count: 1}},
+			starts:     []time.Time{day(1), day(2), day(3)},
+		},
+		{
+			name:   "no buckets nor bounds",
+			starts: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jm := &JsonMap{Conditions: tt.conditions, DateHistogram: &DateHistogram{Field: "createdAt", Interval: INTERVAL_DAY, FillEmpty: true}}
+			filled, err := jm.fillHistogram(fm, tt.buckets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var starts []time.Time
+			for _, bucket := range filled {
+				starts = append(starts, bucket.Start)
+			}
+			if !reflect.DeepEqual(starts, tt.starts) {
+				t.Errorf("starts = %v\nwant     %v", starts, tt.starts)
+			}
+		})
+	}
+
+	jm := &JsonMap{
+		Conditions:    map[string]ConditionOperators{"createdAt": {GreaterOrEqual: "2000-01-01T00:00:00Z", LowerThan: "2024-01-01T00:00:00Z"}},
+		DateHistogram: &DateHistogram{Field: "createdAt", Interval: INTERVAL_MINUTE, FillEmpty: true},
+	}
+	if _, err := jm.fillHistogram(fm, nil); err == nil || err.ErrorCode != HISTOGRAM_ERR_CODE {
+		t.Errorf("fillHistogram() = %v, want too many buckets", err)
+	}
+}
+
+func TestValidateDateHistogram(t *testing.T) {
+	fm := &FieldsMap{
+		ConditionFields:   map[string]string{"createdAt": "created_at", "status": "status"},
+		DateTimeFieldKeys: map[string]string{"createdAt": "created_at"},
+		AggregationFields: map[string]string{"amount": "amount", "status": "status"},
+	}
+	tests := []struct {
+		name      string
+		histogram *DateHistogram
+		field     string
+	}{
+		{name: "valid", histogram: &DateHistogram{Field: "createdAt", Interval: INTERVAL_WEEK, TimeZone: "-03:30", Metrics: []Metric{{Function: METRIC_COUNT}}}},
+		{name: "not a date field", histogram: &DateHistogram{Field: "status", Interval: INTERVAL_DAY}, field: "dateHistogram.field"},
+		{name: "interval", histogram: &DateHistogram{Field: "createdAt", Interval: "fortnight"}, field: "dateHistogram.interval"},
+		{name: "injected time zone", histogram: &DateHistogram{Field: "createdAt", Interval: INTERVAL_DAY, TimeZone: "UTC'--"}, field: "dateHistogram.timeZone"},
+		{name: "local time zone", histogram: &DateHistogram{Field: "createdAt", Interval: INTERVAL_DAY, TimeZone: "Local"}, field: "dateHistogram.timeZone"},
+		{name: "offset out of range", histogram: &DateHistogram{Field: "createdAt", Interval: INTERVAL_DAY, TimeZone: "+15:00"}, field: "dateHistogram.timeZone"},
+		{name: "metric", histogram: &DateHistogram{Field: "createdAt", Interval: INTERVAL_DAY, Metrics: []Metric{{Function: METRIC_SUM, Field: "price"}}}, field: "dateHistogram.metrics.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&JsonMap{DateHistogram: tt.histogram}).validateDateHistogram(fm)
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("validateDateHistogram() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.ErrorCode != HISTOGRAM_ERR_CODE || err.Field != tt.field {
+				t.Errorf("validateDateHistogram() = %v, want HISTOGRAM_ERR_CODE on %s", err, tt.field)
+			}
+		})
+	}
+}
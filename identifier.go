@@ -0,0 +1,147 @@
+package tesoql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RAW_EXPRESSION_PREFIX marks a FieldsMap value as a raw SQL expression, see RawExpression.
+const RAW_EXPRESSION_PREFIX = "raw:"
+
+var identifierPattern = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_$#]*$`)
+
+// RawExpression marks a SQL expression to be written into queries as it is, instead of
+// being validated and quoted as a column name. Raw expressions are trusted input: they
+// must only come from code, never from a request or user editable configuration.
+// A projected raw expression is selected under its FieldsMap key.
+//
+// Example usage:
+//
+//	fm := &tesoql.FieldsMap{
+//		ProjectionFields: map[string]string{
+//			"id":       "id",
+//			"fullName": tesoql.RawExpression("first_name || ' ' || last_name"),
+//		},
+//	}
+//
+// Returns:
+//
+// - string: The expression with RAW_EXPRESSION_PREFIX prepended.
+func RawExpression(expr string) string {
+	return RAW_EXPRESSION_PREFIX + expr
+}
+
+func isRawExpression(name string) bool {
+	return strings.HasPrefix(name, RAW_EXPRESSION_PREFIX)
+}
+
+// validateIdentifier checks that name is a column or table name, optionally
+// qualified with dots (schema.table, table.column).
+func validateIdentifier(name string) error {
+	if name == "" {
+		return fmt.Errorf("identifier is empty")
+	}
+	for _, part := range strings.Split(name, ".") {
+		if !identifierPattern.MatchString(part) {
+			return fmt.Errorf("'%s' is not a valid identifier", name)
+		}
+	}
+	return nil
+}
+
+// validateMongoField checks that name is a document field path that can not be
+// mistaken for an operator.
+func validateMongoField(name string) error {
+	if isRawExpression(name) {
+		return fmt.Errorf("raw expressions are not supported on mongo ('%s')", name)
+	}
+	for _, part := range strings.Split(name, ".") {
+		if part == "" || strings.HasPrefix(part, "$") || strings.ContainsRune(part, 0) {
+			return fmt.Errorf("'%s' is not a valid field path", name)
+		}
+	}
+	return nil
+}
+
+// quoteIdentifier quotes every part of a possibly qualified identifier with the
+// dialect's quote character. Raw expressions are returned without their prefix.
+func (d *Dialect) quoteIdentifier(name string) string {
+	if isRawExpression(name) {
+		return strings.TrimPrefix(name, RAW_EXPRESSION_PREFIX)
+	}
+	if d.IdentifierQuote == QUOTE_NONE {
+		return name
+	}
+	opening, closing := d.IdentifierQuote, d.IdentifierQuote
+	if opening == QUOTE_BRACKET {
+		closing = "]"
+	}
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = opening + strings.ReplaceAll(part, closing, closing+closing) + closing
+	}
+	return strings.Join(parts, ".")
+}
+
+// validateIdentifiers checks the table name and every column of the FieldsMap
+// before any query is built, so that a mistyped or malicious mapping fails at
+// start up instead of reaching the database.
+func (cfg *Config) validateIdentifiers() error {
+	validate := validateIdentifier
+	if cfg.Engine == MONGO_ENGINE {
+		validate = validateMongoField
+	} else if cfg.ConnectionConfig != nil && cfg.ConnectionConfig.TableName != "" {
+		if err := validateIdentifier(cfg.ConnectionConfig.TableName); err != nil {
+			return fmt.Errorf("ConnectionConfig.TableName: %v", err)
+		}
+	}
+
+	if cfg.FieldsMap == nil {
+		return nil
+	}
+	if cfg.FieldsMap.TiebreakerField != "" {
+		if err := validate(cfg.FieldsMap.TiebreakerField); err != nil {
+			return fmt.Errorf("FieldsMap.TiebreakerField: %v", err)
+		}
+	}
+	fieldGroups := []struct {
+		name   string
+		fields map[string]string
+	}{
+		{"DateTimeFieldKeys", cfg.FieldsMap.DateTimeFieldKeys},
+		{"SearchFields", cfg.FieldsMap.SearchFields},
+		{"SortingFields", cfg.FieldsMap.SortingFields},
+		{"ProjectionFields", cfg.FieldsMap.ProjectionFields},
+		{"ConditionFields", cfg.FieldsMap.ConditionFields},
+		{"AggregationFields", cfg.FieldsMap.AggregationFields},
+		{"FacetFields", cfg.FieldsMap.FacetFields},
+		{"DistinctFields", cfg.FieldsMap.DistinctFields},
+		{"FoldedSearchFields", cfg.FieldsMap.FoldedSearchFields},
+	}
+	for _, group := range fieldGroups {
+		for _, key := range sortedKeys(group.fields) {
+			column := group.fields[key]
+			if isRawExpression(column) && cfg.Engine != MONGO_ENGINE {
+				if strings.TrimSpace(strings.TrimPrefix(column, RAW_EXPRESSION_PREFIX)) == "" {
+					return fmt.Errorf("FieldsMap.%s['%s']: raw expression is empty", group.name, key)
+				}
+				if group.name == "ProjectionFields" {
+					// raw projections are selected under their key
+					if !identifierPattern.MatchString(key) {
+						return fmt.Errorf("FieldsMap.%s['%s']: key of a raw expression is not a valid alias", group.name, key)
+					}
+				}
+				continue
+			}
+			if group.name == "AggregationFields" && !identifierPattern.MatchString(key) {
+				// groups are returned under their key
+				return fmt.Errorf("FieldsMap.%s['%s']: key is not a valid alias", group.name, key)
+			}
+			if err := validate(column); err != nil {
+				return fmt.Errorf("FieldsMap.%s['%s']: %v", group.name, key, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package tesoql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect *Dialect
+		input   string
+		quoted  string
+	}{
+		{name: "none", dialect: GenericDialect, input: "sales.order", quoted: "sales.order"},
+		{name: "double", dialect: PostgresDialect, input: "order", quoted: `"order"`},
+		{name: "double qualified", dialect: PostgresDialect, input: "sales.order", quoted: `"sales"."order"`},
+		{name: "double escaped", dialect: PostgresDialect, input: `a"b`, quoted: `"a""b"`},
+		{name: "backtick", dialect: MySqlDialect, input: "sales.user", quoted: "`sales`.`user`"},
+		{name: "backtick escaped", dialect: MySqlDialect, input: "a`b", quoted: "`a``b`"},
+		{name: "bracket", dialect: SqlServerDialect, input: "dbo.user", quoted: "[dbo].[user]"},
+		{name: "bracket escaped", dialect: SqlServerDialect, input: "a]b", quoted: "[a]]b]"},
+		{name: "raw expression", dialect: PostgresDialect, input: RawExpression("LOWER(name)"), quoted: "LOWER(name)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if quoted := tt.dialect.quoteIdentifier(tt.input); quoted != tt.quoted {
+				t.Errorf("quoteIdentifier(%q) = %s, want %s", tt.input, quoted, tt.quoted)
+			}
+		})
+	}
+}
+
+func TestValidateIdentifier(t *testing.T) {
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{input: "order", valid: true},
+		{input: "sales.order", valid: true},
+		{input: "_created_at", valid: true},
+		{input: "şehir", valid: true},
+		{input: "", valid: false},
+		{input: "1st", valid: false},
+		{input: "sales..order", valid: false},
+		{input: "name; DROP TABLE users", valid: false},
+		{input: "name--", valid: false},
+		{input: `a"b`, valid: false},
+	}
+	for _, tt := range tests {
+		if err := validateIdentifier(tt.input); (err == nil) != tt.valid {
+			t.Errorf("validateIdentifier(%q) = %v, want valid %t", tt.input, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateIdentifiers(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   *Config
+		error string
+	}{
+		{
+			name: "valid",
+			cfg: &Config{Engine: POSTGRES_ENGINE, ConnectionConfig: &ConnectionConfig{TableName: "sales.order"}, FieldsMap: &FieldsMap{
+				SearchFields:     map[string]string{"name": "product_name"},
+				ProjectionFields: map[string]string{"fullName": RawExpression("first_name || ' ' || last_name")},
+			}},
+		},
+		{
+			name:  "table",
+			cfg:   &Config{Engine: POSTGRES_ENGINE, ConnectionConfig: &ConnectionConfig{TableName: "order; --"}},
+			error: "ConnectionConfig.TableName",
+		},
+		{
+			name:  "column",
+			cfg:   &Config{Engine: POSTGRES_ENGINE, FieldsMap: &FieldsMap{SortingFields: map[string]string{"name": "name DESC"}}},
+			error: "FieldsMap.SortingFields['name']",
+		},
+		{
+			name:  "tiebreaker",
+			cfg:   &Config{Engine: POSTGRES_ENGINE, FieldsMap: &FieldsMap{TiebreakerField: "id)"}},
+			error: "FieldsMap.TiebreakerField",
+		},
+		{
+			name:  "empty raw expression",
+			cfg:   &Config{Engine: POSTGRES_ENGINE, FieldsMap: &FieldsMap{SearchFields: map[string]string{"name": RawExpression(" ")}}},
+			error: "raw expression is empty",
+		},
+		{
+			name:  "raw projection alias",
+			cfg:   &Config{Engine: POSTGRES_ENGINE, FieldsMap: &FieldsMap{ProjectionFields: map[string]string{"full name": RawExpression("a || b")}}},
+			error: "not a valid alias",
+		},
+		{
+			name:  "mongo operator",
+			cfg:   &Config{Engine: MONGO_ENGINE, FieldsMap: &FieldsMap{ConditionFields: map[string]string{"amount": "$where"}}},
+			error: "not a valid field path",
+		},
+		{
+			name:  "mongo raw expression",
+			cfg:   &Config{Engine: MONGO_ENGINE, FieldsMap: &FieldsMap{ConditionFields: map[string]string{"amount": RawExpression("x")}}},
+			error: "raw expressions are not supported on mongo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validateIdentifiers()
+			if tt.error == "" {
+				if err != nil {
+					t.Errorf("validateIdentifiers() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("validateIdentifiers() = %v, want an error containing %q", err, tt.error)
+			}
+		})
+	}
+}
+
+func TestSqlQueryQuotesIdentifiers(t *testing.T) {
+	fm := &FieldsMap{
+		ProjectionFields: map[string]string{"id": "id", "user": "user", "status": RawExpression("UPPER(status)")},
+		SortingFields:    map[string]string{"user": "user"},
+		ConditionFields:  map[string]string{"order": "order"},
+	}
+	jm := &JsonMap{
+		ProjectionFields: []string{"id", "user", "status"},
+		Conditions:       map[string]ConditionOperators{"order": {LowerThan: 5}},
+		SortConditions:   []SortInput{{Field: "user", SortCondition: "DESC"}},
+		Pagination:       Pagination{Limit: 10},
+	}
+	query, where, _ := jm.GetSqlQueryWithDialect(fm, "sales.order", PostgresDialect, false)
+	want := `SELECT "id", "user", UPPER(status) AS "status" FROM "sales"."order" WHERE 1=1 AND "order" < $1 ORDER BY "user" DESC LIMIT 10 OFFSET 0`
+	if query != want {
+		t.Errorf("query = %s\nwant    %s", query, want)
+	}
+	if where != ` AND "order" < $1` {
+		t.Errorf("where = %s", where)
+	}
+}
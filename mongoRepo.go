@@ -6,43 +6,64 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"time"
 )
 
 type mongoRepository struct {
-	mongo     *mongo.Collection
-	fieldsMap *FieldsMap
+	mongo        *mongo.Collection
+	fieldsMap    *FieldsMap
+	ownsClient   bool // The client was connected by tesoql, and is disconnected by close.
+	dateToString bool // Date histograms are bucketed with $dateToString instead of $dateTrunc.
 }
 
-func newMongoRepository(cfg *Config) *mongoRepository {
+func newMongoRepository(cfg *Config) (*mongoRepository, *ErrorResponseDTO) {
 	var collection *mongo.Collection
 	var client *mongo.Client
 
-	if cfg.ConnectionConfig.ConnectionString != "" {
+	if cfg.ConnectionConfig.DBName == "" || cfg.ConnectionConfig.TableName == "" {
+		return nil, newResponse(TESOQL_CONFIG_ERROR, "Database or collection name is not provided!", CONFIG_CONNECTION_ERR_CODE)
+	}
+
+	ownsClient := cfg.ConnectionConfig.ConnectionString != ""
+	if ownsClient {
+		ctx, cancel := withDefaultTimeout(context.Background(), cfg.DefaultTimeout)
+		defer cancel()
 		var err error
-		client, err = mongo.Connect(context.TODO(), options.Client().ApplyURI(cfg.ConnectionConfig.ConnectionString))
+		client, err = mongo.Connect(ctx, options.Client().ApplyURI(cfg.ConnectionConfig.ConnectionString))
 		if err != nil {
-			panic(fmt.Sprintf("Failed to connect to mongo: %v", err))
+			return nil, newResponse(TESOQL_CONNECTION_ERROR, fmt.Sprintf("Failed to connect to mongo: %v", err), CONNECTION_OPEN_ERR_CODE).withCause(err)
+		}
+
+		if err = client.Ping(ctx, nil); err != nil {
+			client.Disconnect(context.Background())
+			return nil, newResponse(TESOQL_CONNECTION_ERROR, fmt.Sprintf("Failed to ping mongo: %v", err), CONNECTION_PING_ERR_CODE).withCause(err)
 		}
 	} else if cfg.ConnectionConfig.Client != nil {
 		var ok bool
 		client, ok = cfg.ConnectionConfig.Client.(*mongo.Client)
 		if !ok || client == nil {
-			panic("Provided client is not a valid mongo client")
+			return nil, newResponse(TESOQL_CONFIG_ERROR, "Provided client is not a valid mongo client", CONFIG_CONNECTION_ERR_CODE)
 		}
 	} else {
-		panic("Mongo config is not defined correctly")
-	}
-
-	if cfg.ConnectionConfig.DBName == "" || cfg.ConnectionConfig.TableName == "" {
-		panic("Database or collection name is not provided!")
+		return nil, newResponse(TESOQL_CONFIG_ERROR, "Mongo config is not defined correctly", CONFIG_CONNECTION_ERR_CODE)
 	}
 
 	collection = client.Database(cfg.ConnectionConfig.DBName).Collection(cfg.ConnectionConfig.TableName)
 	return &mongoRepository{
-		mongo:     collection,
-		fieldsMap: cfg.FieldsMap,
+		mongo:        collection,
+		fieldsMap:    cfg.FieldsMap,
+		ownsClient:   ownsClient,
+		dateToString: cfg.MongoDateToString,
+	}, nil
+}
+
+func (r *mongoRepository) Close() error {
+	if !r.ownsClient {
+		return nil
+	}
+	if err := r.mongo.Database().Client().Disconnect(context.Background()); err != nil {
+		return newResponse(TESOQL_CONNECTION_ERROR, err.Error(), CONNECTION_CLOSE_ERR_CODE).withCause(err)
 	}
+	return nil
 }
 
 //func (r *mongoRepository) repository(jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO) {
@@ -107,55 +128,223 @@ func newMongoRepository(cfg *Config) *mongoRepository {
 //
 //}
 
-func (r *mongoRepository) repository(jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO) {
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
+func (r *mongoRepository) Find(ctx context.Context, jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO) {
 
-	var opts *options.FindOptions
 	var filter = bson.D{{}}
 
 	query := jsonMap.NewMongoQuery(r.fieldsMap)
-
-	opts = options.Find().SetLimit(query.Limit).SetSkip(query.Offset)
-
-	if query.Projection != nil {
-		opts = opts.SetProjection(query.Projection)
+	if query.Group != nil {
+		return r.aggregate(ctx, jsonMap, query)
 	}
+	opts := findOptions(query)
 
 	if query.Filter != nil {
 		filter = *query.Filter
 	}
 
-	if query.Sort != nil {
-		opts = opts.SetSort(query.Sort)
-	}
-
 	var results []map[string]interface{}
 	var size int
 	var totalCount int
 
 	if jsonMap.TotalCount {
-		countOpts := &options.CountOptions{}
+		countOpts := &options.CountOptions{Collation: query.Collation}
 		totalCount64, countErr := r.mongo.CountDocuments(ctx, filter, countOpts)
 		if countErr != nil {
-			return nil, 0, 0, newResponse(TESOQL_MONGO_ERROR, countErr.Error(), MONGO_FIND_ERR_CODE)
+			return nil, 0, 0, newResponse(TESOQL_MONGO_ERROR, countErr.Error(), MONGO_FIND_ERR_CODE).withCause(countErr)
 		}
 		totalCount = int(totalCount64)
 	}
 
 	if !jsonMap.SuppressDataResponse {
-		cur, err := r.mongo.Find(ctx, filter, opts)
+		cur, err := r.mongo.Find(ctx, query.FindFilter(), opts)
 		if err != nil {
-			return nil, 0, 0, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_FIND_ERR_CODE)
+			return nil, 0, 0, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_FIND_ERR_CODE).withCause(err)
 		}
 		defer cur.Close(ctx)
 		err = cur.All(ctx, &results)
 		if err != nil {
-			return nil, 0, 0, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_CURSOR_ERR_CODE)
+			return nil, 0, 0, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_CURSOR_ERR_CODE).withCause(err)
 		}
 		size = len(results)
 	}
 
 	return results, totalCount, size, nil
 }
+
+// aggregate runs the pipeline of a query with aggregations, and counts the groups.
+func (r *mongoRepository) aggregate(ctx context.Context, jsonMap *JsonMap, query *MongoQuery) ([]map[string]interface{}, int, int, *ErrorResponseDTO) {
+	var results []map[string]interface{}
+	var totalCount int
+
+	if jsonMap.TotalCount {
+		cur, err := r.mongo.Aggregate(ctx, query.CountPipeline(), aggregateOptions(query.Collation))
+		if err != nil {
+			return nil, 0, 0, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_FIND_ERR_CODE).withCause(err)
+		}
+		var counts []struct {
+			Count int `bson:"count"`
+		}
+		err = cur.All(ctx, &counts)
+		if err != nil {
+			return nil, 0, 0, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_CURSOR_ERR_CODE).withCause(err)
+		}
+		if len(counts) > 0 {
+			totalCount = counts[0].Count
+		}
+	}
+
+	if !jsonMap.SuppressDataResponse {
+		cur, err := r.mongo.Aggregate(ctx, query.Pipeline(), aggregateOptions(query.Collation))
+		if err != nil {
+			return nil, 0, 0, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_FIND_ERR_CODE).withCause(err)
+		}
+		defer cur.Close(ctx)
+		err = cur.All(ctx, &results)
+		if err != nil {
+			return nil, 0, 0, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_CURSOR_ERR_CODE).withCause(err)
+		}
+	}
+
+	return results, totalCount, len(results), nil
+}
+
+// Stream runs the find of the JsonMap and returns an iterator decoding the documents
+// one by one as the mongo cursor advances. The total count is not computed.
+func (r *mongoRepository) Stream(ctx context.Context, jsonMap *JsonMap) (RowIterator, *ErrorResponseDTO) {
+	query := jsonMap.NewMongoQuery(r.fieldsMap)
+	var cur *mongo.Cursor
+	var err error
+	if query.Group != nil {
+		cur, err = r.mongo.Aggregate(ctx, query.Pipeline(), aggregateOptions(query.Collation))
+	} else {
+		cur, err = r.mongo.Find(ctx, query.FindFilter(), findOptions(query))
+	}
+	if err != nil {
+		return nil, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_FIND_ERR_CODE).withCause(err)
+	}
+	return &mongoRowIterator{ctx: ctx, cur: cur}, nil
+}
+
+// Facets runs the pipelines of the facets of the JsonMap: one $facet stage for the
+// facets sharing the filters of the JsonMap, and one pipeline per disjunctive facet.
+func (r *mongoRepository) Facets(ctx context.Context, jsonMap *JsonMap) (map[string][]FacetBucket, *ErrorResponseDTO) {
+	facets := make(map[string][]FacetBucket, len(jsonMap.Facets))
+	for _, p := range getMongoFacetPipelines(r.fieldsMap, jsonMap) {
+		cur, err := r.mongo.Aggregate(ctx, p.pipeline, aggregateOptions(getMongoCollation(r.fieldsMap, p.filters)))
+		if err != nil {
+			return nil, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_FIND_ERR_CODE).withCause(err)
+		}
+		if p.facet != nil {
+			var output []bson.M
+			err = cur.All(ctx, &output)
+			if err != nil {
+				return nil, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_CURSOR_ERR_CODE).withCause(err)
+			}
+			facets[p.facet.name()] = mongoFacetBuckets(p.facet, output)
+			continue
+		}
+		var documents []map[string][]bson.M
+		err = cur.All(ctx, &documents)
+		if err != nil {
+			return nil, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_CURSOR_ERR_CODE).withCause(err)
+		}
+		for i := range jsonMap.Facets {
+			facet := &jsonMap.Facets[i]
+			if facet.Disjunctive {
+				continue
+			}
+			var output []bson.M
+			if len(documents) > 0 {
+				output = documents[0][facet.name()]
+			}
+			facets[facet.name()] = mongoFacetBuckets(facet, output)
+		}
+	}
+	return facets, nil
+}
+
+// Distinct runs the aggregation pipeline listing the distinct values of the field.
+func (r *mongoRepository) Distinct(ctx context.Context, field string, jsonMap *JsonMap) ([]DistinctValue, *ErrorResponseDTO) {
+	cur, err := r.mongo.Aggregate(ctx, getMongoDistinctPipeline(r.fieldsMap, jsonMap, field), aggregateOptions(getMongoCollation(r.fieldsMap, jsonMap)))
+	if err != nil {
+		return nil, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_FIND_ERR_CODE).withCause(err)
+	}
+	defer cur.Close(ctx)
+	var documents []bson.M
+	err = cur.All(ctx, &documents)
+	if err != nil {
+		return nil, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_CURSOR_ERR_CODE).withCause(err)
+	}
+	return mongoDistinctValues(documents), nil
+}
+
+// DateHistogram runs the aggregation pipeline of the date histogram of the JsonMap.
+func (r *mongoRepository) DateHistogram(ctx context.Context, jsonMap *JsonMap) ([]HistogramBucket, *ErrorResponseDTO) {
+	cur, err := r.mongo.Aggregate(ctx, getMongoDateHistogramPipeline(r.fieldsMap, jsonMap, r.dateToString), aggregateOptions(getMongoCollation(r.fieldsMap, jsonMap)))
+	if err != nil {
+		return nil, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_FIND_ERR_CODE).withCause(err)
+	}
+	defer cur.Close(ctx)
+	var documents []bson.M
+	err = cur.All(ctx, &documents)
+	if err != nil {
+		return nil, newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_CURSOR_ERR_CODE).withCause(err)
+	}
+	return mongoHistogramBuckets(jsonMap.DateHistogram, documents), nil
+}
+
+func findOptions(query *MongoQuery) *options.FindOptions {
+	opts := options.Find().SetLimit(query.Limit).SetSkip(query.Offset)
+	if query.Projection != nil {
+		opts = opts.SetProjection(query.Projection)
+	}
+	if query.Sort != nil {
+		opts = opts.SetSort(query.Sort)
+	}
+	if query.Collation != nil {
+		opts = opts.SetCollation(query.Collation)
+	}
+	return opts
+}
+
+func aggregateOptions(collation *options.Collation) *options.AggregateOptions {
+	return &options.AggregateOptions{Collation: collation}
+}
+
+type mongoRowIterator struct {
+	ctx context.Context
+	cur *mongo.Cursor
+	row map[string]interface{}
+	err error
+}
+
+func (it *mongoRowIterator) Next() bool {
+	if it.err != nil || !it.cur.Next(it.ctx) {
+		return false
+	}
+	row := make(map[string]interface{})
+	if err := it.cur.Decode(&row); err != nil {
+		it.err = newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_CURSOR_ERR_CODE).withCause(err)
+		return false
+	}
+	it.row = row
+	return true
+}
+
+func (it *mongoRowIterator) Row() map[string]interface{} {
+	return it.row
+}
+
+func (it *mongoRowIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	if err := it.cur.Err(); err != nil {
+		return newResponse(TESOQL_MONGO_ERROR, err.Error(), MONGO_CURSOR_ERR_CODE).withCause(err)
+	}
+	return nil
+}
+
+func (it *mongoRowIterator) Close() error {
+	return it.cur.Close(context.Background())
+}
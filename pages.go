@@ -0,0 +1,108 @@
+package tesoql
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ForEachPage walks every record matching the JsonMap page by page, calling fn with each
+// page, for batch jobs that need the whole result. Pages continue from the sort values of
+// the last record of the previous page (keyset pagination) instead of an offset, so that
+// records are neither skipped nor repeated when rows are inserted or deleted during the
+// walk. It requires FieldsMap.TiebreakerField to make the sort order total. Sort fields
+// may hold null values, but they cannot be raw expressions, which are not read back.
+//
+// The page size is PaginationConfig.BatchPageSize (DEFAULT_BATCH_PAGE_SIZE when zero), a
+// server-side option that is not bound by LimitUpperBound; the limit and offset of the
+// JsonMap are ignored. A cursor in the JsonMap is honored as the starting position.
+// The total count, when requested, is only computed for the first page. Each page
+// query is bounded by Config.DefaultTimeout when ctx has no deadline.
+//
+// Example usage:
+//
+//	err := tesoQL.Service.ForEachPage(ctx, &jsonMapVariable, func(page tesoql.Result) error {
+//		return writeOrders(page.Items)
+//	})
+//
+// Returns:
+//
+// - error: The error returned by fn, which stops the walk, or an *ErrorResponseDTO if a page could not be retrieved.
+func (s *Service) ForEachPage(ctx context.Context, jsonMap *JsonMap, fn func(page Result) error) error {
+	if s.fieldsMap == nil || s.fieldsMap.TiebreakerField == "" {
+		return newResponse(TESOQL_VALIDATION_ERROR, "ForEachPage requires FieldsMap.TiebreakerField.", CURSOR_ERR_CODE)
+	}
+	if jsonMap.Aggregations != nil {
+		return newResponse(TESOQL_VALIDATION_ERROR, "ForEachPage does not support aggregations.", AGGREGATION_ERR_CODE).withField("aggregations")
+	}
+	if jsonMap.SortByRelevance {
+		return newResponse(TESOQL_VALIDATION_ERROR, "ForEachPage does not support sorting by relevance.", SORTABLE_ERR_CODE).withField("sortByRelevance")
+	}
+	batchSize := int64(DEFAULT_BATCH_PAGE_SIZE)
+	if s.pagination != nil && s.pagination.BatchPageSize > 0 {
+		batchSize = s.pagination.BatchPageSize
+	}
+
+	page := *jsonMap
+	page.Pagination = Pagination{Cursor: jsonMap.Pagination.Cursor}
+	if err := s.validate(&page); err != nil {
+		return err
+	}
+	for _, sortInput := range page.SortConditions {
+		// raw expressions are not selected, the next page could not continue from them
+		if isRawExpression(s.fieldsMap.SortingFields[sortInput.Field]) {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("ForEachPage cannot continue from the raw sort expression of '%s'.", sortInput.Field),
+				CURSOR_ERR_CODE).withField("sortConditions." + sortInput.Field)
+		}
+	}
+	// one record more tells whether another page follows
+	page.Pagination.Limit = batchSize + 1
+	page.keysetColumns = true
+	columns := effectiveSort(s.fieldsMap, &page)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start := time.Now()
+		items, totalCount, size, err := s.findPage(ctx, &page)
+		if err != nil {
+			return err
+		}
+
+		result := Result{Items: items, Size: size, Limit: batchSize}
+		if page.TotalCount {
+			result.TotalCount = &totalCount
+			page.TotalCount = false
+		}
+		if int64(size) > batchSize {
+			result.Items = items[:batchSize]
+			result.Size = int(batchSize)
+			result.HasMore = true
+		}
+		result.Duration = time.Since(start)
+		// the position of the next page is read before the added sort columns are stripped,
+		// and before fn runs, so that a page is never handed over when the walk cannot go on
+		var keyset []interface{}
+		if result.HasMore {
+			var keysetErr *ErrorResponseDTO
+			if keyset, keysetErr = rowKeyset(columns, result.Items[result.Size-1], true); keysetErr != nil {
+				return keysetErr
+			}
+		}
+		stripKeysetProjection(s.fieldsMap, &page, result.Items)
+		if err := fn(result); err != nil {
+			return err
+		}
+		if !result.HasMore {
+			return nil
+		}
+		page.keyset = keyset
+	}
+}
+
+func (s *Service) findPage(ctx context.Context, jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO) {
+	ctx, cancel := withDefaultTimeout(ctx, s.defaultTimeout)
+	defer cancel()
+	return s.repo.Find(ctx, jsonMap)
+}
@@ -0,0 +1,153 @@
+package tesoql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestForEachPage(t *testing.T) {
+	fm := &FieldsMap{
+		ProjectionFields: map[string]string{"name": "name"},
+		SortingFields:    map[string]string{"status": "status"},
+		TiebreakerField:  "id",
+	}
+	tests := []struct {
+		name      string
+		batchSize int64
+		count     int
+		sizes     []int
+		keysets   [][]interface{}
+	}{
+		{name: "partial last page", batchSize: 2, count: 5, sizes: []int{2, 2, 1}, keysets: [][]interface{}{nil, {int64(2)}, {int64(4)}}},
+		{name: "full last page", batchSize: 3, count: 6, sizes: []int{3, 3}, keysets: [][]interface{}{nil, {int64(3)}}},
+		{name: "default batch size", count: 3, sizes: []int{3}, keysets: [][]interface{}{nil}},
+		{name: "no records", batchSize: 2, sizes: []int{0}, keysets: [][]interface{}{nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newFakeService(&Config{FieldsMap: fm, Pagination: &PaginationConfig{BatchPageSize: tt.batchSize}}, tt.count)
+			jsonMap := &JsonMap{ProjectionFields: []string{"name"}, TotalCount: true, Pagination: Pagination{Limit: 1, Offset: 1}}
+			var sizes []int
+			err := service.ForEachPage(context.Background(), jsonMap, func(page Result) error {
+				if (page.TotalCount != nil) != (len(sizes) == 0) {
+					t.Errorf("page %d total count = %v, want it on the first page only", len(sizes), page.TotalCount)
+				}
+				for _, item := range page.Items {
+					if _, exists := item["id"]; exists {
+						t.Errorf("item = %v, want the keyset column stripped", item)
+					}
+				}
+				sizes = append(sizes, page.Size)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sizes, tt.sizes) {
+				t.Errorf("sizes = %v, want %v", sizes, tt.sizes)
+			}
+			var keysets [][]interface{}
+			for _, call := range repo.calls {
+				keysets = append(keysets, call.keyset)
+				if call.Pagination.Offset != 0 {
+					t.Errorf("offset = %d, want the pagination of the JsonMap ignored", call.Pagination.Offset)
+				}
+			}
+			if !reflect.DeepEqual(keysets, tt.keysets) {
+				t.Errorf("keysets = %v, want %v", keysets, tt.keysets)
+			}
+		})
+	}
+}
+
+func TestForEachPageErrors(t *testing.T) {
+	stop := errors.New("stop")
+	fm := &FieldsMap{
+		ProjectionFields: map[string]string{"name": "name"},
+		SortingFields:    map[string]string{"status": "status"},
+		TiebreakerField:  "id",
+	}
+	cfg := &Config{FieldsMap: fm, Pagination: &PaginationConfig{BatchPageSize: 2}}
+	tests := []struct {
+		name    string
+		cfg     *Config
+		jsonMap *JsonMap
+		fnErr   error
+		target  error
+		pages   int
+	}{
+		{name: "no tiebreaker", cfg: &Config{}, jsonMap: &JsonMap{}, target: ErrCursor},
+		{name: "aggregations", cfg: cfg, jsonMap: &JsonMap{Aggregations: &Aggregations{}}, target: ErrAggregation},
+		{name: "relevance", cfg: cfg, jsonMap: &JsonMap{SortByRelevance: true}, target: ErrSortable},
+		{
+			name:    "toggle",
+			cfg:     &Config{FieldsMap: fm, Toggles: &ToggleConfig{DisableSorting: true}},
+			jsonMap: &JsonMap{SortConditions: []SortInput{{Field: "status", SortCondition: "ASC"}}},
+			target:  ErrSortableToggle,
+		},
+		{name: "callback", cfg: cfg, jsonMap: &JsonMap{}, fnErr: stop, target: stop, pages: 1},
+		{
+			name: "raw sort expression",
+			cfg: &Config{FieldsMap: &FieldsMap{
+				SortingFields:   map[string]string{"score": RawExpression("price * quantity")},
+				TiebreakerField: "id",
+			}},
+			jsonMap: &JsonMap{SortConditions: []SortInput{{Field: "score", SortCondition: "DESC"}}},
+			target:  ErrCursor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newFakeService(tt.cfg, 5)
+			pages := 0
+			err := service.ForEachPage(context.Background(), tt.jsonMap, func(page Result) error {
+				pages++
+				return tt.fnErr
+			})
+			if !errors.Is(err, tt.target) {
+				t.Errorf("ForEachPage() = %v, want %v", err, tt.target)
+			}
+			if pages != tt.pages {
+				t.Errorf("pages = %d, want %d", pages, tt.pages)
+			}
+		})
+	}
+}
+
+func TestForEachPageNullBoundary(t *testing.T) {
+	fm := &FieldsMap{SortingFields: map[string]string{"status": "status"}, TiebreakerField: "id"}
+	service, repo := newFakeService(&Config{FieldsMap: fm, Pagination: &PaginationConfig{BatchPageSize: 2}}, 5)
+	// the last record of the first page has no status
+	repo.records[1]["status"] = nil
+	var sizes []int
+	err := service.ForEachPage(context.Background(), &JsonMap{SortConditions: []SortInput{{Field: "status", SortCondition: "ASC"}}}, func(page Result) error {
+		sizes = append(sizes, page.Size)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{2, 2, 1}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("sizes = %v, want %v", sizes, want)
+	}
+	if keyset, want := repo.calls[1].keyset, []interface{}{nil, int64(2)}; !reflect.DeepEqual(keyset, want) {
+		t.Errorf("keyset = %v, want %v", keyset, want)
+	}
+}
+
+func TestForEachPageCanceled(t *testing.T) {
+	fm := &FieldsMap{SortingFields: map[string]string{"status": "status"}, TiebreakerField: "id"}
+	service, _ := newFakeService(&Config{FieldsMap: fm, Pagination: &PaginationConfig{BatchPageSize: 2}}, 5)
+	ctx, cancel := context.WithCancel(context.Background())
+	pages := 0
+	err := service.ForEachPage(ctx, &JsonMap{}, func(page Result) error {
+		pages++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || pages != 1 {
+		t.Errorf("ForEachPage() = %v after %d pages, want context.Canceled after 1", err, pages)
+	}
+}
@@ -3,17 +3,22 @@ package tesoql
 import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"strings"
 )
 
 // MongoQuery represents a MongoDB query structure, including filter, projection, sort, limit, and offset options.
 type MongoQuery struct {
 	Filter     *bson.D // Filter criteria for the MongoDB query.
-	Projection *bson.D // Fields to include or exclude in the result set.
+	Seek       *bson.D // Keyset criteria selecting the documents after the cursor, nil without a cursor.
+	Group      *bson.D // $group stage of the aggregations, nil without aggregations.
+	Projection *bson.D // Fields to include or exclude in the result set, the $project stage of the groups with aggregations.
 	Sort       *bson.D // Sorting criteria for the query results.
 	Limit      int64   // Maximum number of documents to return.
 	Offset     int64   // Number of documents to skip.
+
+	Collation *options.Collation // Collation of the query, nil without FieldsMap.SearchLocale.
 }
 
 // NewMongoQuery creates a new MongoQuery based on the provided FieldsMap and JsonMap.
@@ -31,13 +36,93 @@ type MongoQuery struct {
 func (jm *JsonMap) NewMongoQuery(fm *FieldsMap) *MongoQuery {
 	query := new(MongoQuery)
 	query.Filter = getMongoFilter(fm, jm)
+	query.Seek = getMongoSeekFilter(fm, jm)
 	query.Sort = getMongoSortCondition(fm, jm)
-	query.Projection = getMongoProjection(fm, jm)
+	query.Collation = getMongoCollation(fm, jm)
+	if jm.Aggregations != nil {
+		query.Group, query.Projection = getMongoGroup(fm, jm)
+	} else {
+		query.Projection = getMongoProjection(fm, jm)
+	}
 	query.Limit = jm.Pagination.Limit
 	query.Offset = jm.Pagination.Offset
+	if jm.keyset != nil {
+		query.Offset = 0
+	}
 	return query
 }
 
+// FindFilter returns the filter to find the documents of the page: Filter, combined
+// with Seek when paginating with a cursor. Counting should use Filter alone.
+//
+// Returns:
+//
+// - bson.D: The filter to pass to Find.
+func (q *MongoQuery) FindFilter() bson.D {
+	var filterArr bson.A
+	if q.Filter != nil {
+		filterArr = append(filterArr, *q.Filter)
+	}
+	if q.Seek != nil {
+		filterArr = append(filterArr, *q.Seek)
+	}
+	switch len(filterArr) {
+	case 0:
+		return bson.D{}
+	case 1:
+		return filterArr[0].(bson.D)
+	}
+	return bson.D{{"$and", filterArr}}
+}
+
+// Pipeline returns the aggregation pipeline of a query with aggregations: the filter,
+// the $group and $project stages, then the sorting and paging of the groups.
+//
+// Example usage:
+//
+//	query := jm.NewMongoQuery(fm)
+//	cur, err := collection.Aggregate(ctx, query.Pipeline())
+//
+// Returns:
+//
+// - mongo.Pipeline: The pipeline, nil when the query has no aggregations.
+func (q *MongoQuery) Pipeline() mongo.Pipeline {
+	if q.Group == nil {
+		return nil
+	}
+	pipeline := mongo.Pipeline{{{"$match", q.FindFilter()}}, {{"$group", *q.Group}}}
+	if q.Projection != nil {
+		pipeline = append(pipeline, bson.D{{"$project", *q.Projection}})
+	}
+	if q.Sort != nil {
+		pipeline = append(pipeline, bson.D{{"$sort", *q.Sort}})
+	}
+	if q.Offset > 0 {
+		pipeline = append(pipeline, bson.D{{"$skip", q.Offset}})
+	}
+	if q.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{"$limit", q.Limit}})
+	}
+	return pipeline
+}
+
+// CountPipeline returns the aggregation pipeline counting the groups of a query with
+// aggregations, into the "count" field of its only document.
+//
+// Returns:
+//
+// - mongo.Pipeline: The pipeline, nil when the query has no aggregations.
+func (q *MongoQuery) CountPipeline() mongo.Pipeline {
+	if q.Group == nil {
+		return nil
+	}
+	filter := bson.D{}
+	if q.Filter != nil {
+		filter = *q.Filter
+	}
+	return mongo.Pipeline{{{"$match", filter}}, {{"$group", bson.D{(*q.Group)[0]}}}, {{"$count", "count"}}}
+}
+
 func getMongoFilter(fm *FieldsMap, jm *JsonMap) *bson.D {
 	var filterArr bson.A
 	var condArr bson.A
@@ -51,6 +136,10 @@ func getMongoFilter(fm *FieldsMap, jm *JsonMap) *bson.D {
 		filterArr = append(filterArr, combinedFilter)
 	}
 
+	if jm.Filter != nil {
+		filterArr = append(filterArr, getMongoFilterNode(fm, jm.Filter))
+	}
+
 	if filterArr != nil {
 		filter := bson.D{{"$and", filterArr}}
 		return &filter
@@ -58,6 +147,36 @@ func getMongoFilter(fm *FieldsMap, jm *JsonMap) *bson.D {
 	return nil
 }
 
+func getMongoFilterNode(fm *FieldsMap, node *Filter) bson.D {
+	switch {
+	case node.And != nil:
+		return bson.D{{"$and", getMongoFilterNodes(fm, node.And, bson.D{})}}
+	case node.Or != nil:
+		return bson.D{{"$or", getMongoFilterNodes(fm, node.Or, bson.D{{"_id", bson.D{{"$exists", false}}}})}}
+	case node.Not != nil:
+		return bson.D{{"$nor", bson.A{getMongoFilterNode(fm, node.Not)}}}
+	case node.Operators != nil:
+		condArr := mongoConditionPredicates(fm, node.Field, *node.Operators)
+		if len(condArr) > 0 {
+			return bson.D{{"$and", condArr}}
+		}
+	}
+	return bson.D{}
+}
+
+// getMongoFilterNodes translates the child nodes of a group. Mongo rejects empty
+// $and and $or arrays, so an empty group is replaced by its neutral element.
+func getMongoFilterNodes(fm *FieldsMap, nodes []Filter, neutral bson.D) bson.A {
+	arr := bson.A{}
+	for i := range nodes {
+		arr = append(arr, getMongoFilterNode(fm, &nodes[i]))
+	}
+	if len(arr) == 0 {
+		arr = append(arr, neutral)
+	}
+	return arr
+}
+
 func getMongoProjection(fm *FieldsMap, jm *JsonMap) *bson.D {
 
 	var projection bson.D
@@ -65,6 +184,10 @@ func getMongoProjection(fm *FieldsMap, jm *JsonMap) *bson.D {
 	for _, field := range jm.ProjectionFields {
 		projection = append(projection, bson.E{Key: fm.ProjectionFields[field], Value: 1})
 	}
+	// the sort fields are needed to build the next cursor
+	for _, column := range keysetProjection(fm, jm) {
+		projection = append(projection, bson.E{Key: column, Value: 1})
+	}
 	if projection != nil {
 		return &projection
 	}
@@ -75,19 +198,15 @@ func getMongoSortCondition(fm *FieldsMap, jm *JsonMap) *bson.D {
 
 	var sort bson.D
 
-	for _, sortInput := range jm.SortConditions {
-		var sortCondition int
-		switch sortInput.SortCondition {
-		case "ASC":
-			sortCondition = 1
-			break
-		case "DESC":
+	if jm.SortByRelevance {
+		sort = append(sort, bson.E{Key: RELEVANCE_SCORE_FIELD, Value: bson.D{{"$meta", "textScore"}}})
+	}
+	for _, c := range effectiveSort(fm, jm) {
+		sortCondition := 1
+		if c.desc {
 			sortCondition = -1
-			break
-		default:
-			sortCondition = 1
 		}
-		sort = append(sort, bson.E{Key: fm.SortingFields[sortInput.Field], Value: sortCondition})
+		sort = append(sort, bson.E{Key: c.column, Value: sortCondition})
 	}
 	if sort != nil {
 		return &sort
@@ -96,76 +215,103 @@ func getMongoSortCondition(fm *FieldsMap, jm *JsonMap) *bson.D {
 }
 
 func addMongoSearchFilter(filterArr bson.A, jm *JsonMap, fm *FieldsMap) bson.A {
-	for key, values := range jm.Search {
+	for _, key := range sortedKeys(jm.Search) {
+		if searchMode(fm, key) == SEARCH_MODE_FULL_TEXT {
+			continue
+		}
 		var orFilters bson.A
-		for _, value := range values {
-			orFilters = append(orFilters, bson.D{
-				{fm.SearchFields[key], primitive.Regex{Pattern: fmt.Sprintf("%v", value), Options: "i"}},
-			})
+		for _, value := range jm.Search[key] {
+			orFilters = append(orFilters, mongoSearchPredicate(fm, key, value))
+		}
+		operator := "$or"
+		if jm.matchesAllSearch(key) {
+			operator = "$and"
 		}
 		if orFilters != nil {
-			filterArr = append(filterArr, bson.D{{"$or", orFilters}})
+			filterArr = append(filterArr, bson.D{{operator, orFilters}})
 		}
 	}
-	return filterArr
+	for _, key := range sortedKeys(jm.SearchExclude) {
+		var excluded bson.A
+		for _, value := range jm.SearchExclude[key] {
+			excluded = append(excluded, mongoSearchPredicate(fm, key, value))
+		}
+		if excluded != nil {
+			filterArr = append(filterArr, bson.D{{"$nor", excluded}})
+		}
+	}
+	if textSearch := mongoTextSearch(fm, jm); textSearch != nil {
+		filterArr = append(filterArr, textSearch)
+	}
+	return append(filterArr, mongoGlobalSearch(fm, jm)...)
 }
 
 func addMongoConditionFilter(condArr bson.A, jm *JsonMap, fm *FieldsMap) bson.A {
+	for _, k := range sortedKeys(jm.Conditions) {
+		condArr = append(condArr, mongoConditionPredicates(fm, k, jm.Conditions[k])...)
+	}
+	return condArr
+}
+
+func mongoConditionPredicates(fm *FieldsMap, k string, v ConditionOperators) bson.A {
+	var condArr bson.A
 	var condition bson.D
-	for k, v := range jm.Conditions {
-		if v.ValuesToExactMatch != nil {
-			if isDateTimeFieldKey(k, fm.DateTimeFieldKeys) {
-				for _, value := range v.ValuesToExactMatch {
-					value = parseDateTimeOrReturn(value)
-				}
+	if v.ValuesToExactMatch != nil {
+		values := v.ValuesToExactMatch
+		if isDateTimeFieldKey(k, fm.DateTimeFieldKeys) {
+			values = make([]interface{}, len(v.ValuesToExactMatch))
+			for i, value := range v.ValuesToExactMatch {
+				values[i] = parseDateTimeOrReturn(value)
 			}
-			condition = bson.D{{fm.ConditionFields[k], bson.D{{"$in", v.ValuesToExactMatch}}}}
-
-			condArr = append(condArr, condition)
 		}
-		if v.GreaterOrEqual != nil {
+		condition = bson.D{{fm.ConditionFields[k], bson.D{{"$in", values}}}}
 
-			v.GreaterOrEqual = treatDateTime(k, fm.DateTimeFieldKeys, v.GreaterOrEqual)
+		condArr = append(condArr, condition)
+	}
+	if v.GreaterOrEqual != nil {
 
-			condition = bson.D{{fm.ConditionFields[k], bson.D{{"$gte", v.GreaterOrEqual}}}}
+		v.GreaterOrEqual = treatDateTime(k, fm.DateTimeFieldKeys, v.GreaterOrEqual)
 
-			condArr = append(condArr, condition)
-		}
-		if v.GreaterThan != nil {
+		condition = bson.D{{fm.ConditionFields[k], bson.D{{"$gte", v.GreaterOrEqual}}}}
 
-			v.GreaterThan = treatDateTime(k, fm.DateTimeFieldKeys, v.GreaterThan)
+		condArr = append(condArr, condition)
+	}
+	if v.GreaterThan != nil {
 
-			condition = bson.D{{fm.ConditionFields[k], bson.D{{"$gt", v.GreaterThan}}}}
+		v.GreaterThan = treatDateTime(k, fm.DateTimeFieldKeys, v.GreaterThan)
 
-			condArr = append(condArr, condition)
-		}
-		if v.LowerOrEqual != nil {
+		condition = bson.D{{fm.ConditionFields[k], bson.D{{"$gt", v.GreaterThan}}}}
+
+		condArr = append(condArr, condition)
+	}
+	if v.LowerOrEqual != nil {
 
-			v.LowerOrEqual = treatDateTime(k, fm.DateTimeFieldKeys, v.LowerOrEqual)
+		v.LowerOrEqual = treatDateTime(k, fm.DateTimeFieldKeys, v.LowerOrEqual)
 
-			condition = bson.D{{fm.ConditionFields[k], bson.D{{"$lte", v.LowerOrEqual}}}}
+		condition = bson.D{{fm.ConditionFields[k], bson.D{{"$lte", v.LowerOrEqual}}}}
 
-			condArr = append(condArr, condition)
-		}
-		if v.LowerThan != nil {
+		condArr = append(condArr, condition)
+	}
+	if v.LowerThan != nil {
 
-			v.LowerThan = treatDateTime(k, fm.DateTimeFieldKeys, v.LowerThan)
+		v.LowerThan = treatDateTime(k, fm.DateTimeFieldKeys, v.LowerThan)
 
-			condition = bson.D{{fm.ConditionFields[k], bson.D{{"$lt", v.LowerThan}}}}
+		condition = bson.D{{fm.ConditionFields[k], bson.D{{"$lt", v.LowerThan}}}}
 
-			condArr = append(condArr, condition)
-		}
-		if v.ValuesToExclude != nil {
-			if isDateTimeFieldKey(k, fm.DateTimeFieldKeys) {
-				for _, item := range v.ValuesToExclude {
-					item = parseDateTimeOrReturn(item)
-				}
+		condArr = append(condArr, condition)
+	}
+	if v.ValuesToExclude != nil {
+		values := v.ValuesToExclude
+		if isDateTimeFieldKey(k, fm.DateTimeFieldKeys) {
+			values = make([]interface{}, len(v.ValuesToExclude))
+			for i, item := range v.ValuesToExclude {
+				values[i] = parseDateTimeOrReturn(item)
 			}
+		}
 
-			condition = bson.D{{fm.ConditionFields[k], bson.D{{"$nin", v.ValuesToExclude}}}}
+		condition = bson.D{{fm.ConditionFields[k], bson.D{{"$nin", values}}}}
 
-			condArr = append(condArr, condition)
-		}
+		condArr = append(condArr, condition)
 	}
 	return condArr
 }
@@ -174,14 +320,23 @@ func addMongoConditionFilter(condArr bson.A, jm *JsonMap, fm *FieldsMap) bson.A
 type SqlQuery struct {
 	Select  string        // Fields to select in the SQL query.
 	Where   string        // Filter conditions for the SQL query.
+	Seek    string        // Keyset conditions selecting the rows after the cursor, empty without a cursor.
+	GroupBy string        // GROUP BY clause of the aggregations, empty without aggregations or groups.
 	OrderBy string        // Sorting criteria for the SQL query.
-	Limit   string        // Maximum number of rows to return.
-	Offset  string        // Number of rows to skip.
-	Args    []interface{} // Arguments for the query's placeholders.
+	Limit   string        // Maximum number of rows to return, in the dialect's syntax.
+	Offset  string        // Number of rows to skip, in the dialect's syntax.
+	Args    []interface{} // Arguments for the query's placeholders, the ones of Where first.
+
+	dialect       *Dialect
+	aggregated    bool
+	limit         int64
+	offset        int64
+	whereArgCount int
 }
 
 // NewSqlQuery creates a new SqlQuery based on the provided FieldsMap and JsonMap.
 // It sets up the select fields, where conditions, sorting, limit, and offset for the query.
+// The query is built with GenericDialect, see NewSqlQueryWithDialect for other engines.
 //
 // Example usage:
 //
@@ -192,43 +347,125 @@ type SqlQuery struct {
 // Returns:
 // - *SqlQuery: A pointer to the initialized SqlQuery struct.
 func (jm *JsonMap) NewSqlQuery(fm *FieldsMap) *SqlQuery {
+	return jm.NewSqlQueryWithDialect(fm, GenericDialect)
+}
+
+// NewSqlQueryWithDialect creates a new SqlQuery like NewSqlQuery, writing placeholders,
+// paging clauses and literals in the syntax of the given dialect.
+//
+// Example usage:
+//
+//	fm := &tesoql.FieldsMap{ /* field mappings */ }
+//	jm := &tesoql.JsonMap{ /* JSON input */ }
+//	query := jm.NewSqlQueryWithDialect(fm, tesoql.PostgresDialect)
+//
+// Returns:
+// - *SqlQuery: A pointer to the initialized SqlQuery struct.
+func (jm *JsonMap) NewSqlQueryWithDialect(fm *FieldsMap, dialect *Dialect) *SqlQuery {
+	dialect = dialectOrGeneric(dialect)
+	args := newSqlArgs(dialect)
+
 	query := new(SqlQuery)
-	query.Select = getSqlProjection(fm, jm)
-	query.Where, query.Args = getSqlFilter(fm, jm)
-	query.OrderBy = getSqlSortCondition(fm, jm)
-	query.Limit = fmt.Sprintf("LIMIT %d", jm.Pagination.Limit)
-	query.Offset = fmt.Sprintf("OFFSET %d", jm.Pagination.Offset)
+	query.dialect = dialect
+	if jm.Aggregations != nil {
+		query.Select, query.GroupBy = getSqlAggregation(fm, jm, dialect)
+		query.aggregated = true
+	} else {
+		query.Select = getSqlProjection(fm, jm, dialect)
+	}
+	query.Where = getSqlFilter(fm, jm, args)
+	query.whereArgCount = len(args.values)
+	query.Seek = getSqlSeekCondition(fm, jm, args)
+	query.OrderBy = getSqlSortCondition(fm, jm, args)
+	query.limit = jm.Pagination.Limit
+	query.offset = jm.Pagination.Offset
+	if jm.keyset != nil {
+		query.offset = 0
+	}
+	query.Limit, query.Offset = dialect.pagingClauses(query.limit, query.offset)
+	query.Args = dialect.bindArgs(args.values)
 	return query
 }
 
-func getSqlProjection(fm *FieldsMap, jm *JsonMap) string {
+func getSqlProjection(fm *FieldsMap, jm *JsonMap, d *Dialect) string {
 	if len(jm.ProjectionFields) > 0 {
 		var fields []string
 		for _, field := range jm.ProjectionFields {
 			if value, exists := fm.ProjectionFields[field]; exists {
-				fields = append(fields, value)
+				if isRawExpression(value) {
+					fields = append(fields, fmt.Sprintf("%s AS %s", d.quoteIdentifier(value), d.quoteIdentifier(field)))
+					continue
+				}
+				fields = append(fields, d.quoteIdentifier(value))
 			}
 		}
+		// the sort columns are needed to build the next cursor
+		for _, column := range keysetProjection(fm, jm) {
+			fields = append(fields, d.quoteIdentifier(column))
+		}
 		return strings.Join(fields, ", ")
 	}
 	return "*"
 }
 
-func getSqlFilter(fm *FieldsMap, jm *JsonMap) (string, []interface{}) {
+func getSqlFilter(fm *FieldsMap, jm *JsonMap, args *sqlArgs) string {
 	var conditions []string
-	var args []interface{}
 
-	conditions, args = addSqlSearchFilter(fm, jm, conditions, args)
+	conditions = addSqlSearchFilter(fm, jm, conditions, args)
+
+	conditions = addSqlConditionFilters(fm, jm, conditions, args)
 
-	conditions, args = addSqlConditionFilters(fm, jm, conditions, args)
+	if jm.Filter != nil {
+		conditions = append(conditions, getSqlFilterNode(fm, jm.Filter, args))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+func getSqlFilterNode(fm *FieldsMap, node *Filter, args *sqlArgs) string {
+	switch {
+	case node.And != nil:
+		return getSqlFilterNodes(fm, node.And, " AND ", "1=1", args)
+	case node.Or != nil:
+		return getSqlFilterNodes(fm, node.Or, " OR ", "1=0", args)
+	case node.Not != nil:
+		return fmt.Sprintf("NOT %s", getSqlFilterNode(fm, node.Not, args))
+	case node.Operators != nil:
+		column := args.dialect.quoteIdentifier(fm.ConditionFields[node.Field])
+		if predicates := sqlConditionPredicates(column, *node.Operators, args); len(predicates) > 0 {
+			return fmt.Sprintf("(%s)", strings.Join(predicates, " AND "))
+		}
+	}
+	return "(1=1)"
+}
 
-	return strings.Join(conditions, " AND "), args
+// getSqlFilterNodes joins the translated child nodes of a group with the operator,
+// an empty group is replaced by its neutral element.
+func getSqlFilterNodes(fm *FieldsMap, nodes []Filter, operator string, neutral string, args *sqlArgs) string {
+	var predicates []string
+	for i := range nodes {
+		predicates = append(predicates, getSqlFilterNode(fm, &nodes[i], args))
+	}
+	if len(predicates) == 0 {
+		predicates = append(predicates, neutral)
+	}
+	return fmt.Sprintf("(%s)", strings.Join(predicates, operator))
 }
 
-func getSqlSortCondition(fm *FieldsMap, jm *JsonMap) string {
+func getSqlSortCondition(fm *FieldsMap, jm *JsonMap, args *sqlArgs) string {
+	d := args.dialect
 	var orderBy []string
-	for _, sortInput := range jm.SortConditions {
-		orderBy = append(orderBy, fmt.Sprintf("%s %s", fm.SortingFields[sortInput.Field], sortInput.SortCondition))
+	if jm.SortByRelevance {
+		if relevance := sqlRelevanceOrder(fm, jm, args); relevance != "" {
+			orderBy = append(orderBy, relevance)
+		}
+	}
+	for _, c := range effectiveSort(fm, jm) {
+		direction := "ASC"
+		if c.desc {
+			direction = "DESC"
+		}
+		orderBy = append(orderBy, fmt.Sprintf("%s %s", d.quoteIdentifier(c.column), direction))
 	}
 	if len(orderBy) > 0 {
 		return fmt.Sprintf(" ORDER BY %s", strings.Join(orderBy, ", "))
@@ -236,65 +473,136 @@ func getSqlSortCondition(fm *FieldsMap, jm *JsonMap) string {
 	return ""
 }
 
-func addSqlSearchFilter(fm *FieldsMap, jm *JsonMap, conditions []string, args []interface{}) ([]string, []interface{}) {
-	for key, values := range jm.Search {
+func addSqlSearchFilter(fm *FieldsMap, jm *JsonMap, conditions []string, args *sqlArgs) []string {
+	for _, key := range sortedKeys(jm.Search) {
 		var orConditions []string
-		for _, value := range values {
-			orConditions = append(orConditions, fmt.Sprintf("%s LIKE ?", fm.SearchFields[key]))
-			args = append(args, fmt.Sprintf("%%%v%%", value))
+		column := args.dialect.quoteIdentifier(fm.SearchFields[key])
+		if len(jm.Search[key]) > 0 && searchMode(fm, key) == SEARCH_MODE_FULL_TEXT && args.dialect.FullTextStyle != "" {
+			conditions = append(conditions, sqlFullTextPredicate(column, jm.Search[key], args))
+			continue
+		}
+		for _, value := range jm.Search[key] {
+			orConditions = append(orConditions, sqlSearchPredicate(fm, key, column, value, args))
+		}
+		operator := " OR "
+		if jm.matchesAllSearch(key) {
+			operator = " AND "
 		}
 		if orConditions != nil {
-			conditions = append(conditions, fmt.Sprintf("(%s)", strings.Join(orConditions, " OR ")))
+			conditions = append(conditions, fmt.Sprintf("(%s)", strings.Join(orConditions, operator)))
+		}
+	}
+	for _, key := range sortedKeys(jm.SearchExclude) {
+		column := args.dialect.quoteIdentifier(fm.SearchFields[key])
+		for _, value := range jm.SearchExclude[key] {
+			conditions = append(conditions, sqlSearchExclusion(fm, key, column, value, args))
 		}
 	}
-	return conditions, args
+	return append(conditions, sqlGlobalSearch(fm, jm, args)...)
 }
 
-func addSqlConditionFilters(fm *FieldsMap, jm *JsonMap, conditions []string, args []interface{}) ([]string, []interface{}) {
-	for key, condition := range jm.Conditions {
-		if condition.ValuesToExactMatch != nil && len(condition.ValuesToExactMatch) > 0 {
-			placeholders := make([]string, len(condition.ValuesToExactMatch))
-			for i, value := range condition.ValuesToExactMatch {
-				placeholders[i] = "?"
-				args = append(args, value)
-			}
-			conditions = append(conditions, fmt.Sprintf("%s IN (%s)", fm.ConditionFields[key], strings.Join(placeholders, ", ")))
-		}
-		if condition.GreaterOrEqual != nil {
-			conditions = append(conditions, fmt.Sprintf("%s >= ?", fm.ConditionFields[key]))
-			args = append(args, condition.GreaterOrEqual)
-		}
-		if condition.GreaterThan != nil {
-			conditions = append(conditions, fmt.Sprintf("%s > ?", fm.ConditionFields[key]))
-			args = append(args, condition.GreaterThan)
-		}
-		if condition.LowerOrEqual != nil {
-			conditions = append(conditions, fmt.Sprintf("%s <= ?", fm.ConditionFields[key]))
-			args = append(args, condition.LowerOrEqual)
+func addSqlConditionFilters(fm *FieldsMap, jm *JsonMap, conditions []string, args *sqlArgs) []string {
+	for _, key := range sortedKeys(jm.Conditions) {
+		column := args.dialect.quoteIdentifier(fm.ConditionFields[key])
+		conditions = append(conditions, sqlConditionPredicates(column, jm.Conditions[key], args)...)
+	}
+	return conditions
+}
+
+func sqlConditionPredicates(column string, condition ConditionOperators, args *sqlArgs) []string {
+	var conditions []string
+	if condition.ValuesToExactMatch != nil && len(condition.ValuesToExactMatch) > 0 {
+		placeholders := make([]string, len(condition.ValuesToExactMatch))
+		for i, value := range condition.ValuesToExactMatch {
+			placeholders[i] = args.bind(value)
 		}
-		if condition.LowerThan != nil {
-			conditions = append(conditions, fmt.Sprintf("%s < ?", fm.ConditionFields[key]))
-			args = append(args, condition.LowerThan)
+		conditions = append(conditions, fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")))
+	}
+	if condition.GreaterOrEqual != nil {
+		conditions = append(conditions, fmt.Sprintf("%s >= %s", column, args.bind(condition.GreaterOrEqual)))
+	}
+	if condition.GreaterThan != nil {
+		conditions = append(conditions, fmt.Sprintf("%s > %s", column, args.bind(condition.GreaterThan)))
+	}
+	if condition.LowerOrEqual != nil {
+		conditions = append(conditions, fmt.Sprintf("%s <= %s", column, args.bind(condition.LowerOrEqual)))
+	}
+	if condition.LowerThan != nil {
+		conditions = append(conditions, fmt.Sprintf("%s < %s", column, args.bind(condition.LowerThan)))
+	}
+	if condition.ValuesToExclude != nil && len(condition.ValuesToExclude) > 0 {
+		placeholders := make([]string, len(condition.ValuesToExclude))
+		for i, value := range condition.ValuesToExclude {
+			placeholders[i] = args.bind(value)
 		}
-		if condition.ValuesToExclude != nil && len(condition.ValuesToExclude) > 0 {
-			placeholders := make([]string, len(condition.ValuesToExclude))
-			for i, value := range condition.ValuesToExclude {
-				placeholders[i] = "?"
-				args = append(args, value)
-			}
-			conditions = append(conditions, fmt.Sprintf("%s NOT IN (%s)", fm.ConditionFields[key], strings.Join(placeholders, ", ")))
+		conditions = append(conditions, fmt.Sprintf("%s NOT IN (%s)", column, strings.Join(placeholders, ", ")))
+	}
+	return conditions
+}
+
+// CountQuery returns the statement counting every row that matches the query's filter,
+// ignoring its ordering, paging and cursor, along with the arguments of the statement.
+// With aggregations, it counts the groups.
+//
+// Example usage:
+//
+//	query := jm.NewSqlQueryWithDialect(fm, tesoql.PostgresDialect)
+//	countQuery, countArgs := query.CountQuery("orders")
+//	row := db.QueryRow(countQuery, countArgs...)
+//
+// Returns:
+//
+// - string: The count statement.
+//
+// - []interface{}: The arguments for the statement's placeholders.
+func (q *SqlQuery) CountQuery(tableName string) (string, []interface{}) {
+	if q.aggregated {
+		return fmt.Sprintf("SELECT COUNT(*) FROM (SELECT COUNT(*) AS tesoql_group_size %s%s) tesoql_groups", q.fromWhere(tableName, false), q.GroupBy), q.Args[:q.whereArgCount]
+	}
+	return fmt.Sprintf("SELECT COUNT(*) %s", q.fromWhere(tableName, false)), q.Args[:q.whereArgCount]
+}
+
+func (q *SqlQuery) fromWhere(tableName string, withSeek bool) string {
+	fromWhere := fmt.Sprintf("FROM %s WHERE 1=1", dialectOrGeneric(q.dialect).quoteIdentifier(tableName)) + q.whereClause()
+	if withSeek && q.Seek != "" {
+		fromWhere += fmt.Sprintf(" AND %s", q.Seek)
+	}
+	return fromWhere
+}
+
+func (q *SqlQuery) whereClause() string {
+	if q.Where != "" {
+		return fmt.Sprintf(" AND %s", q.Where)
+	}
+	return ""
+}
+
+// statement assembles the select statement of the query. With windowCount the total
+// number of matching rows is selected into TOTAL_COUNT_COLUMN of every row.
+func (q *SqlQuery) statement(tableName string, windowCount bool) string {
+	d := dialectOrGeneric(q.dialect)
+	selectClause := q.Select
+	if windowCount {
+		if selectClause == "*" {
+			selectClause = d.quoteIdentifier(tableName) + ".*"
 		}
+		selectClause += fmt.Sprintf(", COUNT(*) OVER() AS %s", TOTAL_COUNT_COLUMN)
 	}
-	return conditions, args
+	return d.paginate(selectClause, q.fromWhere(tableName, true)+q.GroupBy, q.OrderBy, q.limit, q.offset)
 }
 
 // GetSqlQuery generates a full SQL query string, including the select, where, order by, limit, and offset clauses.
 // It also returns the where clause and the arguments for the query.
+// The query is built with GenericDialect, see GetSqlQueryWithDialect for other engines.
 //
 // The reason for returning the where clause and arguments separately is to prevent SQL injection attacks.
 // By using placeholders in the query and passing the arguments separately, it is ensured that user input
 // is properly sanitized and doesn't lead to security vulnerabilities.
 //
+// The where clause only holds the filter conditions (" AND ..."), without ordering, paging and
+// cursor conditions. To count the matching rows, prefer SqlQuery.CountQuery which also
+// returns the matching arguments.
+//
 // Example usage:
 //
 //	fm := &tesoql.FieldsMap{ /* field mappings */ }
@@ -309,21 +617,33 @@ func addSqlConditionFilters(fm *FieldsMap, jm *JsonMap, conditions []string, arg
 //
 // - []interface{}: The arguments for the query's placeholders.
 func (jm *JsonMap) GetSqlQuery(fieldsMap *FieldsMap, tableName string, printQuery bool) (string, string, []interface{}) {
+	return jm.GetSqlQueryWithDialect(fieldsMap, tableName, GenericDialect, printQuery)
+}
 
-	query := jm.NewSqlQuery(fieldsMap)
-
-	sqlQuery := fmt.Sprintf("SELECT %s FROM %s WHERE 1=1", query.Select, tableName)
+// GetSqlQueryWithDialect generates a full SQL query string like GetSqlQuery, in the syntax of the given dialect.
+//
+// Example usage:
+//
+//	fm := &tesoql.FieldsMap{ /* field mappings */ }
+//	jm := &tesoql.JsonMap{ /* JSON input */ }
+//	sqlQuery, whereClause, args := jm.GetSqlQueryWithDialect(fm, "table_name", tesoql.SqlServerDialect, true)
+//
+// Returns:
+//
+// - string: The full SQL query string.
+//
+// - string: The where clause of the SQL query.
+//
+// - []interface{}: The arguments for the query's placeholders.
+func (jm *JsonMap) GetSqlQueryWithDialect(fieldsMap *FieldsMap, tableName string, dialect *Dialect, printQuery bool) (string, string, []interface{}) {
 
-	var whereClause string
+	query := jm.NewSqlQueryWithDialect(fieldsMap, dialect)
 
-	if query.Where != "" {
-		whereClause = fmt.Sprintf(" AND %s", query.Where)
-	}
-	whereClause += fmt.Sprintf("%s %s %s", query.OrderBy, query.Limit, query.Offset)
+	sqlQuery := query.statement(tableName, false)
 
 	if printQuery {
-		fmt.Printf("Query: %s\nWith Arguments: %v\n", sqlQuery+whereClause, query.Args)
+		fmt.Printf("Query: %s\nWith Arguments: %v\n", sqlQuery, query.Args)
 	}
 
-	return sqlQuery + whereClause, whereClause, query.Args
+	return sqlQuery, query.whereClause(), query.Args
 }
@@ -0,0 +1,143 @@
+package tesoql
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCountQuery(t *testing.T) {
+	fm := &FieldsMap{
+		SortingFields:   map[string]string{"id": "id"},
+		ConditionFields: map[string]string{"amount": "amount"},
+		TiebreakerField: "id",
+	}
+	tests := []struct {
+		name      string
+		keyset    []interface{}
+		count     string
+		countArgs []interface{}
+		statement string
+		args      []interface{}
+	}{
+		{
+			name:      "offset page",
+			count:     `SELECT COUNT(*) FROM "orders" WHERE 1=1 AND "amount" >= $1`,
+			countArgs: []interface{}{10},
+			statement: `SELECT "orders".*, COUNT(*) OVER() AS tesoql_total_count FROM "orders" WHERE 1=1 AND "amount" >= $1 ORDER BY "id" ASC LIMIT 5 OFFSET 0`,
+			args:      []interface{}{10},
+		},
+		{
+			name:      "cursor page counts without the seek predicate",
+			keyset:    []interface{}{int64(42)},
+			count:     `SELECT COUNT(*) FROM "orders" WHERE 1=1 AND "amount" >= $1`,
+			countArgs: []interface{}{10},
+			statement: `SELECT "orders".*, COUNT(*) OVER() AS tesoql_total_count FROM "orders" WHERE 1=1 AND "amount" >= $1 AND (("id" > $2)) ORDER BY "id" ASC LIMIT 5 OFFSET 0`,
+			args:      []interface{}{10, int64(42)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jm := &JsonMap{
+				Conditions: map[string]ConditionOperators{"amount": {GreaterOrEqual: 10}},
+				Pagination: Pagination{Limit: 5},
+				keyset:     tt.keyset,
+			}
+			query := jm.NewSqlQueryWithDialect(fm, PostgresDialect)
+			count, countArgs := query.CountQuery("orders")
+			if count != tt.count {
+				t.Errorf("count = %s\nwant    %s", count, tt.count)
+			}
+			if !reflect.DeepEqual(countArgs, tt.countArgs) {
+				t.Errorf("count args = %v, want %v", countArgs, tt.countArgs)
+			}
+			if statement := query.statement("orders", true); statement != tt.statement {
+				t.Errorf("statement = %s\nwant        %s", statement, tt.statement)
+			}
+			if !reflect.DeepEqual(query.Args, tt.args) {
+				t.Errorf("args = %v, want %v", query.Args, tt.args)
+			}
+		})
+	}
+}
+
+func TestStatementWindowCountKeepsProjection(t *testing.T) {
+	fm := &FieldsMap{ProjectionFields: map[string]string{"id": "id"}}
+	jm := &JsonMap{ProjectionFields: []string{"id"}, Pagination: Pagination{Limit: 5}}
+	want := "SELECT `id`, COUNT(*) OVER() AS tesoql_total_count FROM `orders` WHERE 1=1 LIMIT 5 OFFSET 0"
+	if statement := jm.NewSqlQueryWithDialect(fm, MySqlDialect).statement("orders", true); statement != want {
+		t.Errorf("statement = %s\nwant        %s", statement, want)
+	}
+}
+
+// mongoJSON renders a bson value as relaxed extended JSON, to compare filters and
+// pipelines regardless of their Go types.
+func mongoJSON(t *testing.T, value interface{}) string {
+	t.Helper()
+	encoded, err := bson.MarshalExtJSON(bson.D{{"v", value}}, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(encoded)
+}
+
+func TestSqlFilterTree(t *testing.T) {
+	fm := &FieldsMap{ConditionFields: map[string]string{"status": "status", "amount": "amount", "quantity": "quantity"}}
+	jm := &JsonMap{
+		Conditions: map[string]ConditionOperators{"status": {ValuesToExclude: []interface{}{"cancelled"}}},
+		Filter: &Filter{Or: []Filter{
+			{Field: "status", Operators: &ConditionOperators{ValuesToExactMatch: []interface{}{"shipped"}}},
+			{And: []Filter{
+				{Field: "amount", Operators: &ConditionOperators{GreaterThan: 100}},
+				{Not: &Filter{Field: "quantity", Operators: &ConditionOperators{LowerThan: 5}}},
+			}},
+		}},
+	}
+	_, where, args := jm.GetSqlQueryWithDialect(fm, "orders", PostgresDialect, false)
+	want := ` AND "status" NOT IN ($1) AND (("status" IN ($2)) OR (("amount" > $3) AND NOT ("quantity" < $4)))`
+	if where != want {
+		t.Errorf("where = %s\nwant    %s", where, want)
+	}
+	if wantArgs := []interface{}{"cancelled", "shipped", 100, 5}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestMongoFilterTree(t *testing.T) {
+	fm := &FieldsMap{ConditionFields: map[string]string{"status": "status", "amount": "amount", "quantity": "quantity"}}
+	tests := []struct {
+		name   string
+		filter *Filter
+		want   string
+	}{
+		{
+			name: "tree",
+			filter: &Filter{Or: []Filter{
+				{Field: "status", Operators: &ConditionOperators{ValuesToExactMatch: []interface{}{"shipped"}}},
+				{And: []Filter{
+					{Field: "amount", Operators: &ConditionOperators{GreaterThan: 100}},
+					{Not: &Filter{Field: "quantity", Operators: &ConditionOperators{LowerThan: 5}}},
+				}},
+			}},
+			want: `{"v":{"$and":[{"$or":[{"$and":[{"status":{"$in":["shipped"]}}]},{"$and":[{"$and":[{"amount":{"$gt":100}}]},{"$nor":[{"$and":[{"quantity":{"$lt":5}}]}]}]}]}]}}`,
+		},
+		{
+			name:   "empty or matches nothing",
+			filter: &Filter{Or: []Filter{}},
+			want:   `{"v":{"$and":[{"$or":[{"_id":{"$exists":false}}]}]}}`,
+		},
+		{
+			name:   "empty and matches everything",
+			filter: &Filter{And: []Filter{}},
+			want:   `{"v":{"$and":[{"$and":[{}]}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if filter := mongoJSON(t, getMongoFilter(fm, &JsonMap{Filter: tt.filter})); filter != tt.want {
+				t.Errorf("filter = %s\nwant     %s", filter, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,138 @@
 package tesoql
 
-type iTesoQlRepo interface {
-	repository(jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO)
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Repository is the backend a Service queries once the JsonMap has passed the toggle,
+// filter and cursor validations. The built-in Mongo and SQL repositories implement it,
+// and custom backends can be plugged in with RegisterEngine.
+//
+// A Repository may implement io.Closer, in which case TesoQL.Close closes it.
+//
+// Example usage:
+//
+//	type searchApiRepository struct {
+//		client *search.Client
+//	}
+//
+//	func (r *searchApiRepository) Find(ctx context.Context, jsonMap *tesoql.JsonMap) ([]map[string]interface{}, int, int, *tesoql.ErrorResponseDTO) {
+//		// Translate the JsonMap and call the search API...
+//	}
+type Repository interface {
+	// Find returns the page of records matching the JsonMap, the total count of the
+	// matching records (when JsonMap.TotalCount is set) and the size of the page.
+	Find(ctx context.Context, jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO)
+}
+
+// EngineFactory builds the Repository of an engine from the configuration. It is
+// called by Config.Build when Config.Engine is the name the factory is registered with.
+type EngineFactory func(cfg *Config) (Repository, error)
+
+var (
+	enginesMu sync.RWMutex
+	engines   = make(map[string]EngineFactory)
+)
+
+func init() {
+	RegisterEngine(MONGO_ENGINE, newMongoEngine)
+	for engine := range sqlDriverList {
+		RegisterEngine(engine, newSqlEngine)
+	}
+}
+
+// RegisterEngine makes a backend available under the given engine name, to be
+// selected with Config.Engine. The built-in Mongo and SQL engines are registered the
+// same way. It panics if the name is already registered or the factory is nil, and is
+// meant to be called from an init function.
+//
+// Example usage:
+//
+//	func init() {
+//		tesoql.RegisterEngine("search-api", func(cfg *tesoql.Config) (tesoql.Repository, error) {
+//			return &searchApiRepository{client: search.NewClient(cfg.ConnectionConfig.ConnectionString)}, nil
+//		})
+//	}
+//
+//	cfg := &tesoql.Config{Engine: "search-api", ...}
+//	tesoQL, err := cfg.Build()
+func RegisterEngine(name string, factory EngineFactory) {
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	if factory == nil {
+		panic("tesoql: RegisterEngine factory is nil")
+	}
+	if _, exists := engines[name]; exists {
+		panic(fmt.Sprintf("tesoql: RegisterEngine called twice for engine '%s'", name))
+	}
+	engines[name] = factory
+}
+
+// Engines returns the sorted names of the registered engines.
+//
+// Returns:
+//
+// - []string: The names of the registered engines.
+func Engines() []string {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func engineFactory(name string) (EngineFactory, bool) {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	factory, exists := engines[name]
+	return factory, exists
+}
+
+// validateBuiltinConfig checks the parts of the configuration the built-in engines rely on.
+func (cfg *Config) validateBuiltinConfig() *ErrorResponseDTO {
+	if err := cfg.validateIdentifiers(); err != nil {
+		return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Invalid identifier in config: %v", err), CONFIG_IDENTIFIER_ERR_CODE).withCause(err)
+	}
+	if cfg.ConnectionConfig == nil {
+		return newResponse(TESOQL_CONFIG_ERROR, "ConnectionConfig is not provided!", CONFIG_CONNECTION_ERR_CODE)
+	}
+	if cfg.FieldsMap != nil {
+		for _, alias := range sortedKeys(cfg.FieldsMap.FieldTypes) {
+			if !isColumnKind(cfg.FieldsMap.FieldTypes[alias]) {
+				return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Unknown column kind '%s' for field '%s'.", cfg.FieldsMap.FieldTypes[alias], alias), CONFIG_FIELD_TYPE_ERR_CODE).withField(alias)
+			}
+		}
+	}
+	if err := cfg.validateSearchModes(); err != nil {
+		return err
+	}
+	return cfg.validateSearchLocale()
+}
+
+func newMongoEngine(cfg *Config) (Repository, error) {
+	if err := cfg.validateBuiltinConfig(); err != nil {
+		return nil, err
+	}
+	repo, err := newMongoRepository(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func newSqlEngine(cfg *Config) (Repository, error) {
+	if err := cfg.validateBuiltinConfig(); err != nil {
+		return nil, err
+	}
+	repo, err := newSqlRepository(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
 }
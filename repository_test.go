@@ -0,0 +1,102 @@
+package tesoql
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+)
+
+// closingRepository is a Repository of a registered engine, that records being closed.
+type closingRepository struct {
+	closed bool
+}
+
+func (r *closingRepository) Find(ctx context.Context, jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO) {
+	return []map[string]interface{}{{"id": 1}}, 1, 1, nil
+}
+
+func (r *closingRepository) Close() error {
+	r.closed = true
+	return nil
+}
+
+var (
+	registryRepository = &closingRepository{}
+	registryFactoryErr = errors.New("search api is not reachable")
+)
+
+func init() {
+	RegisterEngine("test-registry", func(cfg *Config) (Repository, error) { return registryRepository, nil })
+	RegisterEngine("test-registry-error", func(cfg *Config) (Repository, error) { return nil, registryFactoryErr })
+	RegisterEngine("test-registry-dto", func(cfg *Config) (Repository, error) {
+		return nil, newResponse(TESOQL_CONFIG_ERROR, "ConnectionConfig is not provided!", CONFIG_CONNECTION_ERR_CODE)
+	})
+	RegisterEngine("test-registry-nil", func(cfg *Config) (Repository, error) { return nil, nil })
+}
+
+func TestRegisteredEngine(t *testing.T) {
+	tesoQL, err := (&Config{Engine: "test-registry"}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, _, size, errDTO := tesoQL.Service.Get(&JsonMap{})
+	if errDTO != nil || size != 1 || len(records) != 1 {
+		t.Errorf("Get() = %v, %d, %v", records, size, errDTO)
+	}
+	if err := tesoQL.Close(); err != nil || !registryRepository.closed {
+		t.Errorf("Close() = %v, closed %t", err, registryRepository.closed)
+	}
+}
+
+func TestRegisteredEngineErrors(t *testing.T) {
+	tests := []struct {
+		engine string
+		target error
+	}{
+		{engine: "test-registry-error", target: ErrConfigEngine},
+		{engine: "test-registry-error", target: registryFactoryErr},
+		{engine: "test-registry-dto", target: ErrConfigConnection},
+		{engine: "test-registry-nil", target: ErrConfigEngine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.engine, func(t *testing.T) {
+			if _, err := (&Config{Engine: tt.engine}).Build(); !errors.Is(err, tt.target) {
+				t.Errorf("Build() error = %v, want %v", err, tt.target)
+			}
+		})
+	}
+}
+
+func TestRegisterEnginePanics(t *testing.T) {
+	tests := []struct {
+		name    string
+		engine  string
+		factory EngineFactory
+	}{
+		{name: "nil factory", engine: "test-registry-unused", factory: nil},
+		{name: "duplicate", engine: POSTGRES_ENGINE, factory: newSqlEngine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recovered := recover(); recovered == nil {
+					t.Error("RegisterEngine() did not panic")
+				}
+			}()
+			RegisterEngine(tt.engine, tt.factory)
+		})
+	}
+}
+
+func TestEngines(t *testing.T) {
+	engines := Engines()
+	if !sort.StringsAreSorted(engines) {
+		t.Errorf("Engines() = %v, want sorted names", engines)
+	}
+	for _, engine := range []string{MONGO_ENGINE, POSTGRES_ENGINE, "test-registry"} {
+		if index := sort.SearchStrings(engines, engine); index == len(engines) || engines[index] != engine {
+			t.Errorf("Engines() = %v, want %s", engines, engine)
+		}
+	}
+}
@@ -0,0 +1,540 @@
+package tesoql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// searchMode returns the search mode of a search field, SEARCH_MODE_CONTAINS by default.
+func searchMode(fm *FieldsMap, key string) string {
+	if mode := fm.SearchModes[key]; mode != "" {
+		return mode
+	}
+	return SEARCH_MODE_CONTAINS
+}
+
+// hasFullTextSearch reports whether the JsonMap searches a full-text field.
+func (jm *JsonMap) hasFullTextSearch(fm *FieldsMap) bool {
+	if fm == nil {
+		return false
+	}
+	for key, values := range jm.Search {
+		if len(values) > 0 && searchMode(fm, key) == SEARCH_MODE_FULL_TEXT {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRelevance checks that sorting by relevance is requested along with a full-text
+// search, and neither with aggregations nor with cursor pagination, whose cursors cannot
+// hold the relevance score.
+//
+// Returns:
+//
+// - *ErrorResponseDTO: An error response if validation fails, or nil if validation passes.
+func (jm *JsonMap) validateRelevance(fm *FieldsMap) *ErrorResponseDTO {
+	if !jm.SortByRelevance {
+		return nil
+	}
+	if !jm.hasFullTextSearch(fm) {
+		return newResponse(TESOQL_VALIDATION_ERROR, "Sorting by relevance requires a search on a full-text field.", SORTABLE_ERR_CODE).withField("sortByRelevance")
+	}
+	if jm.Aggregations != nil {
+		return newResponse(TESOQL_VALIDATION_ERROR, "Sorting by relevance cannot be combined with aggregations.", SORTABLE_ERR_CODE).withField("sortByRelevance")
+	}
+	if jm.Pagination.Cursor != "" {
+		return newResponse(TESOQL_VALIDATION_ERROR, "Sorting by relevance cannot be combined with cursor pagination.", SORTABLE_ERR_CODE).withField("sortByRelevance")
+	}
+	return nil
+}
+
+// validateSearchModes checks that the search modes of the FieldsMap are known and set on
+// search fields, and that the dialect of SQL engines supports full-text and regex search.
+func (cfg *Config) validateSearchModes() *ErrorResponseDTO {
+	if cfg.FieldsMap == nil {
+		return nil
+	}
+	for _, key := range sortedKeys(cfg.FieldsMap.CaseSensitiveSearch) {
+		if _, exists := cfg.FieldsMap.SearchFields[key]; !exists {
+			return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Case-sensitive search of '%s' is set, but it is not a search field.", key), CONFIG_SEARCH_MODE_ERR_CODE).withField("FieldsMap.CaseSensitiveSearch." + key)
+		}
+	}
+	for i, key := range cfg.FieldsMap.GlobalSearchFields {
+		path := fmt.Sprintf("FieldsMap.GlobalSearchFields.%d", i)
+		if _, exists := cfg.FieldsMap.SearchFields[key]; !exists {
+			return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Global search field '%s' is not a search field.", key), CONFIG_SEARCH_MODE_ERR_CODE).withField(path)
+		}
+		if mode := searchMode(cfg.FieldsMap, key); mode == SEARCH_MODE_REGEX || mode == SEARCH_MODE_FULL_TEXT {
+			return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Global search field '%s' cannot be searched in '%s' mode.", key, mode), CONFIG_SEARCH_MODE_ERR_CODE).withField(path)
+		}
+	}
+	for _, key := range sortedKeys(cfg.FieldsMap.SearchModes) {
+		path := "FieldsMap.SearchModes." + key
+		if _, exists := cfg.FieldsMap.SearchFields[key]; !exists {
+			return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Search mode of '%s' is set, but it is not a search field.", key), CONFIG_SEARCH_MODE_ERR_CODE).withField(path)
+		}
+		switch mode := cfg.FieldsMap.SearchModes[key]; mode {
+		case SEARCH_MODE_CONTAINS, SEARCH_MODE_PREFIX, SEARCH_MODE_SUFFIX, SEARCH_MODE_EXACT:
+		case SEARCH_MODE_REGEX:
+			if d := cfg.sqlDialect(); cfg.Engine != MONGO_ENGINE && d.RegexStyle == "" {
+				return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Regex search is not supported by the '%s' dialect.", d.Name), CONFIG_SEARCH_MODE_ERR_CODE).withField(path)
+			}
+		case SEARCH_MODE_FULL_TEXT:
+			if d := cfg.sqlDialect(); cfg.Engine != MONGO_ENGINE && d.FullTextStyle == "" {
+				return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Full-text search is not supported by the '%s' dialect.", d.Name), CONFIG_SEARCH_MODE_ERR_CODE).withField(path)
+			}
+		default:
+			return newResponse(TESOQL_CONFIG_ERROR, fmt.Sprintf("Unknown search mode '%s'.", mode), CONFIG_SEARCH_MODE_ERR_CODE).withField(path)
+		}
+	}
+	return nil
+}
+
+// validateSearchRegex checks that the values of the search fields in regex mode are valid
+// regular expressions. The syntax of Go's regexp package is checked, which most engines
+// accept.
+//
+// Returns:
+//
+// - *ErrorResponseDTO: An error response if validation fails, or nil if validation passes.
+func (jm *JsonMap) validateSearchRegex(fm *FieldsMap) *ErrorResponseDTO {
+	searches := []struct {
+		path   string
+		values map[string][]interface{}
+	}{
+		{"search.", jm.Search},
+		{"searchExclude.", jm.SearchExclude},
+	}
+	for _, search := range searches {
+		for _, key := range sortedKeys(search.values) {
+			if searchMode(fm, key) != SEARCH_MODE_REGEX {
+				continue
+			}
+			for _, value := range search.values[key] {
+				if _, err := regexp.Compile(fmt.Sprintf("%v", value)); err != nil {
+					return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Search value '%v' is not a valid regular expression.", value), SEARCHABLE_ERR_CODE).withField(search.path + key).withCause(err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateSearchOptions checks that the excluded values and the search matches of the
+// JsonMap are given for search fields, that the search matches are known, and that
+// full-text fields neither exclude values nor require all of them, which $text cannot
+// express.
+//
+// Returns:
+//
+// - *ErrorResponseDTO: An error response if validation fails, or nil if validation passes.
+func (jm *JsonMap) validateSearchOptions(fm *FieldsMap) *ErrorResponseDTO {
+	for _, key := range sortedKeys(jm.SearchExclude) {
+		path := "searchExclude." + key
+		if _, exists := fm.SearchFields[key]; !exists {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Field : '%v' is not searchable.", key), SEARCHABLE_ERR_CODE).withField(path)
+		}
+		if searchMode(fm, key) == SEARCH_MODE_FULL_TEXT {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Values of full-text field '%v' cannot be excluded.", key), SEARCHABLE_ERR_CODE).withField(path)
+		}
+	}
+	for _, key := range sortedKeys(jm.SearchMatch) {
+		path := "searchMatch." + key
+		if _, exists := fm.SearchFields[key]; !exists {
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Field : '%v' is not searchable.", key), SEARCHABLE_ERR_CODE).withField(path)
+		}
+		switch match := jm.SearchMatch[key]; match {
+		case SEARCH_MATCH_ANY:
+		case SEARCH_MATCH_ALL:
+			if searchMode(fm, key) == SEARCH_MODE_FULL_TEXT {
+				return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("All values of full-text field '%v' cannot be required.", key), SEARCHABLE_ERR_CODE).withField(path)
+			}
+		default:
+			return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Unknown search match '%v'.", match), SEARCHABLE_ERR_CODE).withField(path)
+		}
+	}
+	return nil
+}
+
+// matchesAllSearch reports whether every search value of the field has to match.
+func (jm *JsonMap) matchesAllSearch(key string) bool {
+	return jm.SearchMatch[key] == SEARCH_MATCH_ALL
+}
+
+// validateQuery checks that global search fields are configured for the query of the
+// JsonMap, and that it has at most MAX_QUERY_TERMS words.
+//
+// Returns:
+//
+// - *ErrorResponseDTO: An error response if validation fails, or nil if validation passes.
+func (jm *JsonMap) validateQuery(fm *FieldsMap) *ErrorResponseDTO {
+	terms := jm.queryTerms()
+	if len(terms) == 0 {
+		return nil
+	}
+	if fm == nil || len(fm.GlobalSearchFields) == 0 {
+		return newResponse(TESOQL_VALIDATION_ERROR, "Global search is not configured.", SEARCHABLE_ERR_CODE).withField("q")
+	}
+	if len(terms) > MAX_QUERY_TERMS {
+		return newResponse(TESOQL_VALIDATION_ERROR, fmt.Sprintf("Query cannot have more than %d words.", MAX_QUERY_TERMS), SEARCHABLE_ERR_CODE).withField("q")
+	}
+	return nil
+}
+
+// queryTerms returns the words of the query of the JsonMap.
+func (jm *JsonMap) queryTerms() []string {
+	return strings.Fields(jm.Query)
+}
+
+// excludedTerm returns the word following the minus sign of an excluded query word.
+func excludedTerm(term string) (string, bool) {
+	if len(term) > 1 && strings.HasPrefix(term, "-") {
+		return term[1:], true
+	}
+	return term, false
+}
+
+// sqlGlobalSearch returns the predicates requiring each word of the query to match one of
+// the global search fields, in the search mode of the field, and each word prefixed with
+// a minus sign to match none of them.
+func sqlGlobalSearch(fm *FieldsMap, jm *JsonMap, args *sqlArgs) []string {
+	if len(fm.GlobalSearchFields) == 0 {
+		return nil
+	}
+	var predicates []string
+	for _, term := range jm.queryTerms() {
+		term, excluded := excludedTerm(term)
+		operator := " OR "
+		if excluded {
+			operator = " AND "
+		}
+		var fieldConditions []string
+		for _, key := range fm.GlobalSearchFields {
+			column := args.dialect.quoteIdentifier(fm.SearchFields[key])
+			if excluded {
+				fieldConditions = append(fieldConditions, sqlSearchExclusion(fm, key, column, term, args))
+			} else {
+				fieldConditions = append(fieldConditions, sqlSearchPredicate(fm, key, column, term, args))
+			}
+		}
+		predicates = append(predicates, fmt.Sprintf("(%s)", strings.Join(fieldConditions, operator)))
+	}
+	return predicates
+}
+
+// mongoGlobalSearch returns the filters requiring each word of the query to match one of
+// the global search fields, in the search mode of the field, and each word prefixed with
+// a minus sign to match none of them.
+func mongoGlobalSearch(fm *FieldsMap, jm *JsonMap) bson.A {
+	if len(fm.GlobalSearchFields) == 0 {
+		return nil
+	}
+	var filters bson.A
+	for _, term := range jm.queryTerms() {
+		term, excluded := excludedTerm(term)
+		var fieldFilters bson.A
+		for _, key := range fm.GlobalSearchFields {
+			fieldFilters = append(fieldFilters, mongoSearchPredicate(fm, key, term))
+		}
+		if excluded {
+			filters = append(filters, bson.D{{"$nor", fieldFilters}})
+		} else {
+			filters = append(filters, bson.D{{"$or", fieldFilters}})
+		}
+	}
+	return filters
+}
+
+// sqlSearchPredicate returns the predicate matching a search value on the column, in the
+// search mode of the field. The wildcards of the value are escaped, except in regex mode,
+// and the value is matched case-insensitively unless the field is case-sensitive. With a
+// search locale, the value is folded and matched on the folded column of the field, or
+// accent-insensitively.
+func sqlSearchPredicate(fm *FieldsMap, key string, column string, value interface{}, args *sqlArgs) string {
+	d := args.dialect
+	caseSensitive := fm.CaseSensitiveSearch[key]
+	text := fmt.Sprintf("%v", value)
+	if fm.foldsSearch(key) {
+		text = fm.FoldSearchText(text)
+		value = text
+		if _, exists := fm.FoldedSearchFields[key]; exists {
+			column, caseSensitive = sqlSearchColumn(fm, key, column, d), true
+		} else {
+			d = d.accentInsensitive()
+		}
+	}
+	switch searchMode(fm, key) {
+	case SEARCH_MODE_PREFIX:
+		return d.sqlLike(column, args.bind(d.escapeLike(text)+"%"), caseSensitive)
+	case SEARCH_MODE_SUFFIX:
+		return d.sqlLike(column, args.bind("%"+d.escapeLike(text)), caseSensitive)
+	case SEARCH_MODE_EXACT:
+		return d.sqlEqual(column, args.bind(value), caseSensitive)
+	case SEARCH_MODE_REGEX:
+		return sqlRegex(column, text, caseSensitive, args)
+	}
+	return d.sqlLike(column, args.bind("%"+d.escapeLike(text)+"%"), caseSensitive)
+}
+
+// sqlSearchExclusion returns the predicate excluding the records matching a search value
+// on the column. Records whose column is null are kept, as on Mongo.
+func sqlSearchExclusion(fm *FieldsMap, key string, column string, value interface{}, args *sqlArgs) string {
+	return fmt.Sprintf("(%s IS NULL OR NOT (%s))", sqlSearchColumn(fm, key, column, args.dialect), sqlSearchPredicate(fm, key, column, value, args))
+}
+
+// sqlSearchColumn returns the column the values of a search field are matched on, its
+// folded column when its values are folded.
+func sqlSearchColumn(fm *FieldsMap, key string, column string, d *Dialect) string {
+	if folded, exists := fm.FoldedSearchFields[key]; exists && fm.foldsSearch(key) {
+		return d.quoteIdentifier(folded)
+	}
+	return column
+}
+
+// sqlRegex returns the predicate matching the pattern on the column. Dialects without a
+// regex style are written with the REGEXP operator.
+func sqlRegex(column string, pattern string, caseSensitive bool, args *sqlArgs) string {
+	switch args.dialect.RegexStyle {
+	case REGEX_POSTGRES:
+		operator := "~*"
+		if caseSensitive {
+			operator = "~"
+		}
+		return fmt.Sprintf("%s %s %s", column, operator, args.bind(pattern))
+	case REGEX_LIKE_FUNCTION:
+		matchType := "i"
+		if caseSensitive {
+			matchType = "c"
+		}
+		return fmt.Sprintf("REGEXP_LIKE(%s, %s, '%s')", column, args.bind(pattern), matchType)
+	}
+	return fmt.Sprintf("%s REGEXP %s", column, args.bind(pattern))
+}
+
+// likeEscape returns the character escaping the wildcards of search values.
+func (d *Dialect) likeEscape() string {
+	if d.LikeEscape != "" {
+		return d.LikeEscape
+	}
+	return DEFAULT_LIKE_ESCAPE
+}
+
+// escapeLike escapes the wildcards and the escape character in a search value, so that
+// it is matched literally by a LIKE pattern.
+func (d *Dialect) escapeLike(value string) string {
+	escape := d.likeEscape()
+	special := d.LikeWildcards
+	if special == "" {
+		special = "%_"
+	}
+	special += escape
+	var b strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune(special, r) {
+			b.WriteString(escape)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sqlLike returns the LIKE predicate of an escaped pattern on the column, case-insensitive
+// unless caseSensitive.
+func (d *Dialect) sqlLike(column string, pattern string, caseSensitive bool) string {
+	operator := d.LikeOperator
+	if !caseSensitive && (d.CaseFoldStyle == CASE_FOLD_ILIKE || d.CaseFoldStyle == CASE_FOLD_UNACCENT) {
+		operator = "ILIKE"
+		column, pattern = d.foldAccents(column, pattern)
+	} else {
+		column, pattern = d.foldCase(column, pattern, caseSensitive)
+	}
+	if d.likeEscape() == `\` {
+		return fmt.Sprintf("%s %s %s", column, operator, pattern)
+	}
+	return fmt.Sprintf("%s %s %s ESCAPE '%s'", column, operator, pattern, d.likeEscape())
+}
+
+// sqlEqual returns the equality predicate of a value on the column, case-insensitive
+// unless caseSensitive.
+func (d *Dialect) sqlEqual(column string, value string, caseSensitive bool) string {
+	column, value = d.foldCase(column, value, caseSensitive)
+	return fmt.Sprintf("%s = %s", column, value)
+}
+
+// foldAccents returns the operands of a comparison with their accents removed, in the
+// CASE_FOLD_UNACCENT style.
+func (d *Dialect) foldAccents(column string, value string) (string, string) {
+	if d.CaseFoldStyle != CASE_FOLD_UNACCENT {
+		return column, value
+	}
+	return fmt.Sprintf("unaccent(%s)", column), fmt.Sprintf("unaccent(%s)", value)
+}
+
+// foldCase returns the operands of a comparison in the case-folding style of the dialect.
+// Case-sensitive comparisons are left to the collation of the column, unless the dialect
+// names a case-sensitive collation.
+func (d *Dialect) foldCase(column string, value string, caseSensitive bool) (string, string) {
+	if d.CaseFoldStyle == CASE_FOLD_COLLATE {
+		collation := d.CaseInsensitiveCollation
+		if caseSensitive {
+			collation = d.CaseSensitiveCollation
+		}
+		if collation == "" {
+			return column, value
+		}
+		return fmt.Sprintf("%s COLLATE %s", column, collation), value
+	}
+	column, value = d.foldAccents(column, value)
+	if caseSensitive {
+		return column, value
+	}
+	return fmt.Sprintf("LOWER(%s)", column), fmt.Sprintf("LOWER(%s)", value)
+}
+
+// mongoSearchPredicate returns the filter matching a search value on the field, in the
+// search mode of the field. The value is quoted in the regex, except in regex mode, and
+// prefixes are anchored so that a case-sensitive prefix search can use an index. With a
+// search locale, the folded value is matched on the folded field, or with the case and
+// accent variants of its letters, exact matches being left to the collation of the query.
+func mongoSearchPredicate(fm *FieldsMap, key string, value interface{}) bson.D {
+	field := fm.SearchFields[key]
+	options := "i"
+	if fm.CaseSensitiveSearch[key] {
+		options = ""
+	}
+	text := fmt.Sprintf("%v", value)
+	quote := regexp.QuoteMeta
+	equality := options == ""
+	if fm.foldsSearch(key) {
+		text = fm.FoldSearchText(text)
+		if folded, exists := fm.FoldedSearchFields[key]; exists {
+			field, value, options, equality = folded, text, "", true
+		} else {
+			quote, options, equality = fm.foldingRegex, "", true
+		}
+	}
+	var pattern string
+	switch searchMode(fm, key) {
+	case SEARCH_MODE_PREFIX:
+		pattern = "^" + quote(text)
+	case SEARCH_MODE_SUFFIX:
+		pattern = quote(text) + "$"
+	case SEARCH_MODE_EXACT:
+		if equality {
+			return bson.D{{field, value}}
+		}
+		pattern = "^" + quote(text) + "$"
+	case SEARCH_MODE_REGEX:
+		pattern = text
+	default:
+		pattern = quote(text)
+	}
+	return bson.D{{field, primitive.Regex{Pattern: pattern, Options: options}}}
+}
+
+// sqlFullTextPredicate returns the predicate matching any of the values on the full-text
+// index of the column.
+func sqlFullTextPredicate(column string, values []interface{}, args *sqlArgs) string {
+	switch args.dialect.FullTextStyle {
+	case FULL_TEXT_POSTGRES:
+		return fmt.Sprintf("to_tsvector(%s) @@ %s", column, postgresTsQuery(values, args))
+	case FULL_TEXT_MYSQL:
+		return mysqlMatch(column, values, args)
+	case FULL_TEXT_SQLITE:
+		return fmt.Sprintf("%s MATCH %s", column, args.bind(fts5Query(values)))
+	}
+	return ""
+}
+
+// sqlRelevanceOrder returns the ORDER BY term putting the rows that match the full-text
+// searches best first, or an empty string when the dialect cannot rank them.
+func sqlRelevanceOrder(fm *FieldsMap, jm *JsonMap, args *sqlArgs) string {
+	d := args.dialect
+	if d.FullTextStyle == FULL_TEXT_SQLITE {
+		// the rank of an FTS5 table is lower for better matches
+		return "rank"
+	}
+	var scores []string
+	for _, key := range sortedKeys(jm.Search) {
+		values := jm.Search[key]
+		if len(values) == 0 || searchMode(fm, key) != SEARCH_MODE_FULL_TEXT {
+			continue
+		}
+		column := d.quoteIdentifier(fm.SearchFields[key])
+		switch d.FullTextStyle {
+		case FULL_TEXT_POSTGRES:
+			scores = append(scores, fmt.Sprintf("ts_rank(to_tsvector(%s), %s)", column, postgresTsQuery(values, args)))
+		case FULL_TEXT_MYSQL:
+			scores = append(scores, mysqlMatch(column, values, args))
+		}
+	}
+	if len(scores) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s DESC", strings.Join(scores, " + "))
+}
+
+// postgresTsQuery returns the text search query matching any of the values, every word
+// of a value being required.
+func postgresTsQuery(values []interface{}, args *sqlArgs) string {
+	queries := make([]string, len(values))
+	for i, value := range values {
+		queries[i] = fmt.Sprintf("plainto_tsquery(%s)", args.bind(fmt.Sprintf("%v", value)))
+	}
+	if len(queries) == 1 {
+		return queries[0]
+	}
+	return fmt.Sprintf("(%s)", strings.Join(queries, " || "))
+}
+
+// mysqlMatch returns the MATCH expression of the values, which is both the predicate and
+// the relevance score in MySQL.
+func mysqlMatch(column string, values []interface{}, args *sqlArgs) string {
+	words := make([]string, len(values))
+	for i, value := range values {
+		words[i] = fmt.Sprintf("%v", value)
+	}
+	return fmt.Sprintf("MATCH (%s) AGAINST (%s IN NATURAL LANGUAGE MODE)", column, args.bind(strings.Join(words, " ")))
+}
+
+// fts5Query returns the FTS5 query matching any of the values, every word of a value being
+// required. Words are quoted, so that the FTS5 query syntax is not interpreted.
+func fts5Query(values []interface{}) string {
+	var queries []string
+	for _, value := range values {
+		words := strings.Fields(fmt.Sprintf("%v", value))
+		for i, word := range words {
+			words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+		}
+		if len(words) > 0 {
+			queries = append(queries, fmt.Sprintf("(%s)", strings.Join(words, " ")))
+		}
+	}
+	if len(queries) == 0 {
+		return `""`
+	}
+	return strings.Join(queries, " OR ")
+}
+
+// mongoTextSearch returns the $text filter of the values of the full-text fields, nil when
+// there are none. A collection has a single text index, shared by every full-text field.
+func mongoTextSearch(fm *FieldsMap, jm *JsonMap) bson.D {
+	var terms []string
+	for _, key := range sortedKeys(jm.Search) {
+		if searchMode(fm, key) != SEARCH_MODE_FULL_TEXT {
+			continue
+		}
+		for _, value := range jm.Search[key] {
+			terms = append(terms, fmt.Sprintf("%v", value))
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return bson.D{{"$text", bson.D{{"$search", strings.Join(terms, " ")}}}}
+}
@@ -0,0 +1,625 @@
+package tesoql
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSqlFullTextSearch(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields:  map[string]string{"title": "title", "body": "body"},
+		SearchModes:   map[string]string{"title": SEARCH_MODE_FULL_TEXT, "body": SEARCH_MODE_FULL_TEXT},
+		SortingFields: map[string]string{"id": "id"},
+	}
+	jm := &JsonMap{
+		Search:          map[string][]interface{}{"title": {"pizza margherita", "burger"}, "body": {`cheese "x"`}},
+		SortByRelevance: true,
+		SortConditions:  []SortInput{{Field: "id", SortCondition: "ASC"}},
+		Pagination:      Pagination{Limit: 2},
+	}
+	tests := []struct {
+		name      string
+		dialect   *Dialect
+		statement string
+		args      []interface{}
+	}{
+		{
+			name:    "postgres",
+			dialect: PostgresDialect,
+			statement: `SELECT * FROM "docs" WHERE 1=1 AND to_tsvector("body") @@ plainto_tsquery($1) AND to_tsvector("title") @@ (plainto_tsquery($2) || plainto_tsquery($3)) ` +
+				`ORDER BY ts_rank(to_tsvector("body"), plainto_tsquery($4)) + ts_rank(to_tsvector("title"), (plainto_tsquery($5) || plainto_tsquery($6))) DESC, "id" ASC LIMIT 2 OFFSET 0`,
+			args: []interface{}{`cheese "x"`, "pizza margherita", "burger", `cheese "x"`, "pizza margherita", "burger"},
+		},
+		{
+			name:    "mysql",
+			dialect: MySqlDialect,
+			statement: "SELECT * FROM `docs` WHERE 1=1 AND MATCH (`body`) AGAINST (? IN NATURAL LANGUAGE MODE) AND MATCH (`title`) AGAINST (? IN NATURAL LANGUAGE MODE) " +
+				"ORDER BY MATCH (`body`) AGAINST (? IN NATURAL LANGUAGE MODE) + MATCH (`title`) AGAINST (? IN NATURAL LANGUAGE MODE) DESC, `id` ASC LIMIT 2 OFFSET 0",
+			args: []interface{}{`cheese "x"`, "pizza margherita burger", `cheese "x"`, "pizza margherita burger"},
+		},
+		{
+			name:      "sqlite",
+			dialect:   SqliteDialect,
+			statement: `SELECT * FROM "docs" WHERE 1=1 AND "body" MATCH ? AND "title" MATCH ? ORDER BY rank, "id" ASC LIMIT 2 OFFSET 0`,
+			args:      []interface{}{`("cheese" """x""")`, `("pizza" "margherita") OR ("burger")`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := jm.NewSqlQueryWithDialect(fm, tt.dialect)
+			if statement := query.statement("docs", false); statement != tt.statement {
+				t.Errorf("statement = %s\nwant        %s", statement, tt.statement)
+			}
+			if !reflect.DeepEqual(query.Args, tt.args) {
+				t.Errorf("args = %v, want %v", query.Args, tt.args)
+			}
+		})
+	}
+}
+
+func TestFts5Query(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []interface{}
+		query  string
+	}{
+		{name: "words", values: []interface{}{"pizza margherita"}, query: `("pizza" "margherita")`},
+		{name: "values", values: []interface{}{"pizza", 42}, query: `("pizza") OR ("42")`},
+		{name: "syntax", values: []interface{}{`NEAR(a b) "c" OR`}, query: `("NEAR(a" "b)" """c""" "OR")`},
+		{name: "blank", values: []interface{}{" ", ""}, query: `""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if query := fts5Query(tt.values); query != tt.query {
+				t.Errorf("fts5Query() = %s, want %s", query, tt.query)
+			}
+		})
+	}
+}
+
+func TestMongoTextSearch(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields:  map[string]string{"title": "title", "body": "body", "name": "name"},
+		SearchModes:   map[string]string{"title": SEARCH_MODE_FULL_TEXT, "body": SEARCH_MODE_FULL_TEXT},
+		SortingFields: map[string]string{"id": "id"},
+	}
+	jm := &JsonMap{
+		Search:          map[string][]interface{}{"title": {"pizza margherita", "burger"}, "body": {`cheese "x"`}, "name": {"a"}},
+		SortByRelevance: true,
+		SortConditions:  []SortInput{{Field: "id", SortCondition: "ASC"}},
+	}
+
+	query := jm.NewMongoQuery(fm)
+	filter := `{"v":{"$and":[{"$or":[{"name":{"$regularExpression":{"pattern":"a","options":"i"}}}]},{"$text":{"$search":"cheese \"x\" pizza margherita burger"}}]}}`
+	if got := mongoJSON(t, query.Filter); got != filter {
+		t.Errorf("filter = %s\nwant     %s", got, filter)
+	}
+	sort := `{"v":{"tesoql_score":{"$meta":"textScore"},"id":1}}`
+	if got := mongoJSON(t, query.Sort); got != sort {
+		t.Errorf("sort = %s\nwant   %s", got, sort)
+	}
+	if filter := mongoTextSearch(fm, &JsonMap{Search: map[string][]interface{}{"name": {"a"}}}); filter != nil {
+		t.Errorf("mongoTextSearch() without full-text fields = %v, want nil", filter)
+	}
+}
+
+func TestValidateRelevance(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields: map[string]string{"title": "title", "name": "name"},
+		SearchModes:  map[string]string{"title": SEARCH_MODE_FULL_TEXT},
+	}
+	search := map[string][]interface{}{"title": {"pizza"}}
+	tests := []struct {
+		name    string
+		jsonMap *JsonMap
+		invalid bool
+	}{
+		{name: "full-text search", jsonMap: &JsonMap{SortByRelevance: true, Search: search}},
+		{name: "not requested", jsonMap: &JsonMap{}},
+		{name: "no full-text search", jsonMap: &JsonMap{SortByRelevance: true, Search: map[string][]interface{}{"name": {"a"}}}, invalid: true},
+		{name: "aggregations", jsonMap: &JsonMap{SortByRelevance: true, Search: search, Aggregations: &Aggregations{}}, invalid: true},
+		{name: "cursor", jsonMap: &JsonMap{SortByRelevance: true, Search: search, Pagination: Pagination{Cursor: "abc"}}, invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.jsonMap.validateRelevance(fm)
+			if !tt.invalid {
+				if err != nil {
+					t.Errorf("validateRelevance() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.ErrorCode != SORTABLE_ERR_CODE || err.Field != "sortByRelevance" {
+				t.Errorf("validateRelevance() = %v, want SORTABLE_ERR_CODE on sortByRelevance", err)
+			}
+		})
+	}
+}
+
+func TestValidateSearchModes(t *testing.T) {
+	tests := []struct {
+		name          string
+		engine        string
+		modes         map[string]string
+		caseSensitive map[string]bool
+		field         string
+	}{
+		{name: "full-text postgres", engine: POSTGRES_ENGINE, modes: map[string]string{"title": SEARCH_MODE_FULL_TEXT}},
+		{name: "full-text mongo", engine: MONGO_ENGINE, modes: map[string]string{"title": SEARCH_MODE_FULL_TEXT}},
+		{name: "full-text sql server", engine: SQLSERVER_ENGINE, modes: map[string]string{"title": SEARCH_MODE_FULL_TEXT}, field: "FieldsMap.SearchModes.title"},
+		{name: "not a search field", engine: POSTGRES_ENGINE, modes: map[string]string{"name": SEARCH_MODE_FULL_TEXT}, field: "FieldsMap.SearchModes.name"},
+		{name: "unknown mode", engine: POSTGRES_ENGINE, modes: map[string]string{"title": "fuzzy"}, field: "FieldsMap.SearchModes.title"},
+		{name: "case-sensitive field", engine: POSTGRES_ENGINE, caseSensitive: map[string]bool{"name": true}, field: "FieldsMap.CaseSensitiveSearch.name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Engine: tt.engine, FieldsMap: &FieldsMap{SearchFields: map[string]string{"title": "title"}, SearchModes: tt.modes, CaseSensitiveSearch: tt.caseSensitive}}
+			err := cfg.validateSearchModes()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("validateSearchModes() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !errors.Is(err, ErrConfigSearchMode) || err.Field != tt.field {
+				t.Errorf("validateSearchModes() = %v, want ErrConfigSearchMode on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestSqlSearchModes(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields: map[string]string{"name": "name", "sku": "sku", "code": "code", "city": "city", "notes": "notes"},
+		SearchModes:  map[string]string{"sku": SEARCH_MODE_PREFIX, "code": SEARCH_MODE_SUFFIX, "city": SEARCH_MODE_EXACT, "notes": SEARCH_MODE_REGEX},
+	}
+	jm := &JsonMap{Search: map[string][]interface{}{"name": {"5.0%_off!"}, "sku": {"a[b"}, "code": {"x_"}, "city": {"Ankara"}, "notes": {"^a.b$"}}}
+	tests := []struct {
+		name      string
+		dialect   *Dialect
+		statement string
+		args      []interface{}
+	}{
+		{
+			name:    "postgres",
+			dialect: PostgresDialect,
+			statement: `SELECT * FROM "t" WHERE 1=1 AND (LOWER("city") = LOWER($1)) AND ("code" ILIKE $2 ESCAPE '!') AND ("name" ILIKE $3 ESCAPE '!') ` +
+				`AND ("notes" ~* $4) AND ("sku" ILIKE $5 ESCAPE '!')`,
+			args: []interface{}{"Ankara", "%x!_", "%5.0!%!_off!!%", "^a.b$", "a[b%"},
+		},
+		{
+			name:    "bracket wildcard",
+			dialect: SqlServerDialect,
+			statement: `SELECT * FROM [t] WHERE 1=1 AND ([city] COLLATE Latin1_General_CI_AS = @p1) AND ([code] COLLATE Latin1_General_CI_AS LIKE @p2 ESCAPE '!') ` +
+				`AND ([name] COLLATE Latin1_General_CI_AS LIKE @p3 ESCAPE '!') AND ([notes] REGEXP @p4) AND ([sku] COLLATE Latin1_General_CI_AS LIKE @p5 ESCAPE '!')`,
+			args: []interface{}{"Ankara", "%x!_", "%5.0!%!_off!!%", "^a.b$", "a![b%"},
+		},
+		{
+			name:    "backslash escape",
+			dialect: BigQueryDialect,
+			statement: "SELECT * FROM `t` WHERE 1=1 AND (LOWER(`city`) = LOWER(@p1)) AND (LOWER(`code`) LIKE LOWER(@p2)) AND (LOWER(`name`) LIKE LOWER(@p3)) " +
+				"AND (`notes` REGEXP @p4) AND (LOWER(`sku`) LIKE LOWER(@p5))",
+			args: []interface{}{sql.Named("p1", "Ankara"), sql.Named("p2", `%x\_`), sql.Named("p3", `%5.0\%\_off!%`), sql.Named("p4", "^a.b$"), sql.Named("p5", "a[b%")},
+		},
+		{
+			name:    "regexp_like",
+			dialect: OracleDialect,
+			statement: `SELECT * FROM "t" WHERE 1=1 AND (LOWER("city") = LOWER(:1)) AND (LOWER("code") LIKE LOWER(:2) ESCAPE '!') AND (LOWER("name") LIKE LOWER(:3) ESCAPE '!') ` +
+				`AND (REGEXP_LIKE("notes", :4, 'i')) AND (LOWER("sku") LIKE LOWER(:5) ESCAPE '!')`,
+			args: []interface{}{"Ankara", "%x!_", "%5.0!%!_off!!%", "^a.b$", "a[b%"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := jm.NewSqlQueryWithDialect(fm, tt.dialect)
+			if statement := query.statement("t", false); statement != tt.statement {
+				t.Errorf("statement = %s\nwant        %s", statement, tt.statement)
+			}
+			if !reflect.DeepEqual(query.Args, tt.args) {
+				t.Errorf("args = %v, want %v", query.Args, tt.args)
+			}
+		})
+	}
+}
+
+func TestMongoSearchModes(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields: map[string]string{"name": "name", "sku": "sku", "code": "code", "city": "city", "notes": "notes"},
+		SearchModes:  map[string]string{"sku": SEARCH_MODE_PREFIX, "code": SEARCH_MODE_SUFFIX, "city": SEARCH_MODE_EXACT, "notes": SEARCH_MODE_REGEX},
+	}
+	jm := &JsonMap{Search: map[string][]interface{}{"name": {"5.0%_off!"}, "sku": {"a[b"}, "code": {"x_"}, "city": {"Ankara"}, "notes": {"^a.b$"}}}
+	filter := `{"v":{"$and":[{"$or":[{"city":{"$regularExpression":{"pattern":"^Ankara$","options":"i"}}}]},` +
+		`{"$or":[{"code":{"$regularExpression":{"pattern":"x_$","options":"i"}}}]},` +
+		`{"$or":[{"name":{"$regularExpression":{"pattern":"5\\.0%_off!","options":"i"}}}]},` +
+		`{"$or":[{"notes":{"$regularExpression":{"pattern":"^a.b$","options":"i"}}}]},` +
+		`{"$or":[{"sku":{"$regularExpression":{"pattern":"^a\\[b","options":"i"}}}]}]}}`
+	if got := mongoJSON(t, jm.NewMongoQuery(fm).Filter); got != filter {
+		t.Errorf("filter = %s\nwant     %s", got, filter)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect *Dialect
+		value   string
+		escaped string
+	}{
+		{name: "default", dialect: GenericDialect, value: "50%_off!", escaped: "50!%!_off!!"},
+		{name: "bracket wildcard", dialect: SqlServerDialect, value: "[a]%", escaped: "![a]!%"},
+		{name: "backslash", dialect: BigQueryDialect, value: `a\b%_!`, escaped: `a\\b\%\_!`},
+		{name: "plain", dialect: PostgresDialect, value: "pizza", escaped: "pizza"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if escaped := tt.dialect.escapeLike(tt.value); escaped != tt.escaped {
+				t.Errorf("escapeLike(%q) = %s, want %s", tt.value, escaped, tt.escaped)
+			}
+		})
+	}
+}
+
+func TestValidateSearchRegex(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields: map[string]string{"name": "name", "notes": "notes"},
+		SearchModes:  map[string]string{"notes": SEARCH_MODE_REGEX},
+	}
+	tests := []struct {
+		name    string
+		jsonMap *JsonMap
+		field   string
+	}{
+		{name: "valid", jsonMap: &JsonMap{Search: map[string][]interface{}{"notes": {"^a.b$"}}}},
+		{name: "not regex mode", jsonMap: &JsonMap{Search: map[string][]interface{}{"name": {"("}}}},
+		{name: "search", jsonMap: &JsonMap{Search: map[string][]interface{}{"notes": {"a", "("}}}, field: "search.notes"},
+		{name: "exclude", jsonMap: &JsonMap{SearchExclude: map[string][]interface{}{"notes": {"[a"}}}, field: "searchExclude.notes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.jsonMap.validateSearchRegex(fm)
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("validateSearchRegex() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.ErrorCode != SEARCHABLE_ERR_CODE || err.Field != tt.field {
+				t.Errorf("validateSearchRegex() = %v, want SEARCHABLE_ERR_CODE on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestCaseFolding(t *testing.T) {
+	tests := []struct {
+		name          string
+		dialect       *Dialect
+		caseSensitive bool
+		like          string
+		equal         string
+	}{
+		{name: "ilike", dialect: PostgresDialect, like: `name ILIKE ? ESCAPE '!'`, equal: `LOWER(name) = LOWER(?)`},
+		{name: "ilike case-sensitive", dialect: PostgresDialect, caseSensitive: true, like: `name LIKE ? ESCAPE '!'`, equal: `name = ?`},
+		{name: "lower", dialect: GenericDialect, like: `LOWER(name) LIKE LOWER(?) ESCAPE '!'`, equal: `LOWER(name) = LOWER(?)`},
+		{name: "lower case-sensitive", dialect: GenericDialect, caseSensitive: true, like: `name LIKE ? ESCAPE '!'`, equal: `name = ?`},
+		{name: "collate", dialect: MySqlDialect, like: `name LIKE ? ESCAPE '!'`, equal: `name = ?`},
+		{name: "collate case-sensitive", dialect: MySqlDialect, caseSensitive: true, like: `name COLLATE utf8mb4_bin LIKE ? ESCAPE '!'`, equal: `name COLLATE utf8mb4_bin = ?`},
+		{name: "sql server collate", dialect: SqlServerDialect, like: `name COLLATE Latin1_General_CI_AS LIKE ? ESCAPE '!'`, equal: `name COLLATE Latin1_General_CI_AS = ?`},
+		{
+			name:          "sql server collate case-sensitive",
+			dialect:       SqlServerDialect,
+			caseSensitive: true,
+			like:          `name COLLATE Latin1_General_CS_AS LIKE ? ESCAPE '!'`,
+			equal:         `name COLLATE Latin1_General_CS_AS = ?`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if like := tt.dialect.sqlLike("name", "?", tt.caseSensitive); like != tt.like {
+				t.Errorf("sqlLike() = %s\nwant        %s", like, tt.like)
+			}
+			if equal := tt.dialect.sqlEqual("name", "?", tt.caseSensitive); equal != tt.equal {
+				t.Errorf("sqlEqual() = %s\nwant         %s", equal, tt.equal)
+			}
+		})
+	}
+}
+
+func TestCaseFoldingDialectOverride(t *testing.T) {
+	// latin1 columns, as on MySQL 5.7 and MariaDB setups, reject the utf8mb4 collations
+	latin1 := *MySqlDialect
+	latin1.CaseSensitiveCollation = "latin1_general_cs"
+	cfg := &Config{Engine: MYSQL_ENGINE, Dialect: &latin1}
+	fm := &FieldsMap{
+		SearchFields:        map[string]string{"name": "name", "sku": "sku"},
+		SearchModes:         map[string]string{"sku": SEARCH_MODE_EXACT},
+		CaseSensitiveSearch: map[string]bool{"sku": true},
+	}
+	jm := &JsonMap{Search: map[string][]interface{}{"name": {"pizza"}, "sku": {"AB"}}}
+
+	statement := "SELECT * FROM `t` WHERE 1=1 AND (`name` LIKE ? ESCAPE '!') AND (`sku` COLLATE latin1_general_cs = ?)"
+	if got := jm.NewSqlQueryWithDialect(fm, cfg.sqlDialect()).statement("t", false); got != statement {
+		t.Errorf("statement = %s\nwant        %s", got, statement)
+	}
+	if MySqlDialect.CaseSensitiveCollation != "utf8mb4_bin" {
+		t.Errorf("MySqlDialect.CaseSensitiveCollation = %s, want the default dialect unchanged", MySqlDialect.CaseSensitiveCollation)
+	}
+}
+
+func TestCaseSensitiveSearch(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields:        map[string]string{"name": "name", "sku": "sku", "city": "city", "notes": "notes"},
+		SearchModes:         map[string]string{"sku": SEARCH_MODE_PREFIX, "city": SEARCH_MODE_EXACT, "notes": SEARCH_MODE_REGEX},
+		CaseSensitiveSearch: map[string]bool{"name": true, "city": true, "notes": true},
+	}
+	jm := &JsonMap{Search: map[string][]interface{}{"name": {"Pizza"}, "city": {"Ankara"}, "notes": {"^A"}, "sku": {"AB"}}}
+
+	query := jm.NewSqlQueryWithDialect(fm, PostgresDialect)
+	statement := `SELECT * FROM "t" WHERE 1=1 AND ("city" = $1) AND ("name" LIKE $2 ESCAPE '!') AND ("notes" ~ $3) AND ("sku" ILIKE $4 ESCAPE '!')`
+	if got := query.statement("t", false); got != statement {
+		t.Errorf("statement = %s\nwant        %s", got, statement)
+	}
+	query = jm.NewSqlQueryWithDialect(fm, OracleDialect)
+	statement = `SELECT * FROM "t" WHERE 1=1 AND ("city" = :1) AND ("name" LIKE :2 ESCAPE '!') AND (REGEXP_LIKE("notes", :3, 'c')) AND (LOWER("sku") LIKE LOWER(:4) ESCAPE '!')`
+	if got := query.statement("t", false); got != statement {
+		t.Errorf("statement = %s\nwant        %s", got, statement)
+	}
+
+	filter := `{"v":{"$and":[{"$or":[{"city":"Ankara"}]},{"$or":[{"name":{"$regularExpression":{"pattern":"Pizza","options":""}}}]},` +
+		`{"$or":[{"notes":{"$regularExpression":{"pattern":"^A","options":""}}}]},{"$or":[{"sku":{"$regularExpression":{"pattern":"^AB","options":"i"}}}]}]}}`
+	if got := mongoJSON(t, jm.NewMongoQuery(fm).Filter); got != filter {
+		t.Errorf("filter = %s\nwant     %s", got, filter)
+	}
+}
+
+func TestMongoCaseSensitivePrefix(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields: map[string]string{"sku": "sku"},
+		SearchModes:  map[string]string{"sku": SEARCH_MODE_PREFIX},
+	}
+	tests := []struct {
+		name          string
+		caseSensitive bool
+		predicate     string
+	}{
+		{name: "case-sensitive", caseSensitive: true, predicate: `{"v":{"sku":{"$regularExpression":{"pattern":"^A\\.B","options":""}}}}`},
+		{name: "case-insensitive", predicate: `{"v":{"sku":{"$regularExpression":{"pattern":"^A\\.B","options":"i"}}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm.CaseSensitiveSearch = map[string]bool{"sku": tt.caseSensitive}
+			if got := mongoJSON(t, mongoSearchPredicate(fm, "sku", "A.B")); got != tt.predicate {
+				t.Errorf("predicate = %s\nwant        %s", got, tt.predicate)
+			}
+		})
+	}
+}
+
+func TestSqlGlobalSearch(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields:       map[string]string{"name": "name", "sku": "sku", "description": "description"},
+		SearchModes:        map[string]string{"sku": SEARCH_MODE_PREFIX},
+		GlobalSearchFields: []string{"name", "sku"},
+	}
+	tests := []struct {
+		name      string
+		jsonMap   *JsonMap
+		statement string
+		args      []interface{}
+	}{
+		{
+			name:    "words",
+			jsonMap: &JsonMap{Query: "  pizza 5.0% "},
+			statement: `SELECT * FROM "t" WHERE 1=1 AND ("name" ILIKE $1 ESCAPE '!' OR "sku" ILIKE $2 ESCAPE '!') ` +
+				`AND ("name" ILIKE $3 ESCAPE '!' OR "sku" ILIKE $4 ESCAPE '!')`,
+			args: []interface{}{"%pizza%", "pizza%", "%5.0!%%", "5.0!%%"},
+		},
+		{
+			name:    "excluded word",
+			jsonMap: &JsonMap{Query: "pizza -frozen", Search: map[string][]interface{}{"description": {"cheese"}}},
+			statement: `SELECT * FROM "t" WHERE 1=1 AND ("description" ILIKE $1 ESCAPE '!') AND ("name" ILIKE $2 ESCAPE '!' OR "sku" ILIKE $3 ESCAPE '!') ` +
+				`AND (("name" IS NULL OR NOT ("name" ILIKE $4 ESCAPE '!')) AND ("sku" IS NULL OR NOT ("sku" ILIKE $5 ESCAPE '!')))`,
+			args: []interface{}{"%cheese%", "%pizza%", "pizza%", "%frozen%", "frozen%"},
+		},
+		{
+			name:      "minus sign",
+			jsonMap:   &JsonMap{Query: "-"},
+			statement: `SELECT * FROM "t" WHERE 1=1 AND ("name" ILIKE $1 ESCAPE '!' OR "sku" ILIKE $2 ESCAPE '!')`,
+			args:      []interface{}{"%-%", "-%"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.jsonMap.NewSqlQueryWithDialect(fm, PostgresDialect)
+			if statement := query.statement("t", false); statement != tt.statement {
+				t.Errorf("statement = %s\nwant        %s", statement, tt.statement)
+			}
+			if !reflect.DeepEqual(query.Args, tt.args) {
+				t.Errorf("args = %v, want %v", query.Args, tt.args)
+			}
+		})
+	}
+}
+
+func TestMongoGlobalSearch(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields:       map[string]string{"name": "name", "sku": "sku"},
+		SearchModes:        map[string]string{"sku": SEARCH_MODE_PREFIX},
+		GlobalSearchFields: []string{"name", "sku"},
+	}
+	jm := &JsonMap{Query: "pizza 5.0 -frozen"}
+	filter := `{"v":{"$and":[{"$or":[{"name":{"$regularExpression":{"pattern":"pizza","options":"i"}}},{"sku":{"$regularExpression":{"pattern":"^pizza","options":"i"}}}]},` +
+		`{"$or":[{"name":{"$regularExpression":{"pattern":"5\\.0","options":"i"}}},{"sku":{"$regularExpression":{"pattern":"^5\\.0","options":"i"}}}]},` +
+		`{"$nor":[{"name":{"$regularExpression":{"pattern":"frozen","options":"i"}}},{"sku":{"$regularExpression":{"pattern":"^frozen","options":"i"}}}]}]}}`
+	if got := mongoJSON(t, jm.NewMongoQuery(fm).Filter); got != filter {
+		t.Errorf("filter = %s\nwant     %s", got, filter)
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	global := &FieldsMap{SearchFields: map[string]string{"name": "name"}, GlobalSearchFields: []string{"name"}}
+	tests := []struct {
+		name    string
+		fm      *FieldsMap
+		query   string
+		invalid bool
+	}{
+		{name: "valid", fm: global, query: "pizza -frozen"},
+		{name: "blank", fm: &FieldsMap{}, query: "   "},
+		{name: "max words", fm: global, query: "a b c d e f g h i j"},
+		{name: "not configured", fm: &FieldsMap{}, query: "pizza", invalid: true},
+		{name: "nil fields map", query: "pizza", invalid: true},
+		{name: "too many words", fm: global, query: "a b c d e f g h i j k", invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&JsonMap{Query: tt.query}).validateQuery(tt.fm)
+			if !tt.invalid {
+				if err != nil {
+					t.Errorf("validateQuery() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.ErrorCode != SEARCHABLE_ERR_CODE || err.Field != "q" {
+				t.Errorf("validateQuery() = %v, want SEARCHABLE_ERR_CODE on q", err)
+			}
+		})
+	}
+}
+
+func TestValidateGlobalSearchFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		keys  []string
+		field string
+	}{
+		{name: "valid", keys: []string{"name", "sku"}},
+		{name: "not a search field", keys: []string{"name", "price"}, field: "FieldsMap.GlobalSearchFields.1"},
+		{name: "regex mode", keys: []string{"notes"}, field: "FieldsMap.GlobalSearchFields.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &FieldsMap{
+				SearchFields:       map[string]string{"name": "name", "sku": "sku", "notes": "notes"},
+				SearchModes:        map[string]string{"sku": SEARCH_MODE_PREFIX, "notes": SEARCH_MODE_REGEX},
+				GlobalSearchFields: tt.keys,
+			}
+			err := (&Config{Engine: POSTGRES_ENGINE, FieldsMap: fm}).validateSearchModes()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("validateSearchModes() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !errors.Is(err, ErrConfigSearchMode) || err.Field != tt.field {
+				t.Errorf("validateSearchModes() = %v, want ErrConfigSearchMode on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestSqlSearchOptions(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields: map[string]string{"name": "name", "tags": "tags", "sku": "sku"},
+		SearchModes:  map[string]string{"sku": SEARCH_MODE_EXACT},
+	}
+	jm := &JsonMap{
+		Search:        map[string][]interface{}{"tags": {"vegan", "spicy"}, "name": {"pizza", "pie"}},
+		SearchMatch:   map[string]string{"tags": SEARCH_MATCH_ALL, "name": SEARCH_MATCH_ANY},
+		SearchExclude: map[string][]interface{}{"name": {"frozen", "mini"}, "sku": {"X1"}},
+	}
+	tests := []struct {
+		name      string
+		dialect   *Dialect
+		statement string
+	}{
+		{
+			name:    "ilike",
+			dialect: PostgresDialect,
+			statement: `SELECT * FROM "t" WHERE 1=1 AND ("name" ILIKE $1 ESCAPE '!' OR "name" ILIKE $2 ESCAPE '!') AND ("tags" ILIKE $3 ESCAPE '!' AND "tags" ILIKE $4 ESCAPE '!') ` +
+				`AND ("name" IS NULL OR NOT ("name" ILIKE $5 ESCAPE '!')) AND ("name" IS NULL OR NOT ("name" ILIKE $6 ESCAPE '!')) AND ("sku" IS NULL OR NOT (LOWER("sku") = LOWER($7)))`,
+		},
+		{
+			name:    "lower",
+			dialect: GenericDialect,
+			statement: `SELECT * FROM t WHERE 1=1 AND (LOWER(name) LIKE LOWER(?) ESCAPE '!' OR LOWER(name) LIKE LOWER(?) ESCAPE '!') AND (LOWER(tags) LIKE LOWER(?) ESCAPE '!' AND LOWER(tags) LIKE LOWER(?) ESCAPE '!') ` +
+				`AND (name IS NULL OR NOT (LOWER(name) LIKE LOWER(?) ESCAPE '!')) AND (name IS NULL OR NOT (LOWER(name) LIKE LOWER(?) ESCAPE '!')) AND (sku IS NULL OR NOT (LOWER(sku) = LOWER(?)))`,
+		},
+	}
+	args := []interface{}{"%pizza%", "%pie%", "%vegan%", "%spicy%", "%frozen%", "%mini%", "X1"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := jm.NewSqlQueryWithDialect(fm, tt.dialect)
+			if statement := query.statement("t", false); statement != tt.statement {
+				t.Errorf("statement = %s\nwant        %s", statement, tt.statement)
+			}
+			if !reflect.DeepEqual(query.Args, args) {
+				t.Errorf("args = %v, want %v", query.Args, args)
+			}
+		})
+	}
+}
+
+func TestMongoSearchOptions(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields: map[string]string{"name": "name", "tags": "tags", "sku": "sku"},
+		SearchModes:  map[string]string{"sku": SEARCH_MODE_EXACT},
+	}
+	jm := &JsonMap{
+		Search:        map[string][]interface{}{"tags": {"vegan", "spicy"}, "name": {"pizza", "pie"}},
+		SearchMatch:   map[string]string{"tags": SEARCH_MATCH_ALL, "name": SEARCH_MATCH_ANY},
+		SearchExclude: map[string][]interface{}{"name": {"frozen", "mini"}, "sku": {"X1"}},
+	}
+	filter := `{"v":{"$and":[{"$or":[{"name":{"$regularExpression":{"pattern":"pizza","options":"i"}}},{"name":{"$regularExpression":{"pattern":"pie","options":"i"}}}]},` +
+		`{"$and":[{"tags":{"$regularExpression":{"pattern":"vegan","options":"i"}}},{"tags":{"$regularExpression":{"pattern":"spicy","options":"i"}}}]},` +
+		`{"$nor":[{"name":{"$regularExpression":{"pattern":"frozen","options":"i"}}},{"name":{"$regularExpression":{"pattern":"mini","options":"i"}}}]},` +
+		`{"$nor":[{"sku":{"$regularExpression":{"pattern":"^X1$","options":"i"}}}]}]}}`
+	// the fields are visited in sorted order, whatever the order of the maps
+	for i := 0; i < 10; i++ {
+		if got := mongoJSON(t, jm.NewMongoQuery(fm).Filter); got != filter {
+			t.Fatalf("filter = %s\nwant     %s", got, filter)
+		}
+	}
+}
+
+func TestValidateSearchOptions(t *testing.T) {
+	fm := &FieldsMap{
+		SearchFields: map[string]string{"name": "name", "tags": "tags", "title": "title"},
+		SearchModes:  map[string]string{"title": SEARCH_MODE_FULL_TEXT},
+	}
+	tests := []struct {
+		name    string
+		jsonMap *JsonMap
+		field   string
+	}{
+		{
+			name: "valid",
+			jsonMap: &JsonMap{
+				SearchMatch:   map[string]string{"tags": SEARCH_MATCH_ALL, "name": SEARCH_MATCH_ANY},
+				SearchExclude: map[string][]interface{}{"name": {"frozen"}},
+			},
+		},
+		{name: "excluded field", jsonMap: &JsonMap{SearchExclude: map[string][]interface{}{"price": {"1"}}}, field: "searchExclude.price"},
+		{name: "excluded full-text", jsonMap: &JsonMap{SearchExclude: map[string][]interface{}{"title": {"pizza"}}}, field: "searchExclude.title"},
+		{name: "matched field", jsonMap: &JsonMap{SearchMatch: map[string]string{"price": SEARCH_MATCH_ALL}}, field: "searchMatch.price"},
+		{name: "unknown match", jsonMap: &JsonMap{SearchMatch: map[string]string{"name": "most"}}, field: "searchMatch.name"},
+		{name: "full-text all", jsonMap: &JsonMap{SearchMatch: map[string]string{"title": SEARCH_MATCH_ALL}}, field: "searchMatch.title"},
+		{name: "full-text any", jsonMap: &JsonMap{SearchMatch: map[string]string{"title": SEARCH_MATCH_ANY}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.jsonMap.validateSearchOptions(fm)
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("validateSearchOptions() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.ErrorCode != SEARCHABLE_ERR_CODE || err.Field != tt.field {
+				t.Errorf("validateSearchOptions() = %v, want SEARCHABLE_ERR_CODE on %s", err, tt.field)
+			}
+		})
+	}
+}
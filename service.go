@@ -1,18 +1,40 @@
 package tesoql
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
 // Service provides the core functionality for interacting with the repository.
 // It is initialized with a repository interface and a set of feature toggles.
 type Service struct {
-	repo    *iTesoQlRepo  // The repository interface for database interactions.
-	toggles *ToggleConfig // Feature toggles that control the behavior of the service.
+	repo             Repository        // The repository interface for database interactions.
+	engine           string            // The engine of the repository, deciding how QueryAs decodes records.
+	toggles          *ToggleConfig     // Feature toggles that control the behavior of the service.
+	filterLimits     *FilterLimits     // Limits on the size of filter trees.
+	pagination       *PaginationConfig // Pagination settings, used to clamp the limit and offset in Query.
+	fieldsMap        *FieldsMap        // Field mappings, used to build and verify cursors.
+	cursorSigningKey []byte            // Key signing the cursors, cursor pagination is disabled when empty.
+	defaultTimeout   time.Duration     // Timeout applied when the caller's context has no deadline.
 }
 
-func newTesoQlService(repo *iTesoQlRepo, toggles *ToggleConfig) *Service {
-	return &Service{repo: repo, toggles: toggles}
+func newTesoQlService(repo Repository, cfg *Config) *Service {
+	return &Service{
+		repo:             repo,
+		engine:           cfg.Engine,
+		toggles:          cfg.Toggles,
+		filterLimits:     cfg.FilterLimits,
+		pagination:       cfg.Pagination,
+		fieldsMap:        cfg.FieldsMap,
+		cursorSigningKey: cfg.CursorSigningKey,
+		defaultTimeout:   cfg.DefaultTimeout,
+	}
 }
 
 // Get retrieves data from the repository based on the provided JsonMap.
-// It performs validation against the service's toggles before querying the repository.
+// It performs validation against the service's toggles and the filter tree limits, and verifies the
+// pagination cursor if any, before querying the repository.
 //
 // Example usage:
 //
@@ -40,14 +62,225 @@ func newTesoQlService(repo *iTesoQlRepo, toggles *ToggleConfig) *Service {
 //
 // - *ErrorResponseDTO: An error response, if any occurred during validation or retrieval.
 func (s *Service) Get(jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO) {
-	validationErr := validateToggles(jsonMap, s.toggles)
+	return s.GetContext(context.Background(), jsonMap)
+}
+
+// GetContext works like Get, running the queries with the given context so that the caller's
+// deadline and cancellation reach the database. When ctx has no deadline, Config.DefaultTimeout
+// (DEFAULT_QUERY_TIMEOUT when zero) is applied.
+//
+// Example usage:
+//
+//	func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+//		// jsonMapVariable is decoded and validated from the request...
+//		results, totalCount, size, err := tesoQL.Service.GetContext(r.Context(), &jsonMapVariable)
+//	}
+//
+// Returns:
+//
+// - []map[string]interface{}: The data retrieved from the repository.
+//
+// - int: The total count of records that match the query.
+//
+// - int: The size of the current page of results.
+//
+// - *ErrorResponseDTO: An error response, if any occurred during validation or retrieval.
+func (s *Service) GetContext(ctx context.Context, jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO) {
+	validationErr := s.validate(jsonMap)
 	if validationErr != nil {
 		return nil, 0, 0, validationErr
 	}
-	r := *s.repo
-	response, totalCount, size, err := r.repository(jsonMap)
+	ctx, cancel := withDefaultTimeout(ctx, s.defaultTimeout)
+	defer cancel()
+
+	response, totalCount, size, err := s.repo.Find(ctx, jsonMap)
 	if err != nil {
 		return nil, 0, 0, err
 	}
 	return response, totalCount, size, nil
 }
+
+// validate runs the validations every query goes through: the toggles, the filter
+// tree limits, the aggregations, the facets, the date histogram, the relevance sorting and
+// the pagination cursor.
+func (s *Service) validate(jsonMap *JsonMap) *ErrorResponseDTO {
+	validationErr := validateToggles(jsonMap, s.toggles)
+	if validationErr != nil {
+		return validationErr
+	}
+	validationErr = jsonMap.validateFilter(nil, s.filterLimits)
+	if validationErr != nil {
+		return validationErr
+	}
+	validationErr = jsonMap.validateAggregations(s.fieldsMap)
+	if validationErr != nil {
+		return validationErr
+	}
+	validationErr = jsonMap.validateFacets(s.fieldsMap)
+	if validationErr != nil {
+		return validationErr
+	}
+	validationErr = jsonMap.validateDateHistogram(s.fieldsMap)
+	if validationErr != nil {
+		return validationErr
+	}
+	validationErr = jsonMap.validateRelevance(s.fieldsMap)
+	if validationErr != nil {
+		return validationErr
+	}
+	validationErr = jsonMap.validateQuery(s.fieldsMap)
+	if validationErr != nil {
+		return validationErr
+	}
+	return jsonMap.resolveCursor(s.fieldsMap, s.cursorSigningKey)
+}
+
+// Query retrieves a page of data like GetContext, and returns it in a Result envelope with
+// the pagination metadata. The limit and offset are clamped with the PaginationConfig first,
+// as JsonMap.Validate does, and one record more than the limit is fetched to tell whether
+// another page follows. Next points to the following page with a cursor when cursor
+// pagination is configured, with an offset otherwise. The facets and the date histogram
+// of the JsonMap are computed over the same filters, see Service.Facets and
+// Service.DateHistogram.
+//
+// Example usage:
+//
+//	result, err := tesoQL.Service.Query(r.Context(), &jsonMapVariable)
+//	if err != nil {
+//		// Handle error
+//	}
+//	json.NewEncoder(w).Encode(result)
+//
+// Returns:
+//
+// - *Result: The page of data with its metadata.
+//
+// - *ErrorResponseDTO: An error response, if any occurred during validation or retrieval.
+func (s *Service) Query(ctx context.Context, jsonMap *JsonMap) (*Result, *ErrorResponseDTO) {
+	start := time.Now()
+	result := &Result{}
+
+	requested := jsonMap.Pagination
+	jsonMap.validatePagination(s.pagination)
+	if requested.Limit > 0 && requested.Limit != jsonMap.Pagination.Limit {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Limit %d is out of bounds, %d is used.", requested.Limit, jsonMap.Pagination.Limit))
+	}
+	if requested.Offset != jsonMap.Pagination.Offset {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Offset %d is out of bounds, %d is used.", requested.Offset, jsonMap.Pagination.Offset))
+	}
+	if jsonMap.Pagination.Cursor != "" && jsonMap.Pagination.Offset > 0 {
+		result.Warnings = append(result.Warnings, "Offset is ignored when a cursor is given.")
+	}
+	totalCountRequested := jsonMap.TotalCount
+
+	// fetch one more record to tell whether another page follows
+	probe := *jsonMap
+	if jsonMap.Pagination.Limit > 0 {
+		probe.Pagination.Limit++
+	}
+	probe.keysetColumns = s.buildsCursor(jsonMap)
+	items, totalCount, size, err := s.GetContext(ctx, &probe)
+	jsonMap.TotalCount = probe.TotalCount
+	if err != nil {
+		return nil, err
+	}
+	if len(probe.Facets) > 0 {
+		facetCtx, cancel := withDefaultTimeout(ctx, s.defaultTimeout)
+		result.Facets, err = s.facets(facetCtx, &probe)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if probe.DateHistogram != nil {
+		histogramCtx, cancel := withDefaultTimeout(ctx, s.defaultTimeout)
+		result.Histogram, err = s.dateHistogram(histogramCtx, &probe)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if totalCountRequested && !probe.TotalCount {
+		result.Warnings = append(result.Warnings, "Total count is disabled.")
+	}
+
+	limit, offset := jsonMap.Pagination.Limit, jsonMap.Pagination.Offset
+	if jsonMap.Pagination.Cursor != "" {
+		offset = 0
+	}
+	if limit > 0 && int64(size) > limit {
+		items = items[:limit]
+		size = int(limit)
+		result.HasMore = true
+	}
+	result.Items = items
+	result.Size = size
+	result.Limit = limit
+	result.Offset = offset
+	if probe.TotalCount {
+		result.TotalCount = &totalCount
+	}
+
+	if result.HasMore {
+		result.Next = &Pagination{Limit: limit, Offset: offset + int64(size)}
+		if probe.keysetColumns {
+			// the sort columns were projected, a missing one is a document without the field
+			cursor, cursorErr := newCursor(s.fieldsMap, &probe, items[size-1], s.cursorSigningKey)
+			if cursorErr != nil {
+				result.Warnings = append(result.Warnings, cursorErr.ErrorMsg)
+			}
+			if cursor != "" {
+				result.Next = &Pagination{Limit: limit, Cursor: cursor}
+			} else if jsonMap.Pagination.Cursor != "" {
+				result.Next = nil
+			}
+		}
+	}
+	stripKeysetProjection(s.fieldsMap, &probe, items)
+	if jsonMap.Pagination.Cursor == "" && offset > 0 {
+		previousOffset := offset - limit
+		if previousOffset < 0 || limit <= 0 {
+			previousOffset = 0
+		}
+		result.Previous = &Pagination{Limit: limit, Offset: previousOffset}
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// buildsCursor reports whether Query builds the next cursor of the JsonMap, so that the
+// sort columns have to be read along with the projection fields.
+func (s *Service) buildsCursor(jsonMap *JsonMap) bool {
+	return s.fieldsMap != nil && s.fieldsMap.TiebreakerField != "" && len(s.cursorSigningKey) > 0 &&
+		jsonMap.Pagination.Limit > 0 && jsonMap.Aggregations == nil && !jsonMap.SortByRelevance
+}
+
+// NextCursor builds the cursor of the page following the given results, to be sent back
+// as Pagination.Cursor. It requires FieldsMap.TiebreakerField and Config.CursorSigningKey.
+// The cursor is bound to the sort conditions of the JsonMap: a request with other sort
+// conditions rejects it. The results must hold the sort fields, so the projection fields
+// of the JsonMap have to include them.
+//
+// Example usage:
+//
+//	results, _, _, err := tesoQL.Service.Get(&jsonMapVariable)
+//	if err != nil {
+//		// Handle error
+//	}
+//	next, err := tesoQL.Service.NextCursor(&jsonMapVariable, results)
+//
+// Returns:
+//
+// - string: The cursor of the next page, or an empty string when the results are the last page.
+//
+// - *ErrorResponseDTO: An error response if the cursor could not be built.
+func (s *Service) NextCursor(jsonMap *JsonMap, results []map[string]interface{}) (string, *ErrorResponseDTO) {
+	if len(results) == 0 || jsonMap.Pagination.Limit <= 0 || int64(len(results)) < jsonMap.Pagination.Limit {
+		return "", nil
+	}
+	if jsonMap.SortByRelevance {
+		return "", newResponse(TESOQL_VALIDATION_ERROR, "Sorting by relevance cannot be combined with cursor pagination.", CURSOR_ERR_CODE).withField("sortByRelevance")
+	}
+	return newCursor(s.fieldsMap, jsonMap, results[len(results)-1], s.cursorSigningKey)
+}
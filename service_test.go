@@ -0,0 +1,226 @@
+package tesoql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeRepository serves in-memory records sorted by "id", paging them with the offset,
+// or with the keyset on "id" when a cursor is given, and projecting them the way the
+// built-in repositories do.
+type fakeRepository struct {
+	fieldsMap *FieldsMap
+	records   []map[string]interface{}
+	calls     []*JsonMap
+	deadlines []time.Time
+}
+
+func (r *fakeRepository) Find(ctx context.Context, jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO) {
+	call := *jsonMap
+	r.calls = append(r.calls, &call)
+	deadline, _ := ctx.Deadline()
+	r.deadlines = append(r.deadlines, deadline)
+
+	var matching []map[string]interface{}
+	for _, record := range r.records {
+		if jsonMap.keyset != nil && toInt(record["id"]) <= toInt(jsonMap.keyset[len(jsonMap.keyset)-1]) {
+			continue
+		}
+		matching = append(matching, record)
+	}
+	if offset := jsonMap.Pagination.Offset; jsonMap.keyset == nil && offset > 0 {
+		if offset > int64(len(matching)) {
+			offset = int64(len(matching))
+		}
+		matching = matching[offset:]
+	}
+	if limit := jsonMap.Pagination.Limit; limit > 0 && int64(len(matching)) > limit {
+		matching = matching[:limit]
+	}
+
+	results := make([]map[string]interface{}, 0, len(matching))
+	for _, record := range matching {
+		results = append(results, r.project(jsonMap, record))
+	}
+	totalCount := 0
+	if jsonMap.TotalCount {
+		totalCount = len(r.records)
+	}
+	return results, totalCount, len(results), nil
+}
+
+func (r *fakeRepository) project(jsonMap *JsonMap, record map[string]interface{}) map[string]interface{} {
+	projected := make(map[string]interface{})
+	if len(jsonMap.ProjectionFields) == 0 {
+		for key, value := range record {
+			projected[key] = value
+		}
+		return projected
+	}
+	for _, field := range jsonMap.ProjectionFields {
+		column := r.fieldsMap.ProjectionFields[field]
+		projected[column] = record[column]
+	}
+	for _, column := range keysetProjection(r.fieldsMap, jsonMap) {
+		projected[column] = record[column]
+	}
+	return projected
+}
+
+func newFakeService(cfg *Config, count int) (*Service, *fakeRepository) {
+	repo := &fakeRepository{fieldsMap: cfg.FieldsMap}
+	for i := 1; i <= count; i++ {
+		repo.records = append(repo.records, map[string]interface{}{"id": int64(i), "name": "record", "status": "shipped"})
+	}
+	return newTesoQlService(repo, cfg), repo
+}
+
+func TestWithDefaultTimeout(t *testing.T) {
+	callerCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	callerDeadline, _ := callerCtx.Deadline()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		timeout  time.Duration
+		deadline time.Duration // expected deadline from now, 0 for none
+		keep     bool          // the caller deadline is kept
+	}{
+		{name: "default", ctx: context.Background(), deadline: DEFAULT_QUERY_TIMEOUT},
+		{name: "configured", ctx: context.Background(), timeout: 3 * time.Second, deadline: 3 * time.Second},
+		{name: "disabled", ctx: context.Background(), timeout: -1},
+		{name: "caller deadline", ctx: callerCtx, timeout: 3 * time.Second, keep: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := withDefaultTimeout(tt.ctx, tt.timeout)
+			defer cancel()
+			deadline, hasDeadline := ctx.Deadline()
+			switch {
+			case tt.keep:
+				if deadline != callerDeadline {
+					t.Errorf("deadline = %v, want the caller deadline %v", deadline, callerDeadline)
+				}
+			case tt.deadline == 0:
+				if hasDeadline {
+					t.Errorf("deadline = %v, want none", deadline)
+				}
+			default:
+				if remaining := time.Until(deadline); !hasDeadline || remaining > tt.deadline || remaining < tt.deadline-time.Second {
+					t.Errorf("deadline in %v, want %v", remaining, tt.deadline)
+				}
+			}
+		})
+	}
+}
+
+func TestGetContextBoundsTheRepository(t *testing.T) {
+	service, repo := newFakeService(&Config{DefaultTimeout: 2 * time.Second}, 3)
+	if _, _, size, err := service.GetContext(context.Background(), &JsonMap{Pagination: Pagination{Limit: 2}}); err != nil || size != 2 {
+		t.Fatalf("GetContext() = %d, %v", size, err)
+	}
+	if remaining := time.Until(repo.deadlines[0]); remaining > 2*time.Second || remaining <= 0 {
+		t.Errorf("repository deadline in %v, want at most 2s", remaining)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Hour))
+	defer cancel()
+	want, _ := ctx.Deadline()
+	if _, _, _, err := service.GetContext(ctx, &JsonMap{}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.deadlines[1] != want {
+		t.Errorf("repository deadline = %v, want the caller deadline %v", repo.deadlines[1], want)
+	}
+}
+
+func TestQueryResult(t *testing.T) {
+	totalCount := 6
+	tests := []struct {
+		name       string
+		cfg        *Config
+		pagination Pagination
+		totalCount bool
+		want       Result
+	}{
+		{
+			name:       "first page",
+			cfg:        &Config{},
+			pagination: Pagination{Limit: 4},
+			totalCount: true,
+			want:       Result{Size: 4, TotalCount: &totalCount, HasMore: true, Limit: 4, Next: &Pagination{Limit: 4, Offset: 4}},
+		},
+		{
+			name:       "middle page",
+			cfg:        &Config{},
+			pagination: Pagination{Limit: 2, Offset: 2},
+			want:       Result{Size: 2, HasMore: true, Limit: 2, Offset: 2, Next: &Pagination{Limit: 2, Offset: 4}, Previous: &Pagination{Limit: 2}},
+		},
+		{
+			name:       "last page",
+			cfg:        &Config{},
+			pagination: Pagination{Limit: 4, Offset: 4},
+			want:       Result{Size: 2, Limit: 4, Offset: 4, Previous: &Pagination{Limit: 4}},
+		},
+		{
+			name:       "clamped limit",
+			cfg:        &Config{},
+			pagination: Pagination{Limit: 500},
+			want:       Result{Size: 6, Limit: 50, Warnings: []string{"Limit 500 is out of bounds, 50 is used."}},
+		},
+		{
+			name:       "total count disabled",
+			cfg:        &Config{Toggles: &ToggleConfig{DisableTotalCount: true}},
+			pagination: Pagination{Limit: 10},
+			totalCount: true,
+			want:       Result{Size: 6, Limit: 10, Warnings: []string{"Total count is disabled."}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newFakeService(tt.cfg, 6)
+			result, err := service.Query(context.Background(), &JsonMap{Pagination: tt.pagination, TotalCount: tt.totalCount})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Items) != result.Size {
+				t.Errorf("items = %d, size = %d", len(result.Items), result.Size)
+			}
+			result.Items, result.Duration = nil, 0
+			if !reflect.DeepEqual(*result, tt.want) {
+				t.Errorf("result = %+v\nwant     %+v", *result, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryCursorPages(t *testing.T) {
+	service, repo := newFakeService(&Config{
+		FieldsMap:        &FieldsMap{ProjectionFields: map[string]string{"name": "name"}, TiebreakerField: "id"},
+		CursorSigningKey: []byte("secret"),
+	}, 6)
+	first, err := service.Query(context.Background(), &JsonMap{ProjectionFields: []string{"name"}, Pagination: Pagination{Limit: 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.HasMore || first.Next == nil || first.Next.Cursor == "" || first.Next.Offset != 0 {
+		t.Fatalf("next = %+v, want a cursor", first.Next)
+	}
+	if _, exists := first.Items[0]["id"]; exists || !repo.calls[0].keysetColumns {
+		t.Errorf("items = %v, want the keyset column read then stripped", first.Items)
+	}
+
+	last, err := service.Query(context.Background(), &JsonMap{ProjectionFields: []string{"name"}, Pagination: *first.Next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Size != 2 || last.HasMore || last.Next != nil || last.Previous != nil {
+		t.Errorf("last page = %+v", last)
+	}
+	if keyset := repo.calls[1].keyset; len(keyset) != 1 || keyset[0] != int64(4) {
+		t.Errorf("keyset = %v, want [4]", keyset)
+	}
+}
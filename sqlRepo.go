@@ -1,107 +1,321 @@
 package tesoql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"sync"
 )
 
 type sqlRepository struct {
-	sql           *sql.DB
-	tableName     string
-	fieldsMap     *FieldsMap
-	printSqlQuery bool
+	sql              *sql.DB
+	tableName        string
+	dialect          *Dialect
+	fieldsMap        *FieldsMap
+	printSqlQuery    bool
+	windowTotalCount bool
+	ownsClient       bool // The database was opened by tesoql, and is closed by close.
 }
 
-func newSqlRepository(cfg *Config) *sqlRepository {
+func newSqlRepository(cfg *Config) (*sqlRepository, *ErrorResponseDTO) {
 	var db *sql.DB
 	client, ok := cfg.ConnectionConfig.Client.(*sql.DB)
-	if ok && client != nil {
+	ownsClient := !ok || client == nil
+	if !ownsClient {
 		db = client
 	} else {
 		var err error
 		db, err = sql.Open(cfg.Engine, cfg.ConnectionConfig.ConnectionString)
 		if err != nil {
-			panic(fmt.Sprintf("Error opening database connection: %v", err))
+			return nil, newResponse(TESOQL_CONNECTION_ERROR, fmt.Sprintf("Error opening database connection: %v", err), CONNECTION_OPEN_ERR_CODE).withCause(err)
 		}
 
-		err = db.Ping()
+		ctx, cancel := withDefaultTimeout(context.Background(), cfg.DefaultTimeout)
+		defer cancel()
+		err = db.PingContext(ctx)
 		if err != nil {
-			panic(fmt.Sprintf("Error pinging database: %v", err))
+			db.Close()
+			return nil, newResponse(TESOQL_CONNECTION_ERROR, fmt.Sprintf("Error pinging database: %v", err), CONNECTION_PING_ERR_CODE).withCause(err)
 		}
 	}
 
 	return &sqlRepository{
-		sql:           db,
-		tableName:     cfg.ConnectionConfig.TableName,
-		fieldsMap:     cfg.FieldsMap,
-		printSqlQuery: cfg.PrintSqlQuery,
+		sql:              db,
+		tableName:        cfg.ConnectionConfig.TableName,
+		dialect:          cfg.sqlDialect(),
+		fieldsMap:        cfg.FieldsMap,
+		printSqlQuery:    cfg.PrintSqlQuery,
+		windowTotalCount: cfg.WindowTotalCount,
+		ownsClient:       ownsClient,
+	}, nil
+}
+
+func (r *sqlRepository) Close() error {
+	if !r.ownsClient {
+		return nil
+	}
+	if err := r.sql.Close(); err != nil {
+		return newResponse(TESOQL_CONNECTION_ERROR, err.Error(), CONNECTION_CLOSE_ERR_CODE).withCause(err)
 	}
+	return nil
 }
 
-func (r *sqlRepository) repository(jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO) {
+func (r *sqlRepository) Find(ctx context.Context, jsonMap *JsonMap) ([]map[string]interface{}, int, int, *ErrorResponseDTO) {
 
-	query, whereClause, queryArgs := jsonMap.GetSqlQuery(r.fieldsMap, r.tableName, r.printSqlQuery)
-	var results []map[string]interface{}
+	if err := r.checkPaging(jsonMap); err != nil {
+		return nil, 0, 0, err
+	}
+
+	query := jsonMap.NewSqlQueryWithDialect(r.fieldsMap, r.dialect)
+	// the seek predicate of a cursor page would restrict the windowed count
+	windowCount := jsonMap.TotalCount && !jsonMap.SuppressDataResponse && r.windowTotalCount && r.dialect.WindowCount && jsonMap.keyset == nil
 
+	var countErr *ErrorResponseDTO
+	var totalCount int
+	var wg sync.WaitGroup
+	if jsonMap.TotalCount && !windowCount {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			totalCount, countErr = r.countTotal(ctx, query)
+		}()
+	}
+
+	var results []map[string]interface{}
+	var err *ErrorResponseDTO
 	if !jsonMap.SuppressDataResponse {
-		rows, err := r.sql.Query(query, queryArgs...)
-		if err != nil {
-			return nil, 0, 0, newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_QUERYEXEC_ERR_CODE)
-		}
-		defer rows.Close()
+		results, err = r.fetch(ctx, query, windowCount)
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, 0, 0, err
+	}
 
-		columns, err := rows.Columns()
-		if err != nil {
-			return nil, 0, 0, newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_COLUMNS_ERR_CODE)
+	if windowCount {
+		if len(results) > 0 {
+			totalCount = toInt(results[0][TOTAL_COUNT_COLUMN])
+			for _, row := range results {
+				delete(row, TOTAL_COUNT_COLUMN)
+			}
+		} else if query.offset > 0 {
+			// an empty page past the last row does not tell the total count
+			totalCount, countErr = r.countTotal(ctx, query)
 		}
+	}
+	if countErr != nil {
+		return nil, 0, 0, countErr
+	}
 
-		for rows.Next() {
-			columnPointers := make([]interface{}, len(columns))
-			row := make(map[string]interface{})
-			for i, col := range columns {
-				var colValue interface{}
-				columnPointers[i] = &colValue
-				row[col] = &colValue
-			}
-			if err := rows.Scan(columnPointers...); err != nil {
-				return nil, 0, 0, newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_SCAN_ERR_CODE)
-			}
+	return results, totalCount, len(results), nil
+}
 
-			for i, col := range columns {
-				row[col] = *(columnPointers[i].(*interface{}))
-			}
+func (r *sqlRepository) fetch(ctx context.Context, query *SqlQuery, windowCount bool) ([]map[string]interface{}, *ErrorResponseDTO) {
+	statement := query.statement(r.tableName, windowCount)
+	if r.printSqlQuery {
+		fmt.Printf("Query: %s\nWith Arguments: %v\n", statement, query.Args)
+	}
 
-			results = append(results, row)
-		}
+	rows, err := r.sql.QueryContext(ctx, statement, query.Args...)
+	if err != nil {
+		return nil, newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_QUERYEXEC_ERR_CODE).withCause(err)
 	}
+	defer rows.Close()
 
-	size := len(results)
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_COLUMNS_ERR_CODE).withCause(err)
+	}
 
-	var tesoQlErr *ErrorResponseDTO
-	var totalCount int
+	var results []map[string]interface{}
+	for rows.Next() {
+		row, err := scanRow(rows, columns)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_SCAN_ERR_CODE).withCause(err)
+	}
+	return results, nil
+}
+
+// Facets runs the query of every facet of the JsonMap in parallel.
+func (r *sqlRepository) Facets(ctx context.Context, jsonMap *JsonMap) (map[string][]FacetBucket, *ErrorResponseDTO) {
+	buckets := make([][]FacetBucket, len(jsonMap.Facets))
+	errs := make([]*ErrorResponseDTO, len(jsonMap.Facets))
 	var wg sync.WaitGroup
-	if jsonMap.TotalCount {
+	for i := range jsonMap.Facets {
 		wg.Add(1)
 
-		go func() {
+		go func(facet *Facet, i int) {
 			defer wg.Done()
-			totalCount, tesoQlErr = r.countTotal(whereClause, queryArgs)
-		}()
+			rows, err := r.fetch(ctx, getSqlFacet(r.fieldsMap, jsonMap, facet, r.dialect), false)
+			if err != nil {
+				errs[i] = err.withField(fmt.Sprintf("facets.%d", i))
+				return
+			}
+			buckets[i] = sqlFacetBuckets(facet, rows)
+		}(&jsonMap.Facets[i], i)
 	}
 	wg.Wait()
-	return results, totalCount, size, tesoQlErr
+
+	facets := make(map[string][]FacetBucket, len(jsonMap.Facets))
+	for i := range jsonMap.Facets {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		facets[jsonMap.Facets[i].name()] = buckets[i]
+	}
+	return facets, nil
 }
 
-func (r *sqlRepository) countTotal(whereClause string, queryArgs []interface{}) (int, *ErrorResponseDTO) {
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE 1=1", r.tableName) + whereClause
+// Distinct runs the GROUP BY query listing the distinct values of the field.
+func (r *sqlRepository) Distinct(ctx context.Context, field string, jsonMap *JsonMap) ([]DistinctValue, *ErrorResponseDTO) {
+	rows, err := r.fetch(ctx, getSqlDistinct(r.fieldsMap, jsonMap, field, r.dialect), false)
+	if err != nil {
+		return nil, err
+	}
+	return sqlDistinctValues(rows), nil
+}
+
+// DateHistogram runs the GROUP BY query of the date histogram of the JsonMap.
+func (r *sqlRepository) DateHistogram(ctx context.Context, jsonMap *JsonMap) ([]HistogramBucket, *ErrorResponseDTO) {
+	query, err := getSqlDateHistogram(r.fieldsMap, jsonMap, r.dialect)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.fetch(ctx, query, false)
+	if err != nil {
+		return nil, err
+	}
+	return sqlHistogramBuckets(jsonMap.DateHistogram, rows), nil
+}
 
-	row := r.sql.QueryRow(countQuery, queryArgs...)
+func (r *sqlRepository) countTotal(ctx context.Context, query *SqlQuery) (int, *ErrorResponseDTO) {
+	countQuery, countArgs := query.CountQuery(r.tableName)
+	if r.printSqlQuery {
+		fmt.Printf("Query: %s\nWith Arguments: %v\n", countQuery, countArgs)
+	}
+
+	row := r.sql.QueryRowContext(ctx, countQuery, countArgs...)
 
 	var count int
 	if err := row.Scan(&count); err != nil {
-		return 0, newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_COUNT_QUERYEXEC_ERR_CODE)
+		return 0, newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_COUNT_QUERYEXEC_ERR_CODE).withCause(err)
 	}
 
 	return count, nil
 }
+
+func scanRow(rows *sql.Rows, columns []string) (map[string]interface{}, *ErrorResponseDTO) {
+	columnPointers := make([]interface{}, len(columns))
+	row := make(map[string]interface{})
+	for i, col := range columns {
+		var colValue interface{}
+		columnPointers[i] = &colValue
+		row[col] = &colValue
+	}
+	if err := rows.Scan(columnPointers...); err != nil {
+		return nil, newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_SCAN_ERR_CODE).withCause(err)
+	}
+
+	for i, col := range columns {
+		row[col] = *(columnPointers[i].(*interface{}))
+	}
+	delete(row, ROWNUM_COLUMN)
+	return row, nil
+}
+
+func (r *sqlRepository) checkPaging(jsonMap *JsonMap) *ErrorResponseDTO {
+	if r.dialect.PagingStyle == PAGING_TOP && jsonMap.Pagination.Offset > 0 {
+		return newResponse(TESOQL_SQL_ERROR, fmt.Sprintf("Offset is not supported by the '%s' dialect.", r.dialect.Name), SQL_PAGING_ERR_CODE).withField("pagination.offset")
+	}
+	return nil
+}
+
+// Stream runs the query of the JsonMap and returns an iterator scanning the rows one
+// by one as the driver reads them. The total count is not computed.
+func (r *sqlRepository) Stream(ctx context.Context, jsonMap *JsonMap) (RowIterator, *ErrorResponseDTO) {
+	if err := r.checkPaging(jsonMap); err != nil {
+		return nil, err
+	}
+	query := jsonMap.NewSqlQueryWithDialect(r.fieldsMap, r.dialect)
+	statement := query.statement(r.tableName, false)
+	if r.printSqlQuery {
+		fmt.Printf("Query: %s\nWith Arguments: %v\n", statement, query.Args)
+	}
+
+	rows, err := r.sql.QueryContext(ctx, statement, query.Args...)
+	if err != nil {
+		return nil, newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_QUERYEXEC_ERR_CODE).withCause(err)
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		return nil, newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_COLUMNS_ERR_CODE).withCause(err)
+	}
+	columns := make([]string, len(columnTypes))
+	for i, columnType := range columnTypes {
+		columns[i] = columnType.Name()
+	}
+	return &sqlRowIterator{rows: rows, columns: columns, columnTypes: columnTypes}, nil
+}
+
+type sqlRowIterator struct {
+	rows        *sql.Rows
+	columns     []string
+	columnTypes []*sql.ColumnType
+	row         map[string]interface{}
+	err         error
+}
+
+func (it *sqlRowIterator) Next() bool {
+	if it.err != nil || !it.rows.Next() {
+		return false
+	}
+	row, err := scanRow(it.rows, it.columns)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.row = row
+	return true
+}
+
+// columnSchema returns the columns of the rows, with the kinds inferred from their database type.
+func (it *sqlRowIterator) columnSchema() []Column {
+	var columns []Column
+	for _, columnType := range it.columnTypes {
+		if columnType.Name() == ROWNUM_COLUMN {
+			continue
+		}
+		column := Column{Name: columnType.Name(), Field: columnType.Name(), Kind: sqlColumnKind(columnType)}
+		if column.Kind == COLUMN_DECIMAL {
+			if precision, scale, ok := columnType.DecimalSize(); ok {
+				column.Precision, column.Scale = precision, scale
+			}
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+func (it *sqlRowIterator) Row() map[string]interface{} {
+	return it.row
+}
+
+func (it *sqlRowIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	if err := it.rows.Err(); err != nil {
+		return newResponse(TESOQL_SQL_ERROR, err.Error(), SQL_SCAN_ERR_CODE).withCause(err)
+	}
+	return nil
+}
+
+func (it *sqlRowIterator) Close() error {
+	return it.rows.Close()
+}
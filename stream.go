@@ -0,0 +1,228 @@
+package tesoql
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RowIterator yields the records of a query one by one. Next advances to the next
+// record and reports whether there is one, Row returns it, Err returns the error that
+// stopped the iteration, if any, and Close releases the underlying cursor.
+type RowIterator interface {
+	Next() bool
+	Row() map[string]interface{}
+	Err() error
+	Close() error
+}
+
+// StreamingRepository is a Repository that can stream records as the database cursor
+// advances, instead of loading the whole page in memory. The built-in Mongo and SQL
+// repositories implement it, Service.Stream falls back to Find for the others.
+type StreamingRepository interface {
+	Repository
+	Stream(ctx context.Context, jsonMap *JsonMap) (RowIterator, *ErrorResponseDTO)
+}
+
+// Rows is the result of Service.Stream, read like a *sql.Rows. It is closed
+// automatically once Next returns false, and must be closed otherwise.
+//
+// Example usage:
+//
+//	rows, err := tesoQL.Service.Stream(ctx, &jsonMapVariable)
+//	if err != nil {
+//		// Handle error
+//	}
+//	defer rows.Close()
+//	for rows.Next() {
+//		var order Order
+//		if err := rows.Scan(&order); err != nil {
+//			// Handle error
+//		}
+//	}
+//	if err := rows.Err(); err != nil {
+//		// Handle error
+//	}
+type Rows struct {
+	iterator     RowIterator
+	engine       string
+	fieldsMap    *FieldsMap
+	projection   []string
+	aggregations *Aggregations
+	ctx          *roundTripContext
+	err          error
+	closed       bool
+}
+
+// Next advances to the next record, and reports whether there is one.
+//
+// Returns:
+//
+// - bool: true when a record is available through Row and Scan.
+func (r *Rows) Next() bool {
+	if r.closed {
+		return false
+	}
+	var next bool
+	r.ctx.roundTrip(func() { next = r.iterator.Next() })
+	if next {
+		return true
+	}
+	r.err = r.iterator.Err()
+	r.Close()
+	return false
+}
+
+// Row returns the current record, as Service.Get would.
+//
+// Returns:
+//
+// - map[string]interface{}: The current record.
+func (r *Rows) Row() map[string]interface{} {
+	return r.iterator.Row()
+}
+
+// Scan decodes the current record into dest, a pointer to a struct, the way QueryAs does.
+//
+// Returns:
+//
+// - error: An *ErrorResponseDTO with RESULT_DECODE_ERR_CODE if the record cannot be decoded.
+func (r *Rows) Scan(dest interface{}) error {
+	return decodeRecord(r.engine, r.iterator.Row(), dest).AsError()
+}
+
+// Err returns the error that stopped the iteration, if any.
+//
+// Returns:
+//
+// - error: The error of the iteration, or nil when all records were read.
+func (r *Rows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.closed {
+		return nil
+	}
+	return r.iterator.Err()
+}
+
+// Close releases the database cursor. It can be called several times.
+//
+// Returns:
+//
+// - error: The error of the cursor, if any.
+func (r *Rows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	defer r.ctx.cancel(context.Canceled)
+	return r.iterator.Close()
+}
+
+// Stream retrieves data like GetContext, but yields the records one by one as the
+// database cursor advances instead of loading them all in memory. The JsonMap goes through
+// the same toggle, filter and cursor validations, and its projection, sorting and
+// pagination are honored as they are: a zero limit streams every matching record. The
+// total count is not computed.
+//
+// When ctx has no deadline, Config.DefaultTimeout bounds each round-trip to the database,
+// the query and every advance of the cursor, rather than the whole iteration: the time
+// the caller spends on the records does not count, so that long exports are not cut off.
+//
+// Returns:
+//
+// - *Rows: The records, to be read with Next and Row or Scan, and closed.
+//
+// - *ErrorResponseDTO: An error response, if any occurred during validation or while running the query.
+func (s *Service) Stream(ctx context.Context, jsonMap *JsonMap) (*Rows, *ErrorResponseDTO) {
+	validationErr := s.validate(jsonMap)
+	if validationErr != nil {
+		return nil, validationErr
+	}
+	streamCtx := newRoundTripContext(ctx, s.defaultTimeout)
+
+	var iterator RowIterator
+	var err *ErrorResponseDTO
+	if streaming, ok := s.repo.(StreamingRepository); ok {
+		streamCtx.roundTrip(func() { iterator, err = streaming.Stream(streamCtx, jsonMap) })
+	} else {
+		page := *jsonMap
+		page.TotalCount = false
+		var results []map[string]interface{}
+		streamCtx.roundTrip(func() { results, _, _, err = s.repo.Find(streamCtx, &page) })
+		iterator = &sliceIterator{records: results, index: -1}
+	}
+	if err != nil {
+		streamCtx.cancel(context.Canceled)
+		return nil, err
+	}
+	return &Rows{iterator: iterator, engine: s.engine, fieldsMap: s.fieldsMap, projection: jsonMap.ProjectionFields, aggregations: jsonMap.Aggregations, ctx: streamCtx}, nil
+}
+
+// roundTripContext is the context of a stream. When the caller's context has no deadline,
+// it is canceled with context.DeadlineExceeded once a round-trip to the database outlasts
+// the default timeout, the time spent between round-trips not counting.
+type roundTripContext struct {
+	context.Context
+	cancel  context.CancelCauseFunc
+	timeout time.Duration // none when zero
+}
+
+// newRoundTripContext wraps ctx with the default timeout of the round-trips
+// (DEFAULT_QUERY_TIMEOUT when zero, none when negative), unless ctx already has a deadline.
+func newRoundTripContext(ctx context.Context, timeout time.Duration) *roundTripContext {
+	if _, hasDeadline := ctx.Deadline(); hasDeadline || timeout < 0 {
+		timeout = 0
+	} else if timeout == 0 {
+		timeout = DEFAULT_QUERY_TIMEOUT
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &roundTripContext{Context: ctx, cancel: cancel, timeout: timeout}
+}
+
+// roundTrip runs fn, a call to the database, bounded by the timeout.
+func (c *roundTripContext) roundTrip(fn func()) {
+	if c.timeout == 0 {
+		fn()
+		return
+	}
+	timer := time.AfterFunc(c.timeout, func() { c.cancel(context.DeadlineExceeded) })
+	defer timer.Stop()
+	fn()
+}
+
+// Err reports context.DeadlineExceeded, rather than context.Canceled, once a round-trip timed out.
+func (c *roundTripContext) Err() error {
+	err := c.Context.Err()
+	if cause := context.Cause(c.Context); err != nil && errors.Is(cause, context.DeadlineExceeded) {
+		return cause
+	}
+	return err
+}
+
+// sliceIterator iterates over records already loaded in memory.
+type sliceIterator struct {
+	records []map[string]interface{}
+	index   int
+}
+
+func (it *sliceIterator) Next() bool {
+	if it.index+1 >= len(it.records) {
+		return false
+	}
+	it.index++
+	return true
+}
+
+func (it *sliceIterator) Row() map[string]interface{} {
+	return it.records[it.index]
+}
+
+func (it *sliceIterator) Err() error {
+	return nil
+}
+
+func (it *sliceIterator) Close() error {
+	return nil
+}
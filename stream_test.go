@@ -0,0 +1,108 @@
+package tesoql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// streamingFakeRepository streams its records through a RowIterator, that fails with
+// err once the records are exhausted.
+type streamingFakeRepository struct {
+	fakeRepository
+	err      error
+	iterator *failingIterator
+	ctx      context.Context
+}
+
+func (r *streamingFakeRepository) Stream(ctx context.Context, jsonMap *JsonMap) (RowIterator, *ErrorResponseDTO) {
+	r.ctx = ctx
+	r.iterator = &failingIterator{sliceIterator: sliceIterator{records: r.records, index: -1}, err: r.err}
+	return r.iterator, nil
+}
+
+type failingIterator struct {
+	sliceIterator
+	err    error
+	closed bool
+}
+
+func (it *failingIterator) Err() error {
+	if it.index+1 >= len(it.records) {
+		return it.err
+	}
+	return nil
+}
+
+func (it *failingIterator) Close() error {
+	it.closed = true
+	return nil
+}
+
+func TestStreamFallsBackToFind(t *testing.T) {
+	service, repo := newFakeService(&Config{}, 3)
+	rows, err := service.Stream(context.Background(), &JsonMap{TotalCount: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var order typedOrder
+		if err := rows.Scan(&order); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, order.Id)
+	}
+	if rows.Err() != nil || len(ids) != 3 || ids[2] != 3 {
+		t.Errorf("ids = %v, err = %v", ids, rows.Err())
+	}
+	if repo.calls[0].TotalCount {
+		t.Error("the fallback counted the records")
+	}
+}
+
+func TestStreamIterator(t *testing.T) {
+	driverErr := errors.New("connection reset")
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "exhausted"},
+		{name: "failed", err: driverErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &streamingFakeRepository{err: tt.err}
+			repo.records = []map[string]interface{}{{"id": int64(1)}, {"id": int64(2)}}
+			rows, errDTO := newTesoQlService(repo, &Config{}).Stream(context.Background(), &JsonMap{})
+			if errDTO != nil {
+				t.Fatal(errDTO)
+			}
+			count := 0
+			for rows.Next() {
+				count++
+			}
+			if count != 2 || !errors.Is(rows.Err(), tt.err) || (tt.err == nil && rows.Err() != nil) {
+				t.Errorf("count = %d, err = %v, want 2, %v", count, rows.Err(), tt.err)
+			}
+			if !repo.iterator.closed || repo.ctx.Err() == nil {
+				t.Error("the iterator was not closed once exhausted")
+			}
+			if rows.Next() || rows.Close() != nil {
+				t.Error("the rows were read after being closed")
+			}
+		})
+	}
+}
+
+func TestStreamValidates(t *testing.T) {
+	service, repo := newFakeService(&Config{Toggles: &ToggleConfig{DisableSorting: true}}, 3)
+	_, err := service.Stream(context.Background(), &JsonMap{SortConditions: []SortInput{{Field: "id", SortCondition: "DESC"}}})
+	if !errors.Is(err, ErrSortableToggle) {
+		t.Errorf("Stream() = %v, want ErrSortableToggle", err)
+	}
+	if len(repo.calls) != 0 {
+		t.Error("the repository was queried")
+	}
+}
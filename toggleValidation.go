@@ -1,5 +1,7 @@
 package tesoql
 
+import "fmt"
+
 func validateToggles(jsonMap *JsonMap, toggleConfig *ToggleConfig) *ErrorResponseDTO {
 	if toggleConfig != nil {
 		err := validateUpperToggles(jsonMap, toggleConfig)
@@ -14,6 +16,10 @@ func validateToggles(jsonMap *JsonMap, toggleConfig *ToggleConfig) *ErrorRespons
 		if err != nil {
 			return err
 		}
+		err = validateAggregationToggles(jsonMap, toggleConfig)
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -24,65 +30,154 @@ func validateUpperToggles(jsonMap *JsonMap, t *ToggleConfig) *ErrorResponseDTO {
 	}
 
 	if t.DisableSearch && len(jsonMap.Search) > 0 {
-		return newResponse(TESOQL_TOGGLE_ERROR, "DisableSearch toggle is open.", SEARCHABLE_TOGGLE_ERR_CODE)
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableSearch toggle is open.", SEARCHABLE_TOGGLE_ERR_CODE).withField("search")
+	}
+	if t.DisableSearch && len(jsonMap.SearchExclude) > 0 {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableSearch toggle is open.", SEARCHABLE_TOGGLE_ERR_CODE).withField("searchExclude")
+	}
+	if t.DisableSearch && jsonMap.Query != "" {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableSearch toggle is open.", SEARCHABLE_TOGGLE_ERR_CODE).withField("q")
 	}
 
 	if t.DisableProjection && len(jsonMap.ProjectionFields) > 0 {
-		return newResponse(TESOQL_TOGGLE_ERROR, "DisableProjection toggle is open.", PROJECTION_TOGGLE_ERR_CODE)
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableProjection toggle is open.", PROJECTION_TOGGLE_ERR_CODE).withField("projectionFields")
 	}
 
 	if t.DisableSorting && len(jsonMap.SortConditions) > 0 {
-		return newResponse(TESOQL_TOGGLE_ERROR, "DisableSorting toggle is open.", SORTABLE_TOGGLE_ERR_CODE)
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableSorting toggle is open.", SORTABLE_TOGGLE_ERR_CODE).withField("sortConditions")
+	}
+	if t.DisableSorting && jsonMap.SortByRelevance {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableSorting toggle is open.", SORTABLE_TOGGLE_ERR_CODE).withField("sortByRelevance")
 	}
 
-	if t.DisablePagination && (jsonMap.Pagination.Limit > 0 || jsonMap.Pagination.Offset > 0) {
-		return newResponse(TESOQL_TOGGLE_ERROR, "DisablePagination toggle is open.", PAGINATION_TOGGLE_ERR_CODE)
+	if t.DisablePagination && (jsonMap.Pagination.Limit > 0 || jsonMap.Pagination.Offset > 0 || jsonMap.Pagination.Cursor != "") {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisablePagination toggle is open.", PAGINATION_TOGGLE_ERR_CODE).withField("pagination")
 	}
 
 	if t.DisableConditioning && len(jsonMap.Conditions) > 0 {
-		return newResponse(TESOQL_TOGGLE_ERROR, "DisableConditioning toggle is open.", CONDITION_TOGGLE_ERR_CODE)
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableConditioning toggle is open.", CONDITION_TOGGLE_ERR_CODE).withField("conditions")
+	}
+	if t.DisableConditioning && jsonMap.Filter != nil {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableConditioning toggle is open.", CONDITION_TOGGLE_ERR_CODE).withField("filter")
+	}
+
+	if t.DisableAggregations && jsonMap.Aggregations != nil {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableAggregations toggle is open.", AGGREGATION_TOGGLE_ERR_CODE).withField("aggregations")
+	}
+
+	if t.DisableFacets && len(jsonMap.Facets) > 0 {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableFacets toggle is open.", FACET_TOGGLE_ERR_CODE).withField("facets")
+	}
+
+	if t.DisableDateHistogram && jsonMap.DateHistogram != nil {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableDateHistogram toggle is open.", HISTOGRAM_TOGGLE_ERR_CODE).withField("dateHistogram")
 	}
 
 	return nil
 }
 
 func validateConditionToggles(jsonMap *JsonMap, toggleConfig *ToggleConfig) *ErrorResponseDTO {
-	for _, ops := range jsonMap.Conditions {
-		if toggleConfig.ConditioningToggles != nil {
-			if toggleConfig != nil && toggleConfig.ConditioningToggles != nil {
-				if toggleConfig.ConditioningToggles.DisableGreaterThan && ops.GreaterThan != nil {
-					return newResponse(TESOQL_TOGGLE_ERROR, "DisableGreaterThan toggle is open.", GREATERTHAN_CONDITION_TOGGLE_ERR_CODE)
-				}
-				if toggleConfig.ConditioningToggles.DisableGreaterOrEqual && ops.GreaterOrEqual != nil {
-					return newResponse(TESOQL_TOGGLE_ERROR, "DisableGreaterOrEqual toggle is open.", GREATEROREQUAL_CONDITION_TOGGLE_ERR_CODE)
-				}
-				if toggleConfig.ConditioningToggles.DisableLowerThan && ops.LowerThan != nil {
-					return newResponse(TESOQL_TOGGLE_ERROR, "DisableLowerThan toggle is open.", LOWERTHAN_CONDITION_TOGGLE_ERR_CODE)
-				}
-				if toggleConfig.ConditioningToggles.DisableLowerOrEqual && ops.LowerOrEqual != nil {
-					return newResponse(TESOQL_TOGGLE_ERROR, "DisableLowerOrEqual toggle is open.", LOWEROREQUAL_CONDITION_TOGGLE_ERR_CODE)
-				}
-				if toggleConfig.ConditioningToggles.DisableValuesToExclude && ops.ValuesToExclude != nil {
-					return newResponse(TESOQL_TOGGLE_ERROR, "DisableValuesToExclude toggle is open.", VALUESTOEXCLUDE_CONDITION_TOGGLE_ERR_CODE)
-				}
-				if toggleConfig.ConditioningToggles.DisableValuesToExactMatch && ops.ValuesToExactMatch != nil {
-					return newResponse(TESOQL_TOGGLE_ERROR, "DisableValuesToExactMatch toggle is open.", VALUESTOEXACTMATCH_CONDITION_TOGGLE_ERR_CODE)
-
-				}
+	if toggleConfig.ConditioningToggles == nil {
+		return nil
+	}
+	for _, key := range sortedKeys(jsonMap.Conditions) {
+		err := validateOperatorToggles(jsonMap.Conditions[key], toggleConfig.ConditioningToggles)
+		if err != nil {
+			return err.withField("conditions." + key)
+		}
+	}
+	if jsonMap.Filter != nil {
+		return validateFilterToggles(jsonMap.Filter, toggleConfig.ConditioningToggles)
+	}
+	return nil
+}
+
+func validateFilterToggles(node *Filter, t *ConditioningToggles) *ErrorResponseDTO {
+	for _, children := range [][]Filter{node.And, node.Or} {
+		for i := range children {
+			if err := validateFilterToggles(&children[i], t); err != nil {
+				return err
 			}
 		}
 	}
+	if node.Not != nil {
+		return validateFilterToggles(node.Not, t)
+	}
+	if node.Operators != nil {
+		if err := validateOperatorToggles(*node.Operators, t); err != nil {
+			return err.withField("filter." + node.Field)
+		}
+	}
+	return nil
+}
+
+func validateOperatorToggles(ops ConditionOperators, t *ConditioningToggles) *ErrorResponseDTO {
+	if t.DisableGreaterThan && ops.GreaterThan != nil {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableGreaterThan toggle is open.", GREATERTHAN_CONDITION_TOGGLE_ERR_CODE)
+	}
+	if t.DisableGreaterOrEqual && ops.GreaterOrEqual != nil {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableGreaterOrEqual toggle is open.", GREATEROREQUAL_CONDITION_TOGGLE_ERR_CODE)
+	}
+	if t.DisableLowerThan && ops.LowerThan != nil {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableLowerThan toggle is open.", LOWERTHAN_CONDITION_TOGGLE_ERR_CODE)
+	}
+	if t.DisableLowerOrEqual && ops.LowerOrEqual != nil {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableLowerOrEqual toggle is open.", LOWEROREQUAL_CONDITION_TOGGLE_ERR_CODE)
+	}
+	if t.DisableValuesToExclude && ops.ValuesToExclude != nil {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableValuesToExclude toggle is open.", VALUESTOEXCLUDE_CONDITION_TOGGLE_ERR_CODE)
+	}
+	if t.DisableValuesToExactMatch && ops.ValuesToExactMatch != nil {
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableValuesToExactMatch toggle is open.", VALUESTOEXACTMATCH_CONDITION_TOGGLE_ERR_CODE)
+	}
 	return nil
 }
 
 func validateSortingToggles(jm *JsonMap, t *ToggleConfig) *ErrorResponseDTO {
 	for _, sortInput := range jm.SortConditions {
 		if sortInput.SortCondition == "ASC" && t.SortingToggles != nil && t.SortingToggles.DisableLowToHigh {
-			return newResponse(TESOQL_TOGGLE_ERROR, "DisableLowToHigh toggle is open.", LOWTOHIGH_CONDITION_TOGGLE_ERR_CODE)
+			return newResponse(TESOQL_TOGGLE_ERROR, "DisableLowToHigh toggle is open.", LOWTOHIGH_CONDITION_TOGGLE_ERR_CODE).withField("sortConditions." + sortInput.Field)
 		}
 		if sortInput.SortCondition == "DESC" && t.SortingToggles != nil && t.SortingToggles.DisableHighToLow {
-			return newResponse(TESOQL_TOGGLE_ERROR, "DisableHighToLow toggle is open.", HIGHTOLOW_CONDITION_TOGGLE_ERR_CODE)
+			return newResponse(TESOQL_TOGGLE_ERROR, "DisableHighToLow toggle is open.", HIGHTOLOW_CONDITION_TOGGLE_ERR_CODE).withField("sortConditions." + sortInput.Field)
+		}
+	}
+	return nil
+}
+
+func validateAggregationToggles(jm *JsonMap, t *ToggleConfig) *ErrorResponseDTO {
+	if t.AggregationToggles == nil {
+		return nil
+	}
+	if jm.Aggregations != nil {
+		for i, metric := range jm.Aggregations.Metrics {
+			if err := validateMetricToggles(metric, t.AggregationToggles); err != nil {
+				return err.withField(fmt.Sprintf("aggregations.metrics.%d", i))
+			}
+		}
+	}
+	if jm.DateHistogram != nil {
+		for i, metric := range jm.DateHistogram.Metrics {
+			if err := validateMetricToggles(metric, t.AggregationToggles); err != nil {
+				return err.withField(fmt.Sprintf("dateHistogram.metrics.%d", i))
+			}
 		}
 	}
 	return nil
 }
+
+func validateMetricToggles(metric Metric, toggles *AggregationToggles) *ErrorResponseDTO {
+	switch {
+	case metric.Function == METRIC_COUNT && toggles.DisableCount:
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableCount toggle is open.", COUNT_METRIC_TOGGLE_ERR_CODE)
+	case metric.Function == METRIC_SUM && toggles.DisableSum:
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableSum toggle is open.", SUM_METRIC_TOGGLE_ERR_CODE)
+	case metric.Function == METRIC_AVG && toggles.DisableAvg:
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableAvg toggle is open.", AVG_METRIC_TOGGLE_ERR_CODE)
+	case metric.Function == METRIC_MIN && toggles.DisableMin:
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableMin toggle is open.", MIN_METRIC_TOGGLE_ERR_CODE)
+	case metric.Function == METRIC_MAX && toggles.DisableMax:
+		return newResponse(TESOQL_TOGGLE_ERROR, "DisableMax toggle is open.", MAX_METRIC_TOGGLE_ERR_CODE)
+	}
+	return nil
+}
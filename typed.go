@@ -0,0 +1,274 @@
+package tesoql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// QueryAs retrieves data like Service.GetContext and decodes every record into a T, a
+// struct or a pointer to a struct. Mongo documents are decoded with the bson package,
+// following the `bson` struct tags. SQL rows, and the records of registered engines, are
+// decoded column by column into the field whose `db` tag, `json` tag or name matches the
+// column name (case-insensitively). Fields of columns that are not projected keep their
+// zero value.
+//
+// SQL values are converted to the field type when needed: []byte and strings into
+// strings, numbers, booleans and time.Time (RFC3339 or "2006-01-02 15:04:05"),
+// numbers into other numeric types and booleans. Fields implementing sql.Scanner
+// (sql.NullString...) scan the raw value.
+//
+// Example usage:
+//
+//	type Order struct {
+//		Id          int64      `db:"id" bson:"_id"`
+//		ProductName string     `db:"product_name" bson:"productName"`
+//		Amount      float64    `db:"amount" bson:"amount"`
+//		CreatedAt   *time.Time `db:"created_at" bson:"createdAt"`
+//	}
+//
+//	orders, totalCount, size, err := tesoql.QueryAs[Order](ctx, tesoQL.Service, &jsonMapVariable)
+//
+// Returns:
+//
+// - []T: The decoded records.
+//
+// - int: The total count of records that match the query.
+//
+// - int: The size of the current page of results.
+//
+// - *ErrorResponseDTO: An error response, if any occurred during validation, retrieval or decoding.
+func QueryAs[T any](ctx context.Context, s *Service, jsonMap *JsonMap) ([]T, int, int, *ErrorResponseDTO) {
+	results, totalCount, size, err := s.GetContext(ctx, jsonMap)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	items, err := decodeRecords[T](s.engine, results)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return items, totalCount, size, nil
+}
+
+func decodeRecords[T any](engine string, records []map[string]interface{}) ([]T, *ErrorResponseDTO) {
+	items := make([]T, len(records))
+	for i, record := range records {
+		if err := decodeRecord(engine, record, &items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// decodeRecord decodes a record into dest, a pointer to a struct or to a pointer to a struct.
+func decodeRecord(engine string, record map[string]interface{}, dest interface{}) *ErrorResponseDTO {
+	if engine == MONGO_ENGINE {
+		raw, err := bson.Marshal(record)
+		if err == nil {
+			err = bson.Unmarshal(raw, dest)
+		}
+		if err != nil {
+			return newResponse(TESOQL_DECODE_ERROR, err.Error(), RESULT_DECODE_ERR_CODE).withCause(err)
+		}
+		return nil
+	}
+
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return newResponse(TESOQL_DECODE_ERROR, fmt.Sprintf("Records cannot be decoded into %T, a non-nil pointer is expected.", dest), RESULT_DECODE_ERR_CODE)
+	}
+	target = target.Elem()
+	structType := target.Type()
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return newResponse(TESOQL_DECODE_ERROR, fmt.Sprintf("Records cannot be decoded into %s, a struct is expected.", target.Type()), RESULT_DECODE_ERR_CODE)
+	}
+	if target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(structType))
+		}
+		target = target.Elem()
+	}
+
+	fields := structFields(structType)
+	for _, column := range sortedKeys(record) {
+		index, exists := fields[strings.ToLower(column)]
+		if !exists {
+			continue
+		}
+		if err := assignValue(target.FieldByIndex(index), record[column]); err != nil {
+			return newResponse(TESOQL_DECODE_ERROR, fmt.Sprintf("Column '%s': %v", column, err), RESULT_DECODE_ERR_CODE).withCause(err)
+		}
+	}
+	return nil
+}
+
+var structFieldsCache sync.Map // reflect.Type -> map[string][]int
+
+// structFields maps the lower cased column names of a struct to the index of their field.
+// Tags win over field names, and fields of the outer struct over promoted ones.
+func structFields(t reflect.Type) map[string][]int {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	byName := make(map[string][]int)
+	var walk func(t reflect.Type, parent []int)
+	walk = func(t reflect.Type, parent []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			index := append(append([]int{}, parent...), i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				walk(field.Type, index)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+			name := ""
+			for _, tag := range []string{"db", "json"} {
+				if value, ok := field.Tag.Lookup(tag); ok {
+					name, _, _ = strings.Cut(value, ",")
+					break
+				}
+			}
+			if name == "-" {
+				continue
+			}
+			if name != "" {
+				if existing, exists := fields[strings.ToLower(name)]; !exists || len(existing) > len(index) {
+					fields[strings.ToLower(name)] = index
+				}
+				continue
+			}
+			if existing, exists := byName[strings.ToLower(field.Name)]; !exists || len(existing) > len(index) {
+				byName[strings.ToLower(field.Name)] = index
+			}
+		}
+	}
+	walk(t, nil)
+	for name, index := range byName {
+		if _, exists := fields[name]; !exists {
+			fields[name] = index
+		}
+	}
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02 15:04:05", "2006-01-02"}
+
+// assignValue sets a value read from a SQL driver into a struct field, converting it
+// to the field type when needed.
+func assignValue(dst reflect.Value, src interface{}) error {
+	if scanner, ok := dst.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if dst.Kind() == reflect.Ptr {
+		elem := reflect.New(dst.Type().Elem())
+		if err := assignValue(elem.Elem(), src); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+
+	srcValue := reflect.ValueOf(src)
+	if srcValue.Type().AssignableTo(dst.Type()) {
+		if b, ok := src.([]byte); ok {
+			// drivers may reuse the buffer
+			src = append([]byte{}, b...)
+			srcValue = reflect.ValueOf(src)
+		}
+		dst.Set(srcValue)
+		return nil
+	}
+
+	if b, ok := src.([]byte); ok {
+		src = string(b)
+		srcValue = reflect.ValueOf(src)
+	}
+	if s, ok := src.(string); ok {
+		return assignString(dst, s)
+	}
+
+	switch {
+	case dst.Kind() == reflect.Bool && isNumberKind(srcValue.Kind()):
+		dst.SetBool(!srcValue.IsZero())
+		return nil
+	case isNumberKind(dst.Kind()) && isNumberKind(srcValue.Kind()):
+		dst.Set(srcValue.Convert(dst.Type()))
+		return nil
+	case dst.Kind() == reflect.String && srcValue.Kind() == reflect.String:
+		dst.SetString(srcValue.String())
+		return nil
+	}
+	return fmt.Errorf("%T cannot be assigned to %s", src, dst.Type())
+}
+
+func assignString(dst reflect.Value, s string) error {
+	if dst.Type() == reflect.TypeOf(time.Time{}) {
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				dst.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("'%s' is not a time", s)
+	}
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(n)
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("string cannot be assigned to %s", dst.Type())
+		}
+		dst.SetBytes([]byte(s))
+	case reflect.Interface:
+		dst.Set(reflect.ValueOf(s))
+	default:
+		return fmt.Errorf("string cannot be assigned to %s", dst.Type())
+	}
+	return nil
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return (kind >= reflect.Int && kind <= reflect.Uint64) || kind == reflect.Float32 || kind == reflect.Float64
+}
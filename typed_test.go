@@ -0,0 +1,125 @@
+package tesoql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type typedAudit struct {
+	Id int64 `db:"id"`
+}
+
+type typedOrder struct {
+	typedAudit
+	ProductName string         `db:"product_name"`
+	Amount      float64        `json:"amount,omitempty"`
+	Paid        bool           `db:"paid"`
+	CreatedAt   *time.Time     `db:"created_at"`
+	Customer    sql.NullString `db:"customer"`
+	Status      string
+	Secret      string `db:"-"`
+}
+
+func TestDecodeRecord(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		record map[string]interface{}
+		want   typedOrder
+	}{
+		{
+			name:   "native values",
+			record: map[string]interface{}{"id": int64(7), "product_name": "Tea", "amount": 12.5, "paid": true, "created_at": created, "customer": "ali", "status": "shipped"},
+			want:   typedOrder{typedAudit: typedAudit{Id: 7}, ProductName: "Tea", Amount: 12.5, Paid: true, CreatedAt: &created, Customer: sql.NullString{String: "ali", Valid: true}, Status: "shipped"},
+		},
+		{
+			name:   "driver bytes and strings",
+			record: map[string]interface{}{"id": []byte("7"), "product_name": []byte("Tea"), "amount": "12.5", "paid": "true", "created_at": "2024-05-01 10:30:00"},
+			want:   typedOrder{typedAudit: typedAudit{Id: 7}, ProductName: "Tea", Amount: 12.5, Paid: true, CreatedAt: &created},
+		},
+		{
+			name:   "number conversions",
+			record: map[string]interface{}{"id": int32(7), "amount": int64(12), "paid": int64(1)},
+			want:   typedOrder{typedAudit: typedAudit{Id: 7}, Amount: 12, Paid: true},
+		},
+		{
+			name:   "nulls and unknown columns",
+			record: map[string]interface{}{"id": int64(7), "created_at": nil, "customer": nil, "warehouse": "north", "secret": "x"},
+			want:   typedOrder{typedAudit: typedAudit{Id: 7}},
+		},
+		{
+			name:   "case-insensitive names",
+			record: map[string]interface{}{"ID": int64(7), "STATUS": "shipped"},
+			want:   typedOrder{typedAudit: typedAudit{Id: 7}, Status: "shipped"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var order typedOrder
+			if err := decodeRecord(POSTGRES_ENGINE, tt.record, &order); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(order, tt.want) {
+				t.Errorf("order = %+v\nwant    %+v", order, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRecordErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		record map[string]interface{}
+		dest   interface{}
+	}{
+		{name: "not a time", record: map[string]interface{}{"created_at": "yesterday"}, dest: &typedOrder{}},
+		{name: "not a number", record: map[string]interface{}{"id": "seven"}, dest: &typedOrder{}},
+		{name: "not assignable", record: map[string]interface{}{"product_name": []int{1}}, dest: &typedOrder{}},
+		{name: "not a struct", record: map[string]interface{}{"id": 1}, dest: new(int)},
+		{name: "not a pointer", record: map[string]interface{}{"id": 1}, dest: typedOrder{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := decodeRecord(POSTGRES_ENGINE, tt.record, tt.dest); !errors.Is(err, ErrResultDecode) {
+				t.Errorf("decodeRecord() = %v, want ErrResultDecode", err)
+			}
+		})
+	}
+}
+
+func TestDecodeMongoRecords(t *testing.T) {
+	type document struct {
+		Id        primitive.ObjectID `bson:"_id"`
+		CreatedAt time.Time          `bson:"createdAt"`
+		Address   struct {
+			City string `bson:"city"`
+		} `bson:"address"`
+	}
+	id := primitive.NewObjectID()
+	created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	records := []map[string]interface{}{{"_id": id, "createdAt": primitive.NewDateTimeFromTime(created), "address": primitive.D{{Key: "city", Value: "Izmir"}}}}
+	documents, err := decodeRecords[*document](MONGO_ENGINE, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if documents[0].Id != id || !documents[0].CreatedAt.Equal(created) || documents[0].Address.City != "Izmir" {
+		t.Errorf("document = %+v", documents[0])
+	}
+}
+
+func TestQueryAs(t *testing.T) {
+	service, _ := newFakeService(&Config{}, 3)
+	orders, _, size, err := QueryAs[*typedOrder](context.Background(), service, &JsonMap{Pagination: Pagination{Limit: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 2 || len(orders) != 2 || orders[1].Id != 2 || orders[1].Status != "shipped" {
+		t.Errorf("orders = %+v, size = %d", orders, size)
+	}
+}
@@ -1,16 +1,30 @@
 package tesoql
 
+import "time"
+
 // JsonMap represents the structure for defining query parameters.
 // It includes search filters, projection fields, sorting conditions,
-// complex conditions, pagination, and options to control the response behavior.
+// complex conditions, a boolean filter tree, pagination, and options to control the response behavior.
 type JsonMap struct {
 	Search               map[string][]interface{}      `json:"search"`               // Search criteria mapped by field names.
+	SearchExclude        map[string][]interface{}      `json:"searchExclude"`        // Search values that must not match, mapped by field names.
+	SearchMatch          map[string]string             `json:"searchMatch"`          // Whether any (SEARCH_MATCH_ANY, the default) or all (SEARCH_MATCH_ALL) search values of a field have to match.
 	ProjectionFields     []string                      `json:"projectionFields"`     // Fields to include in the query result.
 	SortConditions       []SortInput                   `json:"sortConditions"`       // Sorting conditions for the query results.
 	Conditions           map[string]ConditionOperators `json:"conditions"`           // Complex conditions for filtering the data.
+	Filter               *Filter                       `json:"filter"`               // Boolean filter tree, ANDed with the other filters.
 	Pagination           Pagination                    `json:"pagination"`           // Pagination settings for limiting and offsetting the results.
 	TotalCount           bool                          `json:"totalCount"`           // Flag to determine whether to include the total count of records.
 	SuppressDataResponse bool                          `json:"suppressDataResponse"` // Flag to suppress the data response (useful for count-only queries).
+	Aggregations         *Aggregations                 `json:"aggregations"`         // Grouping and metrics, returning one record per group instead of the records.
+	Facets               []Facet                       `json:"facets"`               // Value and range counts computed over the same filters, returned next to the records.
+	DateHistogram        *DateHistogram                `json:"dateHistogram"`        // Record counts per time interval computed over the same filters, returned next to the records.
+	Distinct             *DistinctOptions              `json:"distinct"`             // Options of Service.Distinct, ignored by the other methods.
+	SortByRelevance      bool                          `json:"sortByRelevance"`      // Flag to sort by the relevance of the full-text search first.
+	Query                string                        `json:"q"`                    // Free text matched against FieldsMap.GlobalSearchFields, every word in one of them.
+
+	keyset        []interface{} // Position decoded from Pagination.Cursor, one value per sort column.
+	keysetColumns bool          // Whether the sort columns are projected as well, to build the next cursor from the results.
 }
 
 // ConditionOperators defines the various operators that can be applied
@@ -24,11 +38,145 @@ type ConditionOperators struct {
 	ValuesToExclude    []interface{} `json:"valuesToExclude"`    // Exclusion condition (array of values).
 }
 
+// Filter represents a node of a boolean filter tree. A node is either a logical
+// group (And, Or or Not) or a leaf predicate that applies ConditionOperators to a
+// condition field, exactly one of them has to be set.
+//
+// Example JSON: "status = shipped OR (amount > 100 AND quantity < 5)"
+//
+//	{"or": [
+//		{"field": "status", "operators": {"valuesToExactMatch": ["shipped"]}},
+//		{"and": [
+//			{"field": "amount", "operators": {"greaterThan": 100}},
+//			{"field": "quantity", "operators": {"lowerThan": 5}}
+//		]}
+//	]}
+type Filter struct {
+	And       []Filter            `json:"and"`       // Nodes that all have to match.
+	Or        []Filter            `json:"or"`        // Nodes of which at least one has to match.
+	Not       *Filter             `json:"not"`       // Node that must not match.
+	Field     string              `json:"field"`     // Condition field of a leaf predicate.
+	Operators *ConditionOperators `json:"operators"` // Operators of a leaf predicate.
+}
+
+// Aggregations groups the records matching the filters by the GroupBy fields and computes
+// the Metrics of every group, one record per group holding the group fields and the
+// metrics under their names. Sorting and pagination apply to the groups, and sort on
+// these names. Without GroupBy, the metrics are computed over all matching records.
+//
+// Example JSON: "revenue per status"
+//
+//	{"groupBy": ["status"], "metrics": [
+//		{"function": "SUM", "field": "amount", "alias": "revenue"},
+//		{"function": "COUNT"}
+//	]}
+type Aggregations struct {
+	GroupBy []string `json:"groupBy"` // Aggregation fields to group by.
+	Metrics []Metric `json:"metrics"` // Metrics computed for every group.
+}
+
+// Metric is an aggregate function applied to an aggregation field.
+type Metric struct {
+	Function string `json:"function"` // "COUNT", "SUM", "AVG", "MIN" or "MAX".
+	Field    string `json:"field"`    // Aggregation field, optional for COUNT which then counts the records.
+	Alias    string `json:"alias"`    // Name of the metric in the results, "count" or "<function>_<field>" in lower case by default.
+}
+
+// Facet requests the number of records matching the filters per value of a facet field,
+// the most frequent first, or per range of values when Ranges are given. A disjunctive
+// facet ignores the conditions on its own field, so that the counts of the other values
+// remain visible while one of them is selected.
+//
+// Example JSON: "orders per status, and per amount range"
+//
+//	{"facets": [
+//		{"field": "status", "disjunctive": true},
+//		{"field": "amount", "ranges": [{"to": 100}, {"from": 100, "to": 500}, {"from": 500}]}
+//	]}
+type Facet struct {
+	Field       string       `json:"field"`       // Facet field to count the records of.
+	Name        string       `json:"name"`        // Name of the facet in the results, Field by default.
+	Ranges      []FacetRange `json:"ranges"`      // Ranges to count the records of, instead of the values.
+	Limit       int          `json:"limit"`       // Maximum number of values of a value facet, DEFAULT_FACET_LIMIT when zero.
+	Disjunctive bool         `json:"disjunctive"` // Flag to ignore the conditions on the facet field.
+}
+
+// FacetRange is a range of a range facet, From included and To excluded. One of the
+// bounds can be omitted to leave the range open.
+type FacetRange struct {
+	Key  string      `json:"key"`  // Name of the range in the results, "<from>-<to>" by default, "*" standing for a missing bound.
+	From interface{} `json:"from"` // Lower bound, included.
+	To   interface{} `json:"to"`   // Upper bound, excluded.
+}
+
+// FacetBucket is the number of records of a facet value or range.
+type FacetBucket struct {
+	Value interface{} `json:"value"` // The field value, or the key of the range.
+	Count int         `json:"count"` // The number of matching records.
+}
+
+// DistinctOptions tunes the values returned by Service.Distinct.
+type DistinctOptions struct {
+	Prefix string `json:"prefix"` // Prefix the values start with, for typeahead lookups.
+	Limit  int    `json:"limit"`  // Maximum number of values, DEFAULT_DISTINCT_LIMIT when zero.
+	Counts bool   `json:"counts"` // Flag to return the number of matching records of every value.
+}
+
+// DistinctValue is a distinct value of a field.
+type DistinctValue struct {
+	Value interface{} `json:"value"`           // The field value.
+	Count int         `json:"count,omitempty"` // The number of matching records, when DistinctOptions.Counts is set.
+}
+
+// DateHistogram counts the records matching the filters per interval of a date field,
+// and optionally computes metrics for every interval. The time range is the one of the
+// conditions on the date field: with FillEmpty, the intervals of the range without records
+// are returned with a zero count.
+//
+// Example JSON: "orders and revenue per day of January, in Istanbul time"
+//
+//	{"conditions": {"created": {"greaterOrEqual": "2024-01-01T00:00:00+03:00", "lowerThan": "2024-02-01T00:00:00+03:00"}},
+//	 "dateHistogram": {"field": "created", "interval": "day", "timeZone": "Europe/Istanbul",
+//		"metrics": [{"function": "SUM", "field": "amount", "alias": "revenue"}], "fillEmpty": true}}
+type DateHistogram struct {
+	Field     string   `json:"field"`     // Date field of FieldsMap.DateTimeFieldKeys.
+	Interval  string   `json:"interval"`  // One of the INTERVAL_* intervals: "minute", "hour", "day", "week", "month", "quarter" or "year".
+	TimeZone  string   `json:"timeZone"`  // IANA time zone name or "+hh:mm" offset the intervals start in, UTC when empty.
+	Metrics   []Metric `json:"metrics"`   // Metrics computed for every interval, on aggregation fields.
+	FillEmpty bool     `json:"fillEmpty"` // Flag to return the intervals without records too.
+}
+
+// HistogramBucket is an interval of a date histogram.
+type HistogramBucket struct {
+	Start   time.Time              `json:"start"`             // The start of the interval, in the time zone of the histogram.
+	Count   int                    `json:"count"`             // The number of matching records.
+	Metrics map[string]interface{} `json:"metrics,omitempty"` // The metrics of the interval by name, nil for an empty interval.
+}
+
 // Pagination defines the structure for paginating query results.
-// It includes settings for limiting the number of results and skipping a certain number of records.
+// It includes settings for limiting the number of results and skipping a certain number of records,
+// or continuing after the last record of a previous page with a cursor.
 type Pagination struct {
-	Limit  int64 `json:"limit"`  // Maximum number of results to return.
-	Offset int64 `json:"offset"` // Number of results to skip before starting to return results.
+	Limit  int64  `json:"limit"`            // Maximum number of results to return.
+	Offset int64  `json:"offset"`           // Number of results to skip before starting to return results.
+	Cursor string `json:"cursor,omitempty"` // Opaque cursor of the previous page (see Service.NextCursor), replaces Offset.
+}
+
+// Result is the envelope of a page of data returned by Service.Query.
+// The Next and Previous page descriptors are ready to be sent back as JsonMap.Pagination.
+type Result struct {
+	Items      []map[string]interface{} `json:"items"`               // The records of the page.
+	Size       int                      `json:"size"`                // The number of records of the page.
+	TotalCount *int                     `json:"totalCount"`          // The number of records matching the query, nil when not requested or disabled.
+	HasMore    bool                     `json:"hasMore"`             // Whether another page follows.
+	Limit      int64                    `json:"limit"`               // The limit used, after clamping with the PaginationConfig.
+	Offset     int64                    `json:"offset"`              // The offset used, after clamping with the PaginationConfig, 0 with a cursor.
+	Next       *Pagination              `json:"next,omitempty"`      // The pagination of the next page, nil on the last page.
+	Previous   *Pagination              `json:"previous,omitempty"`  // The pagination of the previous page, nil on the first page or with a cursor.
+	Duration   time.Duration            `json:"duration"`            // The time the query took.
+	Warnings   []string                 `json:"warnings,omitempty"`  // Non-fatal issues, such as a clamped limit.
+	Facets     map[string][]FacetBucket `json:"facets,omitempty"`    // The buckets of the requested facets, by facet name.
+	Histogram  []HistogramBucket        `json:"histogram,omitempty"` // The buckets of the requested date histogram.
 }
 
 // SortInput defines the structure for specifying sorting behavior in a query.
@@ -39,9 +187,13 @@ type SortInput struct {
 }
 
 // ErrorResponseDTO is used to represent errors that occur during query processing.
-// It includes the type of error, a descriptive message, and an error code.
+// It includes the type of error, a descriptive message, an error code, the JsonMap
+// field the error is about and the underlying error. It implements the error interface
+// and matches the sentinel errors (ErrSortable...) with errors.Is.
 type ErrorResponseDTO struct {
-	ErrorType string // The type of error (e.g., validation error, repository error).
-	ErrorMsg  string // A detailed error message.
-	ErrorCode int    // A numeric code representing the specific error.
+	ErrorType string `json:"ErrorType"`       // The type of error (e.g., validation error, repository error).
+	ErrorMsg  string `json:"ErrorMsg"`        // A detailed error message.
+	ErrorCode int    `json:"ErrorCode"`       // A numeric code representing the specific error.
+	Field     string `json:"Field,omitempty"` // The JsonMap field that failed, as a dotted JSON path (e.g. "sortConditions.amount").
+	Cause     error  `json:"-"`               // The underlying error of the database driver or of the context, if any.
 }
@@ -5,7 +5,7 @@ import (
 )
 
 // Validate performs a series of checks on the JsonMap instance to ensure
-// that the search, projection, sorting, and pagination settings are valid
+// that the search, projection, sorting, filter, aggregation, facet, date histogram, pagination and cursor settings are valid
 // according to the provided FieldsMap and PaginationConfig.
 //
 // It validates search fields, projection fields, sorting conditions, and
@@ -37,35 +37,69 @@ func (jm *JsonMap) Validate(cfg *Config) *ErrorResponseDTO {
 		return err
 	}
 
+	err = jm.validateFilter(cfg.FieldsMap, cfg.FilterLimits)
+	if err != nil {
+		return err
+	}
+
+	err = jm.validateAggregations(cfg.FieldsMap)
+	if err != nil {
+		return err
+	}
+
+	err = jm.validateFacets(cfg.FieldsMap)
+	if err != nil {
+		return err
+	}
+
+	err = jm.validateDateHistogram(cfg.FieldsMap)
+	if err != nil {
+		return err
+	}
+
 	err = jm.validateSorting(cfg.FieldsMap)
 	if err != nil {
 		return err
 	}
 
+	err = jm.validateRelevance(cfg.FieldsMap)
+	if err != nil {
+		return err
+	}
+
 	jm.validatePagination(cfg.Pagination)
 
+	err = jm.resolveCursor(cfg.FieldsMap, cfg.CursorSigningKey)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // validateSorting checks if the sorting fields specified in the JsonMap
 // exist in the FieldsMap and ensures the sorting condition is either "ASC" or "DESC".
+// The sorting of aggregations is checked by validateAggregations.
 //
 // Returns:
 //
 // - *ErrorResponseDTO: An error response if validation fails, or nil if validation passes.
 func (jm *JsonMap) validateSorting(fm *FieldsMap) *ErrorResponseDTO {
+	if jm.Aggregations != nil {
+		return nil
+	}
 	for _, sortInput := range jm.SortConditions {
 		if _, exists := fm.SortingFields[sortInput.Field]; !exists {
 			return newResponse(
 				TESOQL_VALIDATION_ERROR,
 				fmt.Sprintf("Field : '%v' is not sortable.", sortInput.Field),
-				SORTABLE_ERR_CODE)
+				SORTABLE_ERR_CODE).withField("sortConditions." + sortInput.Field)
 		}
 		if sortInput.SortCondition != "ASC" && sortInput.SortCondition != "DESC" {
 			return newResponse(
 				TESOQL_VALIDATION_ERROR,
 				"Sort condition operators cannot be different than 'ASC' or 'DESC' (type : string)!",
-				SORTABLE_ERR_CODE)
+				SORTABLE_ERR_CODE).withField("sortConditions." + sortInput.Field)
 		}
 
 	}
@@ -107,10 +141,19 @@ func (jm *JsonMap) validateSearchAndProjection(fm *FieldsMap) *ErrorResponseDTO
 				return newResponse(
 					TESOQL_VALIDATION_ERROR,
 					fmt.Sprintf("Field : '%v' is not searchable.", field),
-					SEARCHABLE_ERR_CODE)
+					SEARCHABLE_ERR_CODE).withField("search." + field)
 			}
 		}
 	}
+	if err := jm.validateSearchOptions(fm); err != nil {
+		return err
+	}
+	if err := jm.validateSearchRegex(fm); err != nil {
+		return err
+	}
+	if err := jm.validateQuery(fm); err != nil {
+		return err
+	}
 
 	if fm.ProjectionFields != nil && jm.ProjectionFields != nil {
 		for _, field := range jm.ProjectionFields {
@@ -118,7 +161,7 @@ func (jm *JsonMap) validateSearchAndProjection(fm *FieldsMap) *ErrorResponseDTO
 				return newResponse(
 					TESOQL_VALIDATION_ERROR,
 					fmt.Sprintf("Field : '%v' is not compatible for to apply projectioning.", field),
-					PROJECTION_ERR_CODE)
+					PROJECTION_ERR_CODE).withField("projectionFields." + field)
 			}
 		}
 	}
@@ -138,10 +181,109 @@ func (jm *JsonMap) validateConditions(fm *FieldsMap) *ErrorResponseDTO {
 				return newResponse(
 					TESOQL_VALIDATION_ERROR,
 					fmt.Sprintf("Field : '%v' is not compatible to apply a condition query.", field),
-					CONDITION_ERR_CODE)
+					CONDITION_ERR_CODE).withField("conditions." + field)
 			}
 
 		}
 	}
 	return nil
 }
+
+// validateFilter checks that every node of the filter tree in the JsonMap is either a
+// logical group or a leaf predicate, that leaf predicates use fields compatible to
+// apply a condition query (skipped when the FieldsMap is nil), and that the tree does
+// not exceed the depth and node limits.
+//
+// Returns:
+//
+// - *ErrorResponseDTO: An error response if validation fails, or nil if validation passes.
+func (jm *JsonMap) validateFilter(fm *FieldsMap, limits *FilterLimits) *ErrorResponseDTO {
+	if jm.Filter == nil {
+		return nil
+	}
+	maxDepth, maxNodes := DEFAULT_FILTER_MAX_DEPTH, DEFAULT_FILTER_MAX_NODES
+	if limits != nil && limits.MaxDepth > 0 {
+		maxDepth = limits.MaxDepth
+	}
+	if limits != nil && limits.MaxNodes > 0 {
+		maxNodes = limits.MaxNodes
+	}
+	nodeCount := 0
+	return validateFilterNode(jm.Filter, fm, 1, maxDepth, maxNodes, &nodeCount)
+}
+
+func validateFilterNode(node *Filter, fm *FieldsMap, depth int, maxDepth int, maxNodes int, nodeCount *int) *ErrorResponseDTO {
+	*nodeCount++
+	if *nodeCount > maxNodes {
+		return newResponse(
+			TESOQL_VALIDATION_ERROR,
+			fmt.Sprintf("Filter cannot have more than %d nodes.", maxNodes),
+			FILTER_ERR_CODE).withField("filter")
+	}
+	if depth > maxDepth {
+		return newResponse(
+			TESOQL_VALIDATION_ERROR,
+			fmt.Sprintf("Filter cannot be nested deeper than %d levels.", maxDepth),
+			FILTER_ERR_CODE).withField("filter")
+	}
+
+	kinds := 0
+	for _, isSet := range []bool{node.And != nil, node.Or != nil, node.Not != nil, node.Field != "" || node.Operators != nil} {
+		if isSet {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return newResponse(
+			TESOQL_VALIDATION_ERROR,
+			"Filter node must be exactly one of 'and', 'or', 'not' or a 'field' with 'operators'.",
+			FILTER_ERR_CODE).withField("filter")
+	}
+
+	var children []Filter
+	switch {
+	case node.And != nil:
+		children = node.And
+	case node.Or != nil:
+		children = node.Or
+	case node.Not != nil:
+		return validateFilterNode(node.Not, fm, depth+1, maxDepth, maxNodes, nodeCount)
+	default:
+		return validateFilterLeaf(node, fm)
+	}
+	if len(children) == 0 {
+		return newResponse(
+			TESOQL_VALIDATION_ERROR,
+			"Filter groups ('and', 'or') cannot be empty.",
+			FILTER_ERR_CODE).withField("filter")
+	}
+	for i := range children {
+		if err := validateFilterNode(&children[i], fm, depth+1, maxDepth, maxNodes, nodeCount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateFilterLeaf(node *Filter, fm *FieldsMap) *ErrorResponseDTO {
+	if node.Field == "" || node.Operators == nil || isEmptyConditionOperators(node.Operators) {
+		return newResponse(
+			TESOQL_VALIDATION_ERROR,
+			"Filter predicates must have a 'field' and at least one operator.",
+			FILTER_ERR_CODE).withField("filter")
+	}
+	if fm != nil && fm.ConditionFields != nil {
+		if _, exists := fm.ConditionFields[node.Field]; !exists {
+			return newResponse(
+				TESOQL_VALIDATION_ERROR,
+				fmt.Sprintf("Field : '%v' is not compatible to apply a condition query.", node.Field),
+				CONDITION_ERR_CODE).withField("filter." + node.Field)
+		}
+	}
+	return nil
+}
+
+func isEmptyConditionOperators(ops *ConditionOperators) bool {
+	return ops.GreaterThan == nil && ops.GreaterOrEqual == nil && ops.LowerThan == nil && ops.LowerOrEqual == nil &&
+		len(ops.ValuesToExactMatch) == 0 && len(ops.ValuesToExclude) == 0
+}
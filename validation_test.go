@@ -0,0 +1,36 @@
+package tesoql
+
+import "testing"
+
+func TestValidateFilter(t *testing.T) {
+	fm := &FieldsMap{ConditionFields: map[string]string{"status": "status", "amount": "amount"}}
+	leaf := Filter{Field: "amount", Operators: &ConditionOperators{GreaterThan: 1}}
+	tests := []struct {
+		name   string
+		filter *Filter
+		limits *FilterLimits
+		code   int
+	}{
+		{name: "valid", filter: &Filter{Or: []Filter{leaf, {Not: &leaf}}}},
+		{name: "empty group", filter: &Filter{And: []Filter{}}, code: FILTER_ERR_CODE},
+		{name: "two kinds", filter: &Filter{And: []Filter{leaf}, Not: &leaf}, code: FILTER_ERR_CODE},
+		{name: "no operator", filter: &Filter{Field: "amount", Operators: &ConditionOperators{}}, code: FILTER_ERR_CODE},
+		{name: "unknown field", filter: &Filter{Field: "secret", Operators: &ConditionOperators{GreaterThan: 1}}, code: CONDITION_ERR_CODE},
+		{name: "too deep", filter: &Filter{Not: &Filter{Not: &leaf}}, limits: &FilterLimits{MaxDepth: 2}, code: FILTER_ERR_CODE},
+		{name: "too many nodes", filter: &Filter{And: []Filter{leaf, leaf, leaf}}, limits: &FilterLimits{MaxNodes: 3}, code: FILTER_ERR_CODE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&JsonMap{Filter: tt.filter}).validateFilter(fm, tt.limits)
+			if tt.code == 0 {
+				if err != nil {
+					t.Errorf("validateFilter() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.ErrorCode != tt.code {
+				t.Errorf("validateFilter() = %v, want code %d", err, tt.code)
+			}
+		})
+	}
+}